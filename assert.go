@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Assertion types
+const (
+	assertStatus     = "status"
+	assertHeader     = "header"
+	assertJSONPath   = "json_path"
+	assertBodyRegex  = "body_regex"
+	assertMaxTime    = "max_time_ms"
+	assertJSONSchema = "json_schema"
+)
+
+// Assertion is a declarative check evaluated against every response.
+// Name holds the header name or JSON path, Value the expected value.
+type Assertion struct {
+	Type   string          `json:"type"`
+	Name   string          `json:"name,omitempty"`
+	Value  string          `json:"value,omitempty"`
+	Schema json.RawMessage `json:"schema,omitempty"`
+}
+
+// AssertionResult is the outcome of evaluating a single assertion
+type AssertionResult struct {
+	Assertion Assertion `json:"assertion"`
+	Passed    bool      `json:"passed"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// String describes the assertion in a single line
+func (a Assertion) String() string {
+	switch a.Type {
+	case assertStatus:
+		return fmt.Sprintf("status == %s", a.Value)
+	case assertHeader:
+		if a.Value == "" {
+			return fmt.Sprintf("header %s present", a.Name)
+		}
+		return fmt.Sprintf("header %s == %s", a.Name, a.Value)
+	case assertJSONPath:
+		return fmt.Sprintf("%s == %s", a.Name, a.Value)
+	case assertBodyRegex:
+		return fmt.Sprintf("body matches /%s/", a.Value)
+	case assertMaxTime:
+		return fmt.Sprintf("response time < %sms", a.Value)
+	case assertJSONSchema:
+		return "body matches JSON schema"
+	default:
+		return a.Type
+	}
+}
+
+// evaluateAssertions runs every assertion against the response
+func evaluateAssertions(assertions []Assertion, resp HTTPResponse) []AssertionResult {
+	var results []AssertionResult
+	for _, a := range assertions {
+		err := evaluateAssertion(a, resp)
+		result := AssertionResult{Assertion: a, Passed: err == nil}
+		if err != nil {
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// assertionsPassed reports whether every result passed
+func assertionsPassed(results []AssertionResult) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func evaluateAssertion(a Assertion, resp HTTPResponse) error {
+	switch a.Type {
+	case assertStatus:
+		want, err := strconv.Atoi(strings.TrimSpace(a.Value))
+		if err != nil {
+			return fmt.Errorf("invalid status %q", a.Value)
+		}
+		if resp.StatusCode != want {
+			return fmt.Errorf("got status %d", resp.StatusCode)
+		}
+
+	case assertHeader:
+		value, ok := lookupHeader(resp.Headers, a.Name)
+		if !ok {
+			return fmt.Errorf("header %s missing", a.Name)
+		}
+		if a.Value != "" && value != a.Value {
+			return fmt.Errorf("got %q", value)
+		}
+
+	case assertJSONPath:
+		var doc any
+		if err := json.Unmarshal([]byte(resp.Body), &doc); err != nil {
+			return fmt.Errorf("body is not JSON: %v", err)
+		}
+		got, ok := lookupJSONPath(doc, a.Name)
+		if !ok {
+			return fmt.Errorf("path %s not found", a.Name)
+		}
+		if !reflect.DeepEqual(got, parseExpectedValue(a.Value)) {
+			return fmt.Errorf("got %s", formatJSONValue(got))
+		}
+
+	case assertBodyRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %v", err)
+		}
+		if !re.MatchString(resp.Body) {
+			return fmt.Errorf("body does not match")
+		}
+
+	case assertMaxTime:
+		limit, err := strconv.ParseInt(strings.TrimSpace(a.Value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid time limit %q", a.Value)
+		}
+		if resp.Duration.Milliseconds() >= limit {
+			return fmt.Errorf("took %dms", resp.Duration.Milliseconds())
+		}
+
+	case assertJSONSchema:
+		var schema, doc any
+		if err := json.Unmarshal(a.Schema, &schema); err != nil {
+			return fmt.Errorf("invalid schema: %v", err)
+		}
+		if err := json.Unmarshal([]byte(resp.Body), &doc); err != nil {
+			return fmt.Errorf("body is not JSON: %v", err)
+		}
+		if violations := validateSchema(schema, doc); len(violations) > 0 {
+			return fmt.Errorf("%s", strings.Join(violations, "; "))
+		}
+
+	default:
+		return fmt.Errorf("unknown assertion type %q", a.Type)
+	}
+
+	return nil
+}
+
+// lookupHeader finds a header value ignoring the case of its name
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// parseExpectedValue interprets an expected value as JSON, falling back to a plain string
+func parseExpectedValue(s string) any {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	return v
+}
+
+func formatJSONValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// splitJSONPath splits a path like $.items[0].name into its segments
+func splitJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			open := strings.Index(part, "[")
+			if open < 0 {
+				segments = append(segments, part)
+				break
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			end := strings.Index(part[open:], "]")
+			if end < 0 {
+				segments = append(segments, part[open+1:])
+				break
+			}
+			segments = append(segments, strings.Trim(part[open+1:open+end], `'"`))
+			part = part[open+end+1:]
+		}
+	}
+	return segments
+}
+
+// lookupJSONPath resolves a dotted path with optional [index] segments in a decoded JSON document
+func lookupJSONPath(doc any, path string) (any, bool) {
+	current := doc
+	for _, segment := range splitJSONPath(path) {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			current = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestEvaluateAssertions tests that each assertion type passes and fails as expected
+func TestEvaluateAssertions(t *testing.T) {
+	resp := HTTPResponse{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"user": {"name": "ada", "roles": ["admin", "dev"]}, "count": 2}`,
+		Duration:   120 * time.Millisecond,
+	}
+
+	tests := []struct {
+		assertion Assertion
+		passed    bool
+	}{
+		{Assertion{Type: assertStatus, Value: "200"}, true},
+		{Assertion{Type: assertStatus, Value: "404"}, false},
+		{Assertion{Type: assertHeader, Name: "content-type"}, true},
+		{Assertion{Type: assertHeader, Name: "Content-Type", Value: "text/plain"}, false},
+		{Assertion{Type: assertHeader, Name: "X-Missing"}, false},
+		{Assertion{Type: assertJSONPath, Name: "$.user.name", Value: "ada"}, true},
+		{Assertion{Type: assertJSONPath, Name: "$.user.roles[1]", Value: `"dev"`}, true},
+		{Assertion{Type: assertJSONPath, Name: "count", Value: "2"}, true},
+		{Assertion{Type: assertJSONPath, Name: "$.count", Value: "3"}, false},
+		{Assertion{Type: assertJSONPath, Name: "$.missing", Value: "1"}, false},
+		{Assertion{Type: assertBodyRegex, Value: `"name":\s*"ada"`}, true},
+		{Assertion{Type: assertBodyRegex, Value: `bob`}, false},
+		{Assertion{Type: assertMaxTime, Value: "500"}, true},
+		{Assertion{Type: assertMaxTime, Value: "100"}, false},
+		{Assertion{Type: assertJSONSchema, Schema: json.RawMessage(`{"type": "object", "required": ["user", "count"]}`)}, true},
+		{Assertion{Type: assertJSONSchema, Schema: json.RawMessage(`{"properties": {"count": {"type": "string"}}}`)}, false},
+		{Assertion{Type: "unknown"}, false},
+	}
+
+	for _, tt := range tests {
+		results := evaluateAssertions([]Assertion{tt.assertion}, resp)
+		if len(results) != 1 {
+			t.Fatalf("Expected 1 result, got %d", len(results))
+		}
+		if results[0].Passed != tt.passed {
+			t.Errorf("%s: expected passed=%v, got %v (%s)", tt.assertion, tt.passed, results[0].Passed, results[0].Message)
+		}
+	}
+}
+
+// TestLookupJSONPath tests that dotted and indexed paths resolve in nested documents
+func TestLookupJSONPath(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{"items": [{"id": 1}, {"id": 2, "tags": ["a"]}]}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{"$.items[1].id", float64(2), true},
+		{"items.0.id", float64(1), true},
+		{"$.items[1].tags[0]", "a", true},
+		{"$['items'][0]['id']", float64(1), true},
+		{"$.items[5]", nil, false},
+		{"$.nope", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := lookupJSONPath(doc, tt.path)
+		if ok != tt.found || got != tt.want {
+			t.Errorf("lookupJSONPath(%q) = %v, %v; expected %v, %v", tt.path, got, ok, tt.want, tt.found)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const cliUsage = `Usage:
+  whelm [--workspace DIR] [command]
+  whelm                 Start the interactive client
+  whelm init            Create a project workspace (.whelm/) in the current directory
+  whelm run [flags] [name...]
+                        Run saved requests and check their assertions
+  whelm import <har|insomnia|openapi|postman> [flags] FILE...
+                        Import collections and environments as saved requests.
+                        Re-importing an OpenAPI spec updates its requests.
+  whelm export har [-o FILE] [-domain HOST] [-method METHOD] [-last N]
+                        Export history as HAR 1.2
+  whelm mock [flags]    Serve the mock responses or snapshots of saved
+                        requests as a fake backend
+  whelm listen [flags]  Start the interactive client catching webhooks
+  whelm proxy [flags]   Run a forward HTTP proxy that records every exchange
+                        into history, where it can be opened and re-sent
+  whelm load [flags] NAME
+                        Load test a saved request and print throughput,
+                        status codes and latency percentiles
+  whelm migrate [-dir DIR] [-to yaml|json] [-sidecar]
+                        Convert saved requests to YAML (or back to JSON).
+                        -sidecar moves multi-line bodies into .body.* files
+                        next to their request. New requests are saved as YAML
+                        once any request is.
+
+Mock flags:
+  -addr ADDR            Address to listen on (default localhost:8080)
+  -dir DIR, -folder PATH, -env NAME
+                        Requests to serve and the environment resolving
+                        their URLs, as for run
+  -latency DURATION     Delay every response, e.g. 200ms
+  -error-rate RATE      Answer this fraction of requests with an error
+  -log                  Print hits instead of showing the mock panel
+
+  A request's "mock" field sets the status, headers and body it is served
+  with; bodies are Go templates with .Params, .Query, .Headers, .Body and
+  .JSON of the incoming request and the now, uuid and randInt functions.
+  "match_headers", "delay_ms", "error_rate" and "error_status" tune a
+  single route. Requests without a mock field serve their snapshot.
+
+Listen flags:
+  -addr ADDR            Address to listen on (default localhost:9000)
+  -status CODE          Status answered to every webhook (default 200)
+  -header "Name: value" Header of the answer (repeatable)
+  -body TEXT            Body of the answer
+  -responses DIR        Saved requests whose mock responses answer the
+                        webhooks they match, as for whelm mock
+
+Proxy flags:
+  -addr ADDR            Address to listen on (default localhost:8888)
+  -domain HOST          Only record requests to HOST or its subdomains (repeatable)
+  -exclude HOST         Never record requests to HOST or its subdomains (repeatable)
+  -mitm                 Decrypt HTTPS to recorded hosts with a locally generated
+                        CA, which clients must trust. Other HTTPS is tunnelled.
+  -ca DIR               Where the CA is kept (default ca/ in the personal workspace)
+
+Load flags:
+  -n N                  Number of requests to send (default 100 unless -d is given)
+  -d DURATION           Keep sending requests for this long, e.g. 30s
+  -c N                  Requests in flight at once (default 10)
+  -rate N               Maximum requests per second (default unlimited)
+  -dir DIR, -env NAME   Requests and environment, as for run
+  -json FILE            Write the statistics and every sample as JSON
+  -csv FILE             Write every sample as CSV
+  Exits non-zero when a request fails or gets a 5xx response.
+
+Workspaces:
+  Requests, environments and history are kept in the nearest .whelm/
+  directory above the current one, or in $XDG_DATA_HOME/whelm (default
+  ~/.local/share/whelm) outside a project. --workspace DIR overrides the
+  search. Personal requests and environments stay visible inside a project;
+  move a request to ~personal/ to keep it out of the project.
+
+Configuration:
+  The interactive client reads $XDG_CONFIG_HOME/whelm/config.json (default
+  ~/.config/whelm/config.json). "keymap" picks the default or vim bindings
+  and "keys" rebinds actions by name, e.g.
+    {"keymap": "vim", "keys": {"editor.send": ["ctrl+r"], "tabs.close": []}}
+  "theme" is auto (matching the terminal background), dark, light,
+  high-contrast or a theme of your own under "themes", e.g.
+    {"theme": "mine", "themes": {"mine": {"base": "dark", "accent": "#ff79c6"}}}
+  alt+e opens the request body, headers or response in $VISUAL or $EDITOR.
+
+Run flags:
+  -dir DIR              Directory of saved requests to run (default the
+                        workspace's requests), or a single .http file
+  -folder PATH          Only run requests in this folder and its subfolders
+  -env NAME             Environment whose variables are substituted
+  -concurrency N        Maximum number of requests in flight (default 1)
+  -fail-fast            Stop after the first failing request
+  -junit FILE           Write a JUnit XML report
+  -json FILE            Write a JSON report
+  -spec FILE            Check responses against an OpenAPI spec, overriding
+                        the spec set in folder.json
+  -snapshot             Compare responses with the .snap.json snapshot next to
+                        each request, writing missing snapshots. Values at the
+                        request's "ignore" paths are redacted.
+  -update               Like -snapshot, but overwrite snapshots that changed
+  -ci                   Like -snapshot, but fail requests that have no snapshot
+                        instead of writing one
+
+Import flags:
+  -into FOLDER          Folder to import into (default from the collection name)
+  -history              Import HAR exchanges into history instead of saved requests
+  -domain HOST          Only import requests to HOST or its subdomains (repeatable)
+  -method METHOD        Only import requests with METHOD (repeatable)
+`
+
+// runCLI dispatches a subcommand and returns the process exit code
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "run":
+		return runCommand(args[1:], stdout, stderr)
+	case "import":
+		return importCommand(args[1:], stdout, stderr)
+	case "export":
+		return exportCommand(args[1:], stdout, stderr)
+	case "migrate":
+		return migrateCommand(args[1:], stdout, stderr)
+	case "init":
+		return initCommand(args[1:], stdout, stderr)
+	case "mock":
+		return mockCommand(args[1:], stdout, stderr)
+	case "listen":
+		return listenCommand(args[1:], stdout, stderr)
+	case "proxy":
+		return proxyCommand(args[1:], stdout, stderr)
+	case "load":
+		return loadCommand(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, cliUsage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], cliUsage)
+		return 2
+	}
+}
+
+// runCommand runs a collection of saved requests (all of them, or the named
+// ones in the given order) and exits non-zero when a request fails, any of
+// its assertions do not pass, its response violates the OpenAPI spec or it
+// no longer matches its snapshot
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", requestsDir, "directory of saved requests to run")
+	folder := fs.String("folder", "", "only run requests in this folder")
+	envName := fs.String("env", "", "environment to run against")
+	concurrency := fs.Int("concurrency", 1, "maximum number of requests in flight")
+	failFast := fs.Bool("fail-fast", false, "stop starting requests after the first failure")
+	junitPath := fs.String("junit", "", "write a JUnit XML report to this file")
+	jsonPath := fs.String("json", "", "write a JSON report to this file")
+	specPath := fs.String("spec", "", "validate responses against this OpenAPI spec")
+	snapshot := fs.Bool("snapshot", false, "compare responses with their snapshots")
+	update := fs.Bool("update", false, "write snapshots for changed responses")
+	ci := fs.Bool("ci", false, "compare responses with their snapshots, failing when one is missing")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *ci && *update {
+		fmt.Fprintf(stderr, "error: -ci and -update cannot be combined\n")
+		return 2
+	}
+
+	all, folders, err := readRequestTree(*dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *specPath != "" {
+		if _, err := loadCachedSpec(*specPath); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	var saved []HTTPRequest
+	for _, req := range all {
+		if inFolder(req.Folder, *folder) {
+			req = inheritFolderSettings(req, folders)
+			if *specPath != "" {
+				req.Spec = *specPath
+			}
+			saved = append(saved, req)
+		}
+	}
+
+	requests := saved
+	if fs.NArg() > 0 {
+		requests = nil
+		for _, name := range fs.Args() {
+			req, ok := findRequest(saved, name)
+			if !ok {
+				fmt.Fprintf(stderr, "error: no saved request named %q\n", name)
+				return 1
+			}
+			requests = append(requests, req)
+		}
+	}
+
+	opts := RunOptions{Concurrency: *concurrency, StopOnFailure: *failFast}
+	switch {
+	case *update:
+		opts.Snapshots = snapshotUpdate
+	case *ci:
+		opts.Snapshots = snapshotCI
+	case *snapshot:
+		opts.Snapshots = snapshotCheck
+	}
+	if *envName != "" {
+		envs, err := readEnvironments()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		env, ok := findEnvironment(envs, *envName)
+		if !ok {
+			fmt.Fprintf(stderr, "error: no environment named %q\n", *envName)
+			return 1
+		}
+		opts.Environment = env
+	}
+
+	name := filepath.Base(*dir)
+	if *folder != "" {
+		name = *folder
+	}
+	result := runCollection(name, requests, opts)
+	printCollectionResult(stdout, result)
+
+	if *junitPath != "" {
+		if err := writeReportFile(*junitPath, result, writeJUnitReport); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeReportFile(*jsonPath, result, writeJSONReport); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	if _, failed, _ := result.Counts(); failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printCollectionResult prints one line per request followed by a summary
+func printCollectionResult(w io.Writer, result CollectionResult) {
+	for _, r := range result.Results {
+		req := r.Request
+		switch {
+		case r.Skipped:
+			fmt.Fprintf(w, "SKIP %s %s %s\n", req.Name, req.Method, req.URL)
+			continue
+		case r.Error != "" && r.Response.StatusCode == 0:
+			fmt.Fprintf(w, "FAIL %s %s %s: %s\n", req.Name, req.Method, req.URL, r.Error)
+			continue
+		}
+
+		status := "PASS"
+		if !r.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s %s %s %s -> %d (%dms)\n", status, req.Name, req.Method, req.URL, r.Response.StatusCode, r.Response.Duration.Milliseconds())
+		for _, a := range r.Response.Assertions {
+			if a.Passed {
+				fmt.Fprintf(w, "    ✓ %s\n", a.Assertion)
+			} else {
+				fmt.Fprintf(w, "    ✗ %s: %s\n", a.Assertion, a.Message)
+			}
+		}
+		if c := r.Response.Contract; c != nil {
+			if c.Passed() {
+				fmt.Fprintf(w, "    ✓ contract %s\n", c.Operation)
+			}
+			for _, v := range c.Violations {
+				fmt.Fprintf(w, "    ✗ contract: %s\n", v)
+			}
+		}
+		if s := r.Snapshot; s != nil {
+			switch s.Status {
+			case snapshotChanged:
+				fmt.Fprintf(w, "    ✗ snapshot changed (%s):\n", s.Path)
+				for _, line := range strings.Split(strings.TrimSuffix(diffSummary(*s.Diff), "\n"), "\n") {
+					fmt.Fprintf(w, "        %s\n", line)
+				}
+			case snapshotMatched:
+				fmt.Fprintf(w, "    ✓ snapshot\n")
+			case snapshotMissing:
+				fmt.Fprintf(w, "    ✗ snapshot missing (%s)\n", s.Path)
+			default:
+				fmt.Fprintf(w, "    ✓ snapshot %s (%s)\n", s.Status, s.Path)
+			}
+		}
+		if r.Error != "" {
+			fmt.Fprintf(w, "    ✗ %s\n", r.Error)
+		}
+	}
+
+	passed, failed, skipped := result.Counts()
+	fmt.Fprintf(w, "\n%d requests, %d passed, %d failed, %d skipped in %dms\n",
+		len(result.Results), passed, failed, skipped, result.Duration.Milliseconds())
+}
+
+// findRequest looks up a saved request by name or ID
+func findRequest(requests []HTTPRequest, name string) (HTTPRequest, bool) {
+	for _, req := range requests {
+		if req.Name == name || (req.ID != "" && req.ID == name) {
+			return req, true
+		}
+	}
+	return HTTPRequest{}, false
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// chdirTemp switches into a fresh temporary directory for the duration of the test
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// TestRunCommandExitCode tests that the CLI runner exits non-zero when an assertion fails
+func TestRunCommandExitCode(t *testing.T) {
+	chdirTemp(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	passing := HTTPRequest{Name: "passing", Method: "GET", URL: server.URL, Assertions: []Assertion{
+		{Type: assertStatus, Value: "200"},
+		{Type: assertJSONPath, Name: "$.ok", Value: "true"},
+	}}
+	failing := HTTPRequest{Name: "failing", Method: "GET", URL: server.URL, Assertions: []Assertion{
+		{Type: assertStatus, Value: "201"},
+	}}
+	for _, req := range []HTTPRequest{passing, failing} {
+		if msg := saveRequest(req)(); msg != nil {
+			if err, ok := msg.(errMsg); ok {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"run", "passing"}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for passing request, got %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	if code := runCLI([]string{"run"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 when an assertion fails, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "✗ status == 201") {
+		t.Errorf("Expected failed assertion in output, got:\n%s", stdout.String())
+	}
+}
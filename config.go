@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// configFileName is the name of the config file in the config directory
+const configFileName = "config.json"
+
+// Config holds the user's preferences for the interactive client, e.g.
+//
+//	{
+//	  "keymap": "vim",
+//	  "keys": {
+//	    "editor.send": ["ctrl+r"],
+//	    "tabs.close": []
+//	  }
+//	}
+//
+// Keys rebind actions of the keymap by name; an empty list unbinds one.
+// Theme names a built-in theme (auto, dark, light or high-contrast) or one
+// of Themes, which set colours over a base theme:
+//
+//	"theme": "mine",
+//	"themes": {"mine": {"base": "light", "accent": "#d33682"}}
+type Config struct {
+	Keymap string                     `json:"keymap,omitempty"`
+	Keys   map[string]keyList         `json:"keys,omitempty"`
+	Theme  string                     `json:"theme,omitempty"`
+	Themes map[string]json.RawMessage `json:"themes,omitempty"`
+}
+
+// keyList is the keys of an action, written as a list or a single key
+type keyList []string
+
+func (k *keyList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*k = keyList{single}
+		return nil
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("expected a key or a list of keys")
+	}
+	*k = keys
+	return nil
+}
+
+// configPath returns the path of the config file, following the XDG base
+// directory spec
+func configPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "whelm", configFileName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "whelm", configFileName), nil
+}
+
+// loadConfig reads the config file, returning an empty config when there is none
+func loadConfig() (Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return Config{}, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// keyMap returns the bindings of the configured preset with the
+// configured keys applied
+func (c Config) keyMap() (keyMap, error) {
+	k, err := presetKeyMap(c.Keymap)
+	if err != nil {
+		return keyMap{}, err
+	}
+	for _, name := range sortedKeys(c.Keys) {
+		if err := k.rebind(name, c.Keys[name]); err != nil {
+			return keyMap{}, err
+		}
+	}
+	return k, nil
+}
+
+// newClientModel creates the interactive client set up from the config file
+func newClientModel() (model, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return model{}, err
+	}
+	if err := setupTheme(config); err != nil {
+		return model{}, err
+	}
+	m := initialModel()
+	if m.keys, err = config.keyMap(); err != nil {
+		return m, fmt.Errorf("config: %w", err)
+	}
+	return m, nil
+}
+
+// setupTheme applies the configured theme, asking the terminal for its
+// background colour when the theme is picked automatically
+func setupTheme(config Config) error {
+	t, err := config.resolveTheme(lipgloss.HasDarkBackground)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	applyTheme(t)
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLoadConfig tests that the config is read from the XDG config directory
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	config, err := loadConfig()
+	if err != nil || config.Keymap != "" || config.Keys != nil {
+		t.Errorf("Expected an empty config without a file, got %+v, %v", config, err)
+	}
+
+	path := filepath.Join(dir, "whelm", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"keymap": "vim", "keys": {"main.send": "ctrl+r", "main.quit": ["ctrl+q", "ctrl+c"]}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err = loadConfig()
+	if err != nil {
+		t.Fatalf("Expected the config to load, got %v", err)
+	}
+	keys, err := config.keyMap()
+	if err != nil {
+		t.Fatalf("Expected the keymap to build, got %v", err)
+	}
+	if got := keys.Main.Send.Keys(); len(got) != 1 || got[0] != "ctrl+r" {
+		t.Errorf("Expected a single key to rebind, got %v", got)
+	}
+	if got := keys.Main.Quit.Help().Key; got != "ctrl+q/ctrl+c" {
+		t.Errorf("Expected the help to list the new keys, got %q", got)
+	}
+	if got := keys.Main.Edit.Keys(); got[0] != "i" {
+		t.Errorf("Expected the vim preset, got %v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"keys": {"main.send": 1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(); err == nil {
+		t.Errorf("Expected an error for an invalid key")
+	}
+}
+
+// TestConfigKeyMapErrors tests that unknown presets and actions are reported
+func TestConfigKeyMapErrors(t *testing.T) {
+	if _, err := (Config{Keymap: "emacs"}).keyMap(); err == nil {
+		t.Errorf("Expected an error for an unknown keymap")
+	}
+	if _, err := (Config{Keys: map[string]keyList{"main.fly": {"f"}}}).keyMap(); err == nil {
+		t.Errorf("Expected an error for an unknown action")
+	}
+
+	// Every action can be rebound by name
+	k := defaultKeyMap()
+	for name := range k.named() {
+		if err := k.rebind(name, []string{"f12"}); err != nil {
+			t.Errorf("Expected %s to be rebound, got %v", name, err)
+		}
+	}
+	if k.Main.Send.Keys()[0] != "f12" || k.Review.Reject.Keys()[0] != "f12" {
+		t.Errorf("Expected the bindings themselves to change")
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContractResult is the outcome of checking a response against the OpenAPI
+// operation its request matches
+type ContractResult struct {
+	Spec       string   `json:"spec"`
+	Operation  string   `json:"operation,omitempty"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Passed reports whether the response matched the spec; nil means no spec was checked
+func (c *ContractResult) Passed() bool {
+	return c == nil || len(c.Violations) == 0
+}
+
+// specCache keeps parsed specs so a collection run parses each file once.
+// Entries are reloaded when the file changes.
+var specCache = struct {
+	sync.Mutex
+	specs map[string]cachedSpec
+}{specs: make(map[string]cachedSpec)}
+
+type cachedSpec struct {
+	modTime time.Time
+	spec    *openAPISpec
+}
+
+// loadCachedSpec returns the parsed spec at path
+func loadCachedSpec(path string) (*openAPISpec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	specCache.Lock()
+	defer specCache.Unlock()
+	if c, ok := specCache.specs[path]; ok && c.modTime.Equal(info.ModTime()) {
+		return c.spec, nil
+	}
+	spec, err := loadOpenAPISpec(path)
+	if err != nil {
+		return nil, err
+	}
+	specCache.specs[path] = cachedSpec{modTime: info.ModTime(), spec: spec}
+	return spec, nil
+}
+
+// validateContract checks a response against the spec configured for its request
+func validateContract(req HTTPRequest, resp HTTPResponse) *ContractResult {
+	spec, err := loadCachedSpec(req.Spec)
+	if err != nil {
+		return &ContractResult{Spec: req.Spec, Violations: []string{err.Error()}}
+	}
+	result := checkContract(spec, req, resp)
+	result.Spec = req.Spec
+	return result
+}
+
+// checkContract matches the request to an operation and validates the
+// response status, required headers and body schema
+func checkContract(spec *openAPISpec, req HTTPRequest, resp HTTPResponse) *ContractResult {
+	result := &ContractResult{}
+	violate := func(format string, args ...any) {
+		result.Violations = append(result.Violations, fmt.Sprintf(format, args...))
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		violate("invalid URL: %v", err)
+		return result
+	}
+	p := spec.stripBasePath(u.Path)
+	o, ok := spec.matchOperation(req.Method, p)
+	if !ok {
+		violate("no operation matches %s %s", strings.ToUpper(req.Method), p)
+		return result
+	}
+	result.Operation = o.Key()
+
+	response := spec.responseFor(o, resp.StatusCode)
+	if response == nil {
+		violate("status %d is not documented", resp.StatusCode)
+		return result
+	}
+
+	for name, h := range mapField(response, "headers") {
+		if spec.resolve(h)["required"] == true && !hasHeader(resp.Headers, name) {
+			violate("missing required header %s", name)
+		}
+	}
+
+	schema, mediaType, ok := spec.responseSchema(response, resp)
+	if !ok {
+		if mediaType == "" {
+			violate("missing Content-Type header")
+		} else {
+			violate("content type %s is not documented", mediaType)
+		}
+		return result
+	}
+	if schema == nil || !strings.Contains(mediaType, "json") {
+		return result
+	}
+
+	var body any
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+		violate("body is not valid JSON: %v", err)
+		return result
+	}
+	for _, msg := range validateAgainst(schema, body, spec.doc, "$") {
+		violate("body %s", msg)
+	}
+	return result
+}
+
+// stripBasePath removes the path of the longest matching server URL, so
+// /v1/pets matches the /pets template of a spec served from /v1
+func (s *openAPISpec) stripBasePath(p string) string {
+	if p == "" {
+		p = "/"
+	}
+	best := ""
+	for _, server := range s.servers() {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			continue
+		}
+		base := strings.TrimSuffix(u.Path, "/")
+		if base != "" && len(base) > len(best) && (p == base || strings.HasPrefix(p, base+"/")) {
+			best = base
+		}
+	}
+	if best == "" {
+		return p
+	}
+	if p = strings.TrimPrefix(p, best); p == "" {
+		return "/"
+	}
+	return p
+}
+
+// responseFor returns the documented response for a status code, falling
+// back to a range such as 2XX and then to default
+func (s *openAPISpec) responseFor(o openAPIOperation, status int) map[string]any {
+	responses := mapField(o.Op, "responses")
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if r, ok := responses[key]; ok {
+			return s.resolve(r)
+		}
+	}
+	return nil
+}
+
+// responseSchema returns the schema documented for the response's media
+// type. ok is false when the spec documents content but not this type.
+func (s *openAPISpec) responseSchema(response map[string]any, resp HTTPResponse) (schema any, mediaType string, ok bool) {
+	contentType, _ := lookupHeader(resp.Headers, "Content-Type")
+	mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+
+	if s.swagger {
+		return response["schema"], mediaType, true
+	}
+
+	content := mapField(response, "content")
+	if len(content) == 0 || resp.Body == "" {
+		return nil, mediaType, true
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	for _, key := range []string{mediaType, major + "/*", "*/*"} {
+		for documented, media := range content {
+			if strings.EqualFold(documented, key) {
+				m, _ := media.(map[string]any)
+				return m["schema"], mediaType, true
+			}
+		}
+	}
+	return nil, mediaType, false
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testContractSpec = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Pets"},
+	"servers": [{"url": "https://api.example.com/v1"}],
+	"paths": {
+		"/pets/{id}": {
+			"get": {
+				"responses": {
+					"200": {
+						"description": "A pet",
+						"headers": {"X-Request-Id": {"required": true, "schema": {"type": "string"}}},
+						"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+					},
+					"4XX": {"description": "Client error"}
+				}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"Pet": {
+				"type": "object",
+				"required": ["id", "name"],
+				"properties": {"id": {"type": "integer"}, "name": {"type": "string"}}
+			}
+		}
+	}
+}`
+
+// TestCheckContract tests that status codes, required headers and body schemas are validated
+func TestCheckContract(t *testing.T) {
+	spec, err := parseOpenAPISpec([]byte(testContractSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := HTTPResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "application/json; charset=utf-8", "X-Request-Id": "abc"},
+		Body:       `{"id": 1, "name": "rex"}`,
+	}
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		modify func(r *HTTPResponse)
+		want   string
+	}{
+		{"valid", "GET", "https://api.example.com/v1/pets/1", func(r *HTTPResponse) {}, ""},
+		{"status range", "GET", "https://api.example.com/v1/pets/1", func(r *HTTPResponse) { r.StatusCode, r.Body = 404, "" }, ""},
+		{"undocumented status", "GET", "https://api.example.com/v1/pets/1", func(r *HTTPResponse) { r.StatusCode = 500 }, "status 500 is not documented"},
+		{"missing header", "GET", "https://api.example.com/v1/pets/1", func(r *HTTPResponse) { r.Headers = map[string]string{"Content-Type": "application/json"} }, "missing required header X-Request-Id"},
+		{"schema", "GET", "https://api.example.com/v1/pets/1", func(r *HTTPResponse) { r.Body = `{"id": "1"}` }, "body $: missing required property \"name\""},
+		{"content type", "GET", "https://api.example.com/v1/pets/1", func(r *HTTPResponse) { r.Headers["Content-Type"] = "text/html" }, "content type text/html is not documented"},
+		{"unknown operation", "DELETE", "https://api.example.com/v1/pets/1", func(r *HTTPResponse) {}, "no operation matches DELETE /pets/1"},
+	}
+
+	for _, tt := range tests {
+		resp := valid
+		resp.Headers = map[string]string{}
+		for k, v := range valid.Headers {
+			resp.Headers[k] = v
+		}
+		tt.modify(&resp)
+
+		result := checkContract(spec, HTTPRequest{Method: tt.method, URL: tt.url}, resp)
+		got := strings.Join(result.Violations, "\n")
+		if tt.want == "" && got != "" {
+			t.Errorf("%s: Expected no violations, got %q", tt.name, got)
+		}
+		if tt.want != "" && !strings.Contains(got, tt.want) {
+			t.Errorf("%s: Expected violation %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestRunCommandSpec tests that contract violations fail a CLI run
+func TestRunCommandSpec(t *testing.T) {
+	chdirTemp(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "abc")
+		if r.URL.Path == "/v1/pets/2" {
+			w.Write([]byte(`{"id": 2}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1, "name": "rex"}`))
+	}))
+	defer server.Close()
+
+	spec := strings.Replace(testContractSpec, "https://api.example.com/v1", server.URL+"/v1", 1)
+	if err := os.WriteFile("spec.json", []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []HTTPRequest{
+		{Name: "good", Method: "GET", URL: server.URL + "/v1/pets/1"},
+		{Name: "bad", Method: "GET", URL: server.URL + "/v1/pets/2"},
+	} {
+		runCmd(t, saveRequest(req))
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"run", "-spec", "spec.json", "good"}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for a conforming response, got %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "✓ contract GET /pets/{id}") {
+		t.Errorf("Expected the matched operation in output, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runCLI([]string{"run", "-spec", "spec.json"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a contract violation, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "✗ contract: body $: missing required property \"name\"") {
+		t.Errorf("Expected the violation in output, got:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Kinds of difference between two responses
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffChanged = "changed"
+)
+
+// Difference is a single change from one response to another. Path is a
+// JSON path such as $.items[0].id for JSON bodies, or the line number of
+// the old body (or new body for added lines) for text bodies.
+type Difference struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// ResponseDiff holds the differences between two responses
+type ResponseDiff struct {
+	Status  *Difference  `json:"status,omitempty"`
+	Headers []Difference `json:"headers,omitempty"`
+	Body    []Difference `json:"body,omitempty"`
+	JSON    bool         `json:"json"`
+}
+
+// Empty reports whether the responses are the same
+func (d ResponseDiff) Empty() bool {
+	return d.Status == nil && len(d.Headers) == 0 && len(d.Body) == 0
+}
+
+// diffResponses compares two responses. Ignore lists JSON paths, which may
+// use * for any key or index, and header names that are left out of the
+// comparison. Ignoring a path also ignores everything below it.
+func diffResponses(old, new HTTPResponse, ignore []string) ResponseDiff {
+	var d ResponseDiff
+	var ignoredPaths [][]string
+	ignoredHeaders := make(map[string]bool)
+	for _, p := range ignore {
+		if strings.HasPrefix(strings.TrimSpace(p), "$") {
+			ignoredPaths = append(ignoredPaths, splitJSONPath(p))
+		} else {
+			ignoredHeaders[strings.ToLower(strings.TrimSpace(p))] = true
+		}
+	}
+
+	if old.StatusCode != new.StatusCode {
+		d.Status = &Difference{Path: "status", Kind: diffChanged, Old: old.Status, New: new.Status}
+	}
+	d.Headers = diffHeaders(old.Headers, new.Headers, ignoredHeaders)
+
+	var oldDoc, newDoc any
+	if json.Unmarshal([]byte(old.Body), &oldDoc) == nil && json.Unmarshal([]byte(new.Body), &newDoc) == nil {
+		d.JSON = true
+		diffJSON(nil, oldDoc, newDoc, ignoredPaths, &d.Body)
+	} else {
+		d.Body = diffLines(old.Body, new.Body)
+	}
+	return d
+}
+
+// diffHeaders compares headers by case-insensitive name
+func diffHeaders(old, new map[string]string, ignored map[string]bool) []Difference {
+	names := make(map[string]string)
+	for k := range old {
+		names[strings.ToLower(k)] = k
+	}
+	for k := range new {
+		names[strings.ToLower(k)] = k
+	}
+
+	var diffs []Difference
+	for _, lower := range sortedKeys(names) {
+		if ignored[lower] {
+			continue
+		}
+		name := names[lower]
+		before, hadOld := lookupHeader(old, name)
+		after, hasNew := lookupHeader(new, name)
+		switch {
+		case !hadOld:
+			diffs = append(diffs, Difference{Path: name, Kind: diffAdded, New: after})
+		case !hasNew:
+			diffs = append(diffs, Difference{Path: name, Kind: diffRemoved, Old: before})
+		case before != after:
+			diffs = append(diffs, Difference{Path: name, Kind: diffChanged, Old: before, New: after})
+		}
+	}
+	return diffs
+}
+
+// diffJSON compares two decoded JSON values structurally, so the order of
+// object keys does not matter
+func diffJSON(path []string, old, new any, ignored [][]string, diffs *[]Difference) {
+	if isIgnoredPath(path, ignored) {
+		return
+	}
+
+	switch o := old.(type) {
+	case map[string]any:
+		n, ok := new.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range o {
+			keys[k] = true
+		}
+		for k := range n {
+			keys[k] = true
+		}
+		for _, k := range sortedKeys(keys) {
+			child := append(append([]string(nil), path...), k)
+			before, hadOld := o[k]
+			after, hasNew := n[k]
+			switch {
+			case isIgnoredPath(child, ignored):
+			case !hadOld:
+				*diffs = append(*diffs, Difference{Path: formatPath(child), Kind: diffAdded, New: formatJSONValue(after)})
+			case !hasNew:
+				*diffs = append(*diffs, Difference{Path: formatPath(child), Kind: diffRemoved, Old: formatJSONValue(before)})
+			default:
+				diffJSON(child, before, after, ignored, diffs)
+			}
+		}
+		return
+
+	case []any:
+		n, ok := new.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(o) || i < len(n); i++ {
+			child := append(append([]string(nil), path...), strconv.Itoa(i))
+			switch {
+			case isIgnoredPath(child, ignored):
+			case i >= len(o):
+				*diffs = append(*diffs, Difference{Path: formatPath(child), Kind: diffAdded, New: formatJSONValue(n[i])})
+			case i >= len(n):
+				*diffs = append(*diffs, Difference{Path: formatPath(child), Kind: diffRemoved, Old: formatJSONValue(o[i])})
+			default:
+				diffJSON(child, o[i], n[i], ignored, diffs)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*diffs = append(*diffs, Difference{Path: formatPath(path), Kind: diffChanged, Old: formatJSONValue(old), New: formatJSONValue(new)})
+	}
+}
+
+// isIgnoredPath reports whether path is at or below one of the ignored paths
+func isIgnoredPath(path []string, ignored [][]string) bool {
+	for _, pattern := range ignored {
+		if len(pattern) > len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range pattern {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// formatPath renders path segments as a JSON path, with array indexes in brackets
+func formatPath(path []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, segment := range path {
+		if _, err := strconv.Atoi(segment); err == nil {
+			fmt.Fprintf(&b, "[%s]", segment)
+		} else {
+			b.WriteString("." + segment)
+		}
+	}
+	return b.String()
+}
+
+// maxLineDiff bounds the table used to diff text bodies; longer bodies are
+// reported as replaced wholesale
+const maxLineDiff = 4_000_000
+
+// diffLines compares text bodies line by line using their longest common subsequence
+func diffLines(old, new string) []Difference {
+	if old == new {
+		return nil
+	}
+	a, b := strings.Split(old, "\n"), strings.Split(new, "\n")
+
+	// Common leading and trailing lines need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var diffs []Difference
+	removed := func(i int) {
+		diffs = append(diffs, Difference{Path: "line " + strconv.Itoa(prefix+i+1), Kind: diffRemoved, Old: a[i]})
+	}
+	added := func(j int) {
+		diffs = append(diffs, Difference{Path: "line " + strconv.Itoa(prefix+j+1), Kind: diffAdded, New: b[j]})
+	}
+
+	if len(a)*len(b) > maxLineDiff {
+		for i := range a {
+			removed(i)
+		}
+		for j := range b {
+			added(j)
+		}
+		return diffs
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			removed(i)
+			i++
+		default:
+			added(j)
+			j++
+		}
+	}
+	return diffs
+}
+
+// diffTitle describes the two exchanges being compared
+func diffTitle(old, new HistoryEntry) string {
+	label := func(e HistoryEntry) string {
+		return fmt.Sprintf("%s %s (%s)", e.Request.Method, e.Request.URL, e.Time.Local().Format("2006-01-02 15:04:05"))
+	}
+	return fmt.Sprintf("- %s\n+ %s", label(old), label(new))
+}
+
+// formatDiff renders a response diff for the diff view
+func formatDiff(d ResponseDiff, ignore []string) string {
+	removedStyle, addedStyle, changedStyle := errorStyle, successStyle, warningStyle
+
+	line := func(diff Difference) string {
+		switch diff.Kind {
+		case diffAdded:
+			return addedStyle.Render(fmt.Sprintf("  + %s: %s", diff.Path, diff.New))
+		case diffRemoved:
+			return removedStyle.Render(fmt.Sprintf("  - %s: %s", diff.Path, diff.Old))
+		default:
+			return changedStyle.Render(fmt.Sprintf("  ~ %s: %s → %s", diff.Path, diff.Old, diff.New))
+		}
+	}
+
+	if d.Empty() {
+		return "No differences\n"
+	}
+
+	content := ""
+	if d.Status != nil {
+		content += "Status:\n" + line(*d.Status) + "\n\n"
+	}
+	if len(d.Headers) > 0 {
+		content += "Headers:\n"
+		for _, diff := range d.Headers {
+			content += line(diff) + "\n"
+		}
+		content += "\n"
+	}
+	if len(d.Body) > 0 {
+		content += "Body:\n"
+		for _, diff := range d.Body {
+			if d.JSON {
+				content += line(diff) + "\n"
+			} else if diff.Kind == diffAdded {
+				content += addedStyle.Render("+ "+diff.New) + "\n"
+			} else {
+				content += removedStyle.Render("- "+diff.Old) + "\n"
+			}
+		}
+		content += "\n"
+	}
+	if len(ignore) > 0 {
+		unique := make(map[string]bool)
+		for _, p := range ignore {
+			unique[p] = true
+		}
+		content += helpStyle.Render("Ignoring "+strings.Join(sortedKeys(unique), ", ")) + "\n"
+	}
+	return content
+}
+
+// diffSummary renders a response diff as plain text, one difference per line
+func diffSummary(d ResponseDiff) string {
+	var diffs []Difference
+	if d.Status != nil {
+		diffs = append(diffs, *d.Status)
+	}
+	for _, h := range d.Headers {
+		h.Path = "header " + h.Path
+		diffs = append(diffs, h)
+	}
+	diffs = append(diffs, d.Body...)
+
+	var b strings.Builder
+	for _, diff := range diffs {
+		switch diff.Kind {
+		case diffAdded:
+			fmt.Fprintf(&b, "+ %s: %s\n", diff.Path, diff.New)
+		case diffRemoved:
+			fmt.Fprintf(&b, "- %s: %s\n", diff.Path, diff.Old)
+		default:
+			fmt.Fprintf(&b, "~ %s: %s → %s\n", diff.Path, diff.Old, diff.New)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestDiffResponsesJSON tests that JSON bodies are compared structurally and ignored paths are skipped
+func TestDiffResponsesJSON(t *testing.T) {
+	old := HTTPResponse{
+		StatusCode: 200, Status: "200 OK",
+		Headers: map[string]string{"Content-Type": "application/json", "Date": "Mon", "X-Old": "1"},
+		Body:    `{"id": 7, "name": "rex", "tags": ["a", "b"], "meta": {"at": "09:00", "v": 1}, "items": [{"id": 1, "n": 1}]}`,
+	}
+	new := HTTPResponse{
+		StatusCode: 201, Status: "201 Created",
+		Headers: map[string]string{"content-type": "application/json", "Date": "Tue", "X-New": "2"},
+		Body:    `{"meta": {"v": 2, "at": "10:00"}, "items": [{"n": 1, "id": 2}], "tags": ["a"], "name": "rex", "id": 7, "extra": true}`,
+	}
+
+	d := diffResponses(old, new, []string{"date", "$.meta.at", "$.items[*].id"})
+	if d.Status == nil || d.Status.New != "201 Created" {
+		t.Errorf("Expected a status change, got %+v", d.Status)
+	}
+
+	var headers []string
+	for _, h := range d.Headers {
+		headers = append(headers, h.Kind+" "+h.Path)
+	}
+	if got := strings.Join(headers, ", "); got != "added X-New, removed X-Old" {
+		t.Errorf("Expected only the added and removed headers, got %q", got)
+	}
+
+	var body []string
+	for _, b := range d.Body {
+		body = append(body, b.Kind+" "+b.Path+" "+b.Old+" "+b.New)
+	}
+	want := []string{"added $.extra  true", "changed $.meta.v 1 2", "removed $.tags[1] \"b\" "}
+	if got := strings.Join(body, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("Expected body differences:\n%s\ngot:\n%s", strings.Join(want, "\n"), got)
+	}
+
+	if !diffResponses(old, old, nil).Empty() {
+		t.Errorf("Expected no differences between identical responses")
+	}
+}
+
+// TestDiffLines tests that text bodies are compared line by line
+func TestDiffLines(t *testing.T) {
+	diffs := diffLines("a\nb\nc\nd", "a\nc\nx\nd")
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Kind+" "+d.Path+" "+d.Old+d.New)
+	}
+	want := "removed line 2 b\nadded line 3 x"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, strings.Join(got, "\n"))
+	}
+}
+
+// TestHistoryDiffView tests that two marked history entries open the diff view, older first
+func TestHistoryDiffView(t *testing.T) {
+	now := time.Now()
+	older := HistoryEntry{Time: now.Add(-time.Hour), Request: HTTPRequest{Method: "GET", URL: "http://prod.test"}, Response: HTTPResponse{StatusCode: 200, Body: `{"v": 1}`}}
+	newer := HistoryEntry{Time: now, Request: HTTPRequest{Method: "GET", URL: "http://staging.test", Ignore: []string{"$.at"}}, Response: HTTPResponse{StatusCode: 200, Body: `{"v": 2, "at": 1}`}}
+
+	m := initialModel()
+	m.state = stateHistory
+	updated, _ := m.Update(historyMsg{newer, older})
+	m = updated.(model)
+
+	// Mark the newest entry, then compare it with the older one
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = updated.(model)
+	m.historyList.Select(1)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = updated.(model)
+
+	if m.state != stateDiff {
+		t.Fatalf("Expected the diff view, got state %d", m.state)
+	}
+	view := m.diffView.View()
+	if !strings.Contains(view, "$.v: 1 → 2") {
+		t.Errorf("Expected the change from the older response, got:\n%s", view)
+	}
+	if strings.Contains(view, "+ $.at") {
+		t.Errorf("Expected $.at to be ignored, got:\n%s", view)
+	}
+	if strings.Index(view, "prod.test") > strings.Index(view, "staging.test") {
+		t.Errorf("Expected the older exchange first, got:\n%s", view)
+	}
+}
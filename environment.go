@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Directories requests and environments are stored in
+var (
+	requestsDir     = "requests"
+	environmentsDir = "environments"
+)
+
+// Environment is a named set of variables substituted into requests
+type Environment struct {
+	Name      string            `json:"-"`
+	Personal  bool              `json:"-"`
+	Variables map[string]string `json:"variables"`
+}
+
+// Label names the environment for display
+func (e Environment) Label() string {
+	if e.Personal {
+		return e.Name + " (personal)"
+	}
+	return e.Name
+}
+
+type environmentsMsg []Environment
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.\-]+)\s*\}\}`)
+
+func loadEnvironments() tea.Msg {
+	envs, err := readEnvironments()
+	if err != nil {
+		return errMsg{err}
+	}
+	return environmentsMsg(envs)
+}
+
+// readEnvironments reads every environment file, sorted by name, followed
+// by the personal environments when a project is open
+func readEnvironments() ([]Environment, error) {
+	envs, err := readEnvironmentDir(environmentsDir)
+	if err != nil || personalEnvironmentsDir == "" {
+		return envs, err
+	}
+	personal, err := readEnvironmentDir(personalEnvironmentsDir)
+	for i := range personal {
+		personal[i].Personal = true
+	}
+	return append(envs, personal...), err
+}
+
+// readEnvironmentDir reads the environment files in dir, sorted by name
+func readEnvironmentDir(dir string) ([]Environment, error) {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var envs []Environment
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var env Environment
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		env.Name = strings.TrimSuffix(file.Name(), ".json")
+		envs = append(envs, env)
+	}
+
+	sort.Slice(envs, func(i, j int) bool { return envs[i].Name < envs[j].Name })
+	return envs, nil
+}
+
+// findEnvironment looks up an environment by name, preferring project environments
+func findEnvironment(envs []Environment, name string) (Environment, bool) {
+	for _, env := range envs {
+		if env.Name == name {
+			return env, true
+		}
+	}
+	return Environment{}, false
+}
+
+// substituteVariables replaces {{name}} references with their values, leaving unknown names untouched
+func substituteVariables(s string, vars map[string]string) string {
+	if len(vars) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := variablePattern.FindStringSubmatch(ref)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// applyVariables returns a copy of the request with variables substituted
+// into its URL, headers, body, auth and assertion values
+func applyVariables(req HTTPRequest, vars map[string]string) HTTPRequest {
+	req.URL = substituteVariables(req.URL, vars)
+	req.Body = substituteVariables(req.Body, vars)
+
+	headers := make(map[string]string, len(req.Headers))
+	for k, v := range req.Headers {
+		headers[substituteVariables(k, vars)] = substituteVariables(v, vars)
+	}
+	req.Headers = headers
+
+	assertions := make([]Assertion, len(req.Assertions))
+	for i, a := range req.Assertions {
+		a.Value = substituteVariables(a.Value, vars)
+		assertions[i] = a
+	}
+	req.Assertions = assertions
+
+	if req.Auth != nil {
+		auth := *req.Auth
+		auth.Username = substituteVariables(auth.Username, vars)
+		auth.Password = substituteVariables(auth.Password, vars)
+		auth.Token = substituteVariables(auth.Token, vars)
+		auth.Value = substituteVariables(auth.Value, vars)
+		req.Auth = &auth
+	}
+
+	return req
+}
+
+// extractVariables pulls values out of a response. A source is either a
+// JSON path into the body or header:<Name> for a response header.
+func extractVariables(extract map[string]string, resp HTTPResponse) (map[string]string, error) {
+	vars := make(map[string]string)
+	if len(extract) == 0 {
+		return vars, nil
+	}
+
+	var doc any
+	bodyErr := json.Unmarshal([]byte(resp.Body), &doc)
+
+	for name, source := range extract {
+		if header, ok := strings.CutPrefix(source, "header:"); ok {
+			value, found := lookupHeader(resp.Headers, header)
+			if !found {
+				return vars, fmt.Errorf("extract %s: header %s missing", name, header)
+			}
+			vars[name] = value
+			continue
+		}
+
+		if bodyErr != nil {
+			return vars, fmt.Errorf("extract %s: body is not JSON", name)
+		}
+		value, found := lookupJSONPath(doc, source)
+		if !found {
+			return vars, fmt.Errorf("extract %s: path %s not found", name, source)
+		}
+		if s, ok := value.(string); ok {
+			vars[name] = s
+		} else {
+			vars[name] = formatJSONValue(value)
+		}
+	}
+
+	return vars, nil
+}
+
+// mergeVariables combines variable sets, later sets taking precedence
+func mergeVariables(sets ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, set := range sets {
+		for k, v := range set {
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestSubstituteVariables tests that known variables are replaced and unknown ones are kept
+func TestSubstituteVariables(t *testing.T) {
+	vars := map[string]string{"host": "api.example.com", "id": "42"}
+
+	tests := []struct {
+		input, want string
+	}{
+		{"https://{{host}}/users/{{ id }}", "https://api.example.com/users/42"},
+		{"{{missing}}", "{{missing}}"},
+		{"no variables", "no variables"},
+	}
+
+	for _, tt := range tests {
+		if got := substituteVariables(tt.input, vars); got != tt.want {
+			t.Errorf("substituteVariables(%q) = %q, expected %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestApplyVariablesDoesNotModifyOriginal tests that resolving a request leaves the saved request intact
+func TestApplyVariablesDoesNotModifyOriginal(t *testing.T) {
+	req := HTTPRequest{
+		URL:     "{{base}}/items",
+		Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+		Body:    `{"owner": "{{user}}"}`,
+	}
+	vars := map[string]string{"base": "http://localhost", "token": "abc", "user": "ada"}
+
+	resolved := applyVariables(req, vars)
+
+	if resolved.URL != "http://localhost/items" || resolved.Headers["Authorization"] != "Bearer abc" || resolved.Body != `{"owner": "ada"}` {
+		t.Errorf("Unexpected resolved request: %+v", resolved)
+	}
+	if req.Headers["Authorization"] != "Bearer {{token}}" {
+		t.Errorf("Original request headers were modified: %v", req.Headers)
+	}
+}
+
+// TestExtractVariables tests extraction from JSON bodies and headers
+func TestExtractVariables(t *testing.T) {
+	resp := HTTPResponse{
+		Headers: map[string]string{"Location": "/users/7"},
+		Body:    `{"token": "secret", "user": {"id": 7}}`,
+	}
+
+	vars, err := extractVariables(map[string]string{
+		"token":    "$.token",
+		"userId":   "$.user.id",
+		"location": "header:location",
+	}, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"token": "secret", "userId": "7", "location": "/users/7"}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, vars[k])
+		}
+	}
+
+	if _, err := extractVariables(map[string]string{"x": "$.missing"}, resp); err == nil {
+		t.Errorf("Expected an error for a missing path")
+	}
+}
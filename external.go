@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// What an external editor is opened on
+const (
+	editBody = iota
+	editHeaders
+	editResponse
+)
+
+// defaultEditor is used when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// externalEdit is content being edited in an external editor through a
+// temporary file
+type externalEdit struct {
+	target   int
+	path     string
+	original string
+}
+
+// editorClosedMsg carries the content saved in the external editor
+type editorClosedMsg struct {
+	target  int
+	content string
+	err     error
+}
+
+// editorCommand returns the user's editor and its arguments, e.g. "code --wait"
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{defaultEditor}
+}
+
+// startExternalEdit writes content to a temporary file with the given
+// extension, so the editor highlights it, and prepares the editor command
+func startExternalEdit(target int, content, ext string) (externalEdit, *exec.Cmd, error) {
+	f, err := os.CreateTemp("", "whelm-*"+ext)
+	if err != nil {
+		return externalEdit{}, nil, err
+	}
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return externalEdit{}, nil, err
+	}
+
+	args := editorCommand()
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	return externalEdit{target: target, path: f.Name(), original: content}, cmd, nil
+}
+
+// finish reads back the edited file once the editor exits and removes it
+func (e externalEdit) finish(err error) tea.Msg {
+	defer os.Remove(e.path)
+	if err != nil {
+		return editorClosedMsg{target: e.target, err: fmt.Errorf("editor: %w", err)}
+	}
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return editorClosedMsg{target: e.target, err: err}
+	}
+	// Editors end files with a newline, which the original may not have had
+	content := string(data)
+	if !strings.HasSuffix(e.original, "\n") {
+		content = strings.TrimSuffix(strings.TrimSuffix(content, "\n"), "\r")
+	}
+	return editorClosedMsg{target: e.target, content: content}
+}
+
+// openInEditor suspends the client and opens the body, headers or
+// response body in the external editor
+func (m model) openInEditor(target int) tea.Cmd {
+	var content, ext string
+	switch target {
+	case editBody:
+		content, ext = m.currentRequest.Body, bodyFileExt(m.currentRequest)
+	case editHeaders:
+		content, ext = formatHeaders(m.currentRequest.Headers), ".txt"
+	case editResponse:
+		content = m.response.Body
+		ext = bodyFileExt(HTTPRequest{Headers: m.response.Headers, Body: m.response.Body})
+	}
+
+	edit, cmd, err := startExternalEdit(target, content, ext)
+	if err != nil {
+		return func() tea.Msg { return errMsg{err} }
+	}
+	return tea.ExecProcess(cmd, edit.finish)
+}
+
+// editorTarget is what the external editor opens from the request editor:
+// the headers while they are focused, otherwise the body
+func (m model) editorTarget() int {
+	if m.headerInput.Focused() {
+		return editHeaders
+	}
+	return editBody
+}
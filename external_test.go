@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExternalEdit tests that content edited in the external editor is loaded back
+func TestExternalEdit(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "editor.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf '{\"name\": \"edited\"}\\n' > \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sh "+script)
+
+	m := initialModel()
+	m.setRequest(HTTPRequest{Method: "POST", URL: "http://example.test/users",
+		Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"name": "old"}`})
+	m, _ = pressKey(m, "e")
+	if cmd := m.openInEditor(m.editorTarget()); cmd == nil {
+		t.Errorf("Expected a command opening the editor")
+	}
+
+	edit, cmd, err := startExternalEdit(editBody, m.currentRequest.Body, bodyFileExt(m.currentRequest))
+	if err != nil {
+		t.Fatalf("Expected the edit to start, got %v", err)
+	}
+	if !strings.HasSuffix(edit.path, ".json") || strings.Join(cmd.Args, " ") != "sh "+script+" "+edit.path {
+		t.Errorf("Expected the editor to open a .json file, got %v", cmd.Args)
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(edit.finish(nil))
+	m = updated.(model)
+	if m.currentRequest.Body != `{"name": "edited"}` || m.bodyInput.Value() != m.currentRequest.Body {
+		t.Errorf("Expected the edited body without the editor's final newline, got %q", m.currentRequest.Body)
+	}
+	if _, err := os.Stat(edit.path); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be removed")
+	}
+
+	// Headers are edited while focused
+	m.headerInput.Focus()
+	if m.editorTarget() != editHeaders {
+		t.Errorf("Expected the focused headers to be edited")
+	}
+	updated, _ = m.Update(editorClosedMsg{target: editHeaders, content: "Accept: text/plain\nX-Trace: 1"})
+	m = updated.(model)
+	if m.currentRequest.Headers["X-Trace"] != "1" || m.currentRequest.Headers["Accept"] != "text/plain" {
+		t.Errorf("Expected the edited headers, got %v", m.currentRequest.Headers)
+	}
+
+	// A failing editor leaves the request alone
+	edit, _, err = startExternalEdit(editBody, m.currentRequest.Body, ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ = m.Update(edit.finish(errors.New("exit status 1")))
+	m = updated.(model)
+	if m.err == nil || m.currentRequest.Body != `{"name": "edited"}` {
+		t.Errorf("Expected the editor's failure to be reported, got %v", m.err)
+	}
+}
+
+// TestEditorCommand tests that $VISUAL is preferred over $EDITOR, falling back to vi
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "code --wait")
+	t.Setenv("EDITOR", "nano")
+	if got := strings.Join(editorCommand(), " "); got != "code --wait" {
+		t.Errorf("Expected $VISUAL, got %q", got)
+	}
+	t.Setenv("VISUAL", "")
+	if got := strings.Join(editorCommand(), " "); got != "nano" {
+		t.Errorf("Expected $EDITOR, got %q", got)
+	}
+	t.Setenv("EDITOR", " ")
+	if got := strings.Join(editorCommand(), " "); got != defaultEditor {
+		t.Errorf("Expected %s, got %q", defaultEditor, got)
+	}
+}
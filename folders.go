@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// folderConfigFile holds the settings shared by the requests in a folder
+const folderConfigFile = "folder.json"
+
+// Auth types
+const (
+	authNone   = "none"
+	authBasic  = "basic"
+	authBearer = "bearer"
+	authAPIKey = "apikey"
+)
+
+// Auth describes how a request authenticates. Type none stops a request
+// from inheriting the auth of its folders.
+type Auth struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Value    string `json:"value,omitempty"`
+}
+
+// FolderConfig holds settings inherited by every request in a folder and its subfolders
+type FolderConfig struct {
+	Headers   map[string]string `json:"headers,omitempty"`
+	Auth      *Auth             `json:"auth,omitempty"`
+	BaseURL   string            `json:"base_url,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+	Spec      string            `json:"spec,omitempty"` // relative to the folder
+	Ignore    []string          `json:"ignore,omitempty"`
+}
+
+// readRequestTree reads every request below dir, from .json files and from
+// .http files holding several requests. Each request's Folder is its
+// directory relative to dir using forward slashes, "" for the root.
+// The returned map has an entry for every folder, including empty ones.
+func readRequestTree(dir string) ([]HTTPRequest, map[string]FolderConfig, error) {
+	folders := map[string]FolderConfig{"": {}}
+
+	// A single .http file can be used in place of a directory
+	if isHTTPFile(dir) {
+		requests, err := readHTTPFile(dir)
+		for i := range requests {
+			requests[i].Path = dir
+		}
+		return requests, folders, err
+	}
+
+	// Check if requests directory exists
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, folders, nil
+	}
+
+	var requests []HTTPRequest
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		folder := filepath.ToSlash(filepath.Dir(rel))
+		if folder == "." {
+			folder = ""
+		}
+
+		if d.IsDir() {
+			if rel == "." {
+				folder = ""
+			} else if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			} else {
+				folder = filepath.ToSlash(rel)
+			}
+			if _, ok := folders[folder]; !ok {
+				folders[folder] = FolderConfig{}
+			}
+			return nil
+		}
+
+		if d.Name() == folderConfigFile {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			var config FolderConfig
+			if err := json.Unmarshal(data, &config); err != nil {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+			config.Spec = resolveFolderPath(filepath.Dir(p), config.Spec)
+			folders[folder] = config
+			return nil
+		}
+
+		if isHTTPFile(d.Name()) {
+			fileRequests, err := readHTTPFile(p)
+			if err != nil {
+				return nil
+			}
+			for _, req := range fileRequests {
+				req.Folder = folder
+				req.Path = p
+				requests = append(requests, req)
+			}
+			return nil
+		}
+
+		if !isRequestFile(d.Name()) {
+			return nil
+		}
+
+		// Read and parse request file
+		req, err := readRequestFile(p)
+		if err != nil {
+			return nil
+		}
+		req.Folder = folder
+		req.Path = p
+		requests = append(requests, req)
+		return nil
+	})
+
+	return requests, folders, err
+}
+
+// folderChain lists a folder and its ancestors, outermost first
+func folderChain(folder string) []string {
+	chain := []string{""}
+	if folder == "" {
+		return chain
+	}
+	parts := strings.Split(folder, "/")
+	for i := range parts {
+		chain = append(chain, strings.Join(parts[:i+1], "/"))
+	}
+	return chain
+}
+
+// resolveFolderPath resolves a path written in a folder's settings, which
+// is relative to the folder so requests run from any directory
+func resolveFolderPath(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, filepath.FromSlash(p))
+}
+
+// relativeFolderPath turns a path into one relative to a folder for its settings
+func relativeFolderPath(dir, p string) string {
+	if p == "" {
+		return p
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return p
+	}
+	absPath, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return absPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// inFolder reports whether a request folder is the given folder or below it
+func inFolder(requestFolder, folder string) bool {
+	return folder == "" || requestFolder == folder || strings.HasPrefix(requestFolder, folder+"/")
+}
+
+// inheritFolderSettings returns a copy of the request with the headers,
+// auth, base URL and variables of its folders applied. Settings of inner
+// folders override outer ones, and the request's own settings win. Ignored
+// paths add up.
+func inheritFolderSettings(req HTTPRequest, folders map[string]FolderConfig) HTTPRequest {
+	headers := make(map[string]string)
+	vars := make(map[string]string)
+	var auth *Auth
+	var ignore []string
+	baseURL, spec := "", ""
+
+	for _, name := range folderChain(req.Folder) {
+		config := folders[name]
+		for k, v := range config.Headers {
+			headers[k] = v
+		}
+		for k, v := range config.Variables {
+			vars[k] = v
+		}
+		if config.Auth != nil {
+			auth = config.Auth
+		}
+		if config.BaseURL != "" {
+			baseURL = config.BaseURL
+		}
+		if config.Spec != "" {
+			spec = config.Spec
+		}
+		ignore = append(ignore, config.Ignore...)
+	}
+
+	for k, v := range req.Headers {
+		headers[k] = v
+	}
+	for k, v := range req.Variables {
+		vars[k] = v
+	}
+	if req.Auth != nil {
+		auth = req.Auth
+	}
+
+	req.Headers = headers
+	req.Variables = vars
+	req.Auth = auth
+	if req.Spec == "" {
+		req.Spec = spec
+	}
+	if ignore != nil {
+		req.Ignore = append(ignore, req.Ignore...)
+	}
+	if baseURL != "" && !isAbsoluteURL(req.URL) {
+		req.URL = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(req.URL, "/")
+	}
+
+	return req
+}
+
+// isAbsoluteURL reports whether a request URL carries its own scheme and host
+func isAbsoluteURL(u string) bool {
+	return strings.Contains(u, "://") || strings.HasPrefix(u, "{{")
+}
+
+// applyAuth adds the credentials described by auth to an outgoing request
+func applyAuth(httpReq *http.Request, auth *Auth) {
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case authBasic:
+		httpReq.SetBasicAuth(auth.Username, auth.Password)
+	case authBearer:
+		if httpReq.Header.Get("Authorization") == "" {
+			httpReq.Header.Set("Authorization", "Bearer "+auth.Token)
+		}
+	case authAPIKey:
+		if auth.Key != "" && httpReq.Header.Get(auth.Key) == "" {
+			httpReq.Header.Set(auth.Key, auth.Value)
+		}
+	}
+}
+
+// treeItem is a row of the saved request tree: a folder or a request
+type treeItem struct {
+	folder  string
+	request int
+	depth   int
+	open    bool
+	count   int
+	label   string
+	desc    string
+}
+
+func (t treeItem) isFolder() bool { return t.request < 0 }
+
+func (t treeItem) Title() string {
+	indent := strings.Repeat("  ", t.depth)
+	if t.isFolder() {
+		marker := "▸"
+		if t.open {
+			marker = "▾"
+		}
+		return fmt.Sprintf("%s%s %s/", indent, marker, t.label)
+	}
+	return indent + t.label
+}
+
+func (t treeItem) Description() string {
+	if t.isFolder() {
+		desc := fmt.Sprintf("%s%d requests", strings.Repeat("  ", t.depth), t.count)
+		if t.desc != "" {
+			desc += " • " + t.desc
+		}
+		return desc
+	}
+	return strings.Repeat("  ", t.depth) + t.desc
+}
+
+func (t treeItem) FilterValue() string { return t.label }
+
+// buildRequestTree flattens the folders and requests into list rows,
+// descending only into expanded folders. The personal requests of a
+// project workspace sort after the project's folders, labelled personal.
+func buildRequestTree(requests []HTTPRequest, folders map[string]FolderConfig, expanded map[string]bool) []treeItem {
+	children := make(map[string][]string)
+	for name := range folders {
+		if name == "" {
+			continue
+		}
+		parent := path.Dir(name)
+		if parent == "." {
+			parent = ""
+		}
+		children[parent] = append(children[parent], name)
+	}
+	for _, names := range children {
+		sort.Strings(names)
+	}
+
+	counts := make(map[string]int)
+	for _, req := range requests {
+		for _, name := range folderChain(req.Folder) {
+			counts[name]++
+		}
+	}
+
+	var items []treeItem
+	var walk func(folder string, depth int)
+	walk = func(folder string, depth int) {
+		for _, child := range children[folder] {
+			open := expanded[child]
+			label, desc := path.Base(child), ""
+			if child == personalFolder {
+				label, desc = "personal", displayPath(personalRequestsDir)
+			}
+			items = append(items, treeItem{folder: child, request: -1, depth: depth, open: open, count: counts[child], label: label, desc: desc})
+			if open {
+				walk(child, depth+1)
+			}
+		}
+		for i, req := range requests {
+			if req.Folder == folder {
+				desc := fmt.Sprintf("%s %s", req.Method, req.URL)
+				if isHTTPFile(req.Path) {
+					desc += " • " + filepath.Base(req.Path)
+				}
+				items = append(items, treeItem{folder: folder, request: i, depth: depth, label: req.Name, desc: desc})
+			}
+		}
+	}
+	walk("", 0)
+
+	return items
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeJSONFile(t *testing.T, path string, v any) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestReadRequestTree tests that nested folders and their settings are read
+func TestReadRequestTree(t *testing.T) {
+	dir := t.TempDir()
+	writeJSONFile(t, filepath.Join(dir, "root.json"), HTTPRequest{Name: "root", Method: "GET"})
+	writeJSONFile(t, filepath.Join(dir, "users", "folder.json"), FolderConfig{BaseURL: "https://users.example.com"})
+	writeJSONFile(t, filepath.Join(dir, "users", "list.json"), HTTPRequest{Name: "list", Method: "GET"})
+	writeJSONFile(t, filepath.Join(dir, "users", "admin", "ban.json"), HTTPRequest{Name: "ban", Method: "POST"})
+	if err := os.MkdirAll(filepath.Join(dir, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	requests, folders, err := readRequestTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, req := range requests {
+		got[req.Name] = req.Folder
+	}
+	want := map[string]string{"root": "", "list": "users", "ban": "users/admin"}
+	for name, folder := range want {
+		if f, ok := got[name]; !ok || f != folder {
+			t.Errorf("Expected %s in folder %q, got %q (found=%v)", name, folder, f, ok)
+		}
+	}
+	if len(requests) != 3 {
+		t.Errorf("Expected folder.json not to be read as a request, got %d requests", len(requests))
+	}
+
+	for _, name := range []string{"", "users", "users/admin", "empty"} {
+		if _, ok := folders[name]; !ok {
+			t.Errorf("Expected folder %q to be listed", name)
+		}
+	}
+	if folders["users"].BaseURL != "https://users.example.com" {
+		t.Errorf("Expected users folder settings to be read, got %+v", folders["users"])
+	}
+}
+
+// TestInheritFolderSettings tests that inner folders and the request override outer settings
+func TestInheritFolderSettings(t *testing.T) {
+	folders := map[string]FolderConfig{
+		"": {
+			Headers:   map[string]string{"Accept": "application/json", "X-Team": "core"},
+			Auth:      &Auth{Type: authBearer, Token: "{{token}}"},
+			BaseURL:   "https://api.example.com",
+			Variables: map[string]string{"version": "v1", "token": "root"},
+		},
+		"users": {
+			Headers:   map[string]string{"X-Team": "users"},
+			BaseURL:   "https://users.example.com/",
+			Variables: map[string]string{"version": "v2"},
+		},
+	}
+
+	req := inheritFolderSettings(HTTPRequest{
+		Folder:    "users",
+		URL:       "/{{version}}/users",
+		Headers:   map[string]string{"Accept": "text/plain"},
+		Variables: map[string]string{"token": "mine"},
+	}, folders)
+
+	if req.URL != "https://users.example.com/{{version}}/users" {
+		t.Errorf("Expected base URL of innermost folder, got %s", req.URL)
+	}
+	if req.Headers["Accept"] != "text/plain" || req.Headers["X-Team"] != "users" {
+		t.Errorf("Unexpected inherited headers: %v", req.Headers)
+	}
+	if req.Variables["version"] != "v2" || req.Variables["token"] != "mine" {
+		t.Errorf("Unexpected inherited variables: %v", req.Variables)
+	}
+	if req.Auth == nil || req.Auth.Type != authBearer {
+		t.Errorf("Expected auth to be inherited from the root folder, got %+v", req.Auth)
+	}
+
+	absolute := inheritFolderSettings(HTTPRequest{URL: "http://other.example.com/x"}, folders)
+	if absolute.URL != "http://other.example.com/x" {
+		t.Errorf("Expected absolute URL to be kept, got %s", absolute.URL)
+	}
+
+	httpReq, _ := http.NewRequest("GET", "http://example.com", nil)
+	applyAuth(httpReq, applyVariables(req, req.Variables).Auth)
+	if httpReq.Header.Get("Authorization") != "Bearer mine" {
+		t.Errorf("Expected bearer token to be applied, got %q", httpReq.Header.Get("Authorization"))
+	}
+}
+
+// TestBuildRequestTree tests that only expanded folders show their contents
+func TestBuildRequestTree(t *testing.T) {
+	requests := []HTTPRequest{
+		{Name: "root"},
+		{Name: "list", Folder: "users"},
+		{Name: "ban", Folder: "users/admin"},
+	}
+	folders := map[string]FolderConfig{"": {}, "users": {}, "users/admin": {}}
+
+	collapsed := buildRequestTree(requests, folders, map[string]bool{})
+	if len(collapsed) != 2 || !collapsed[0].isFolder() || collapsed[0].count != 2 || collapsed[1].label != "root" {
+		t.Errorf("Unexpected collapsed tree: %+v", collapsed)
+	}
+
+	expanded := buildRequestTree(requests, folders, map[string]bool{"users": true, "users/admin": true})
+	var labels []string
+	for _, item := range expanded {
+		labels = append(labels, item.label)
+	}
+	want := []string{"users", "admin", "ban", "list", "root"}
+	if len(labels) != len(want) {
+		t.Fatalf("Expected rows %v, got %v", want, labels)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("Expected rows %v, got %v", want, labels)
+			break
+		}
+	}
+	if expanded[2].depth != 2 {
+		t.Errorf("Expected nested request to be indented twice, got depth %d", expanded[2].depth)
+	}
+}
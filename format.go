@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Saved requests are stored as .json or .yaml files. The YAML form writes
+// multi-line bodies as block scalars so they diff line by line. Either form
+// may keep its body in a sidecar file named after the request, such as
+// create-user.body.json, referenced by body_file.
+
+// isRequestFile reports whether a file name holds a single saved request
+func isRequestFile(name string) bool {
+	if isReservedFile(name) {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func isYAMLFile(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// isSidecarFile reports whether a file holds the body of another request
+func isSidecarFile(name string) bool {
+	return strings.Contains(filepath.Base(name), ".body.")
+}
+
+// sidecarPath returns the body file of the request at p, keeping the
+// extension of bodyFile so editors highlight it
+func sidecarPath(p, bodyFile string) string {
+	base := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+	return filepath.Join(filepath.Dir(p), base+".body"+filepath.Ext(bodyFile))
+}
+
+// bodyFilePath resolves the body_file of the request at p, for reading and
+// writing alike. Body files must sit next to their request and be named as
+// sidecars, so a request cannot pull in files from elsewhere and its body
+// is never listed as a request.
+func bodyFilePath(p, bodyFile string) (string, error) {
+	if strings.ContainsAny(bodyFile, `/\`) || strings.Contains(bodyFile, "..") || !isSidecarFile(bodyFile) {
+		return "", fmt.Errorf("%s: body_file %q must name a .body. file in the same folder", p, bodyFile)
+	}
+	return filepath.Join(filepath.Dir(p), bodyFile), nil
+}
+
+// withSidecarName renames the body file of a request given a new path after
+// the request file, so requests never share a body file
+func withSidecarName(req HTTPRequest) HTTPRequest {
+	if req.BodyFile != "" {
+		req.BodyFile = filepath.Base(sidecarPath(req.Path, req.BodyFile))
+	}
+	return req
+}
+
+// bodyFileExt picks a sidecar extension from the request's content type
+func bodyFileExt(req HTTPRequest) string {
+	contentType, _ := lookupHeader(req.Headers, "Content-Type")
+	switch {
+	case strings.Contains(contentType, "json"):
+		return ".json"
+	case strings.Contains(contentType, "xml"):
+		return ".xml"
+	case strings.Contains(contentType, "graphql"):
+		return ".graphql"
+	case strings.Contains(contentType, "html"):
+		return ".html"
+	}
+	if trimmed := strings.TrimSpace(req.Body); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return ".json"
+	}
+	return ".txt"
+}
+
+// readRequestFile reads a saved request in either format, loading its sidecar body
+func readRequestFile(p string) (HTTPRequest, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return HTTPRequest{}, err
+	}
+
+	var req HTTPRequest
+	if isYAMLFile(p) {
+		err = decodeYAMLRequest(data, &req)
+	} else {
+		err = json.Unmarshal(data, &req)
+	}
+	if err != nil {
+		return HTTPRequest{}, fmt.Errorf("%s: %w", p, err)
+	}
+
+	if req.BodyFile != "" {
+		sidecar, err := bodyFilePath(p, req.BodyFile)
+		if err != nil {
+			return HTTPRequest{}, err
+		}
+		body, err := os.ReadFile(sidecar)
+		if err != nil {
+			return HTTPRequest{}, err
+		}
+		req.Body = string(body)
+	}
+	return req, nil
+}
+
+// encodeRequestFile formats a request for its file. The body is left out
+// when it lives in a sidecar file.
+func encodeRequestFile(req HTTPRequest) ([]byte, error) {
+	if req.BodyFile != "" {
+		req.Body = ""
+	}
+	if isYAMLFile(req.Path) {
+		return encodeYAMLRequest(req)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(req); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeYAMLRequest converts the JSON form of a request to YAML, which keeps
+// the field order and names of the JSON files
+func encodeYAMLRequest(req HTTPRequest) ([]byte, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	if root := node.Content[0]; req.BodyFile != "" {
+		removeYAMLKey(root, "body")
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle drops the flow style inherited from JSON and writes multi-line
+// strings as literal blocks
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && strings.Contains(node.Value, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func removeYAMLKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// decodeYAMLRequest reads a YAML request through its JSON form so both
+// formats share the same field names and types
+func decodeYAMLRequest(data []byte, req *HTTPRequest) error {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, req)
+}
+
+// newRequestExt returns the format for newly saved requests: YAML once the
+// requests directory holds any YAML request, JSON otherwise
+func newRequestExt() string {
+	ext := ".json"
+	filepath.WalkDir(requestsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && p != requestsDir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && isYAMLFile(p) && isRequestFile(d.Name()) {
+			ext = ".yaml"
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return ext
+}
+
+// migrateCommand converts every saved request in a directory to another format
+func migrateCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", requestsDir, "directory of saved requests to convert")
+	to := fs.String("to", "yaml", "format to convert to: yaml or json")
+	sidecar := fs.Bool("sidecar", false, "move multi-line bodies into sidecar files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *to != "yaml" && *to != "json" {
+		fmt.Fprintf(stderr, "error: unknown format %q, expected yaml or json\n", *to)
+		return 2
+	}
+
+	requests, _, err := readRequestTree(*dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	migrated := 0
+	for _, req := range requests {
+		if isHTTPFile(req.Path) {
+			continue
+		}
+		from := req.Path
+		req.Path = strings.TrimSuffix(from, filepath.Ext(from)) + "." + *to
+		if *sidecar && req.BodyFile == "" && strings.Contains(req.Body, "\n") {
+			req.BodyFile = filepath.Base(sidecarPath(req.Path, bodyFileExt(req)))
+		}
+		if req.Path == from && !*sidecar {
+			continue
+		}
+		if req.Path != from {
+			if _, err := os.Stat(req.Path); err == nil {
+				fmt.Fprintf(stderr, "error: %s already exists, skipping %s\n", req.Path, from)
+				continue
+			}
+		}
+
+		if err := writeRequestFile(req); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		if req.Path != from {
+			if err := os.Remove(from); err != nil {
+				fmt.Fprintf(stderr, "error: %v\n", err)
+				return 1
+			}
+		}
+		migrated++
+	}
+
+	fmt.Fprintf(stdout, "Converted %d requests in %s to %s\n", migrated, *dir, strings.ToUpper(*to))
+	return 0
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestYAMLRequestRoundTrip tests that YAML requests keep every field and write bodies as block scalars
+func TestYAMLRequestRoundTrip(t *testing.T) {
+	req := HTTPRequest{
+		ID:      "abc",
+		Name:    "Create user",
+		Method:  "POST",
+		URL:     "{{baseUrl}}/users",
+		Headers: map[string]string{"Content-Type": "application/json", "X-Retry": "3"},
+		Body:    "{\n  \"name\": \"ada\",\n  \"admin\": true\n}",
+		Assertions: []Assertion{
+			{Type: assertStatus, Value: "201"},
+			{Type: assertJSONSchema, Schema: json.RawMessage(`{"type":"object","required":["id"]}`)},
+		},
+		Path: "create-user.yaml",
+	}
+
+	data, err := encodeRequestFile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !strings.Contains(out, "body: |-\n  {\n    \"name\": \"ada\",\n") {
+		t.Errorf("Expected the body as a literal block, got:\n%s", out)
+	}
+	if !strings.Contains(out, `X-Retry: "3"`) || !strings.Contains(out, `value: "201"`) {
+		t.Errorf("Expected strings that look like numbers to stay quoted, got:\n%s", out)
+	}
+
+	var back HTTPRequest
+	if err := decodeYAMLRequest(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	back.Path = req.Path
+	if !requestsEqual(normalizeSchema(back), normalizeSchema(req)) {
+		t.Errorf("Expected the request to round trip, got %+v", back)
+	}
+}
+
+// normalizeSchema re-encodes assertion schemas so formatting differences do not matter
+func normalizeSchema(req HTTPRequest) HTTPRequest {
+	assertions := make([]Assertion, len(req.Assertions))
+	for i, a := range req.Assertions {
+		if a.Schema != nil {
+			var v any
+			json.Unmarshal(a.Schema, &v)
+			a.Schema, _ = json.Marshal(v)
+		}
+		assertions[i] = a
+	}
+	req.Assertions = assertions
+	return req
+}
+
+// TestSidecarBody tests that bodies can live in a file next to their request
+func TestSidecarBody(t *testing.T) {
+	chdirTemp(t)
+
+	req := HTTPRequest{Name: "Upload", Method: "POST", URL: "http://x.test", Body: "<a>\n</a>", BodyFile: "anything.xml"}
+	req.Path = filepath.Join(requestsDir, "upload.yaml")
+	req = withSidecarName(req)
+	if err := writeRequestFile(req); err != nil {
+		t.Fatal(err)
+	}
+
+	sidecar := filepath.Join(requestsDir, "upload.body.xml")
+	if data, err := os.ReadFile(sidecar); err != nil || string(data) != "<a>\n</a>" {
+		t.Fatalf("Expected the body in %s, got %q (%v)", sidecar, data, err)
+	}
+	data, _ := os.ReadFile(req.Path)
+	if strings.Contains(string(data), "<a>") || !strings.Contains(string(data), "body_file: upload.body.xml") {
+		t.Errorf("Expected the request file to reference the sidecar only, got:\n%s", data)
+	}
+
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].Body != "<a>\n</a>" {
+		t.Fatalf("Expected one request with the sidecar body, got %+v", requests)
+	}
+
+	// Renaming moves the sidecar along with the request
+	runCmd(t, renameRequest(requests[0], "Upload file"))
+	if _, err := os.Stat(filepath.Join(requestsDir, "upload-file.body.xml")); err != nil {
+		t.Errorf("Expected the sidecar to follow the rename: %v", err)
+	}
+	if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+		t.Errorf("Expected the old sidecar to be removed")
+	}
+}
+
+// TestBodyFilePath tests that body files must be sidecars next to their request
+func TestBodyFilePath(t *testing.T) {
+	chdirTemp(t)
+	for _, bodyFile := range []string{"../../.ssh/id_rsa", "bodies/x.body.json", `..\x.body.json`, "notes.json"} {
+		if _, err := bodyFilePath(filepath.Join(requestsDir, "x.json"), bodyFile); err == nil {
+			t.Errorf("Expected body_file %q to be rejected", bodyFile)
+		}
+	}
+
+	// A request naming another file is not loaded, and that file is not a request either
+	if err := os.MkdirAll(requestsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"steal.json":       `{"name": "steal", "method": "POST", "body_file": "../secret.txt"}`,
+		"custom.json":      `{"name": "custom", "method": "POST", "body_file": "shared.body.json"}`,
+		"shared.body.json": `{"a": 1}`,
+	} {
+		if err := os.WriteFile(filepath.Join(requestsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].Name != "custom" || requests[0].Body != `{"a": 1}` {
+		t.Fatalf("Expected only the request with a valid body file, got %+v", requests)
+	}
+
+	// Saving in place writes the body file the request names
+	req := requests[0]
+	req.Body = `{"a": 2}`
+	if err := writeRequestFile(req); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(requestsDir, "shared.body.json")); string(data) != `{"a": 2}` {
+		t.Errorf("Expected the named body file to be updated, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(requestsDir, "custom.body.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected no second body file")
+	}
+
+	// Deleting takes the named body file to the trash
+	trashed := runCmd(t, trashRequest(req)).(requestTrashedMsg)
+	if _, err := os.Stat(filepath.Join(requestsDir, "shared.body.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected the body file to be trashed")
+	}
+	runCmd(t, restoreRequest(trashEntry(trashed)))
+	if data, _ := os.ReadFile(filepath.Join(requestsDir, "shared.body.json")); string(data) != `{"a": 2}` {
+		t.Errorf("Expected the body file to be restored, got %q", data)
+	}
+}
+
+// TestMigrateCommand tests that JSON requests are converted to YAML and new requests follow
+func TestMigrateCommand(t *testing.T) {
+	chdirTemp(t)
+	runCmd(t, saveRequest(HTTPRequest{Name: "One", Method: "POST", URL: "http://x.test", Headers: map[string]string{"Content-Type": "application/json"}, Body: "{\n  \"a\": 1\n}"}))
+	runCmd(t, saveRequest(HTTPRequest{Name: "Two", Method: "GET", URL: "http://x.test", Folder: "sub"}))
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"migrate", "-sidecar"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected migrate to succeed, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Converted 2 requests") {
+		t.Errorf("Unexpected output: %s", stdout.String())
+	}
+
+	for _, p := range []string{"one.yaml", "one.body.json", filepath.Join("sub", "two.yaml")} {
+		if _, err := os.Stat(filepath.Join(requestsDir, p)); err != nil {
+			t.Errorf("Expected %s after migrating: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(requestsDir, "one.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected one.json to be removed")
+	}
+
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests after migrating, got %d", len(requests))
+	}
+
+	saved := runCmd(t, saveRequest(HTTPRequest{Name: "Three", Method: "GET", URL: "http://x.test"})).(requestSavedMsg)
+	if filepath.Ext(saved.Path) != ".yaml" {
+		t.Errorf("Expected new requests to be saved as YAML, got %s", saved.Path)
+	}
+}
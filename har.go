@@ -0,0 +1,472 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportsDir is where HAR files exported from the interactive client are written
+var exportsDir = "exports"
+
+// HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []harNameValue `json:"params,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// newHARContent describes a response body, encoding it as base64 when it
+// is binary, since HAR text must be valid UTF-8
+func newHARContent(body, mimeType string) harContent {
+	content := harContent{Size: len(body), MimeType: mimeType, Text: body}
+	if !utf8.ValidString(body) {
+		content.Text = base64.StdEncoding.EncodeToString([]byte(body))
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+// harTimings are in milliseconds, -1 when a phase does not apply
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// harExportedMsg reports the file a HAR export was written to
+type harExportedMsg string
+
+// newHAR converts history entries into a HAR log
+func newHAR(entries []HistoryEntry) harFile {
+	har := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "whelm", Version: "1.0"},
+		Entries: []harEntry{},
+	}}
+	for _, e := range entries {
+		har.Log.Entries = append(har.Log.Entries, harFromExchange(e))
+	}
+	return har
+}
+
+func harFromExchange(e HistoryEntry) harEntry {
+	req, resp := e.Request, e.Response
+
+	request := harRequest{
+		Method:      req.Method,
+		URL:         req.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Headers),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(req.Body),
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		for _, name := range sortedKeys(u.Query()) {
+			for _, v := range u.Query()[name] {
+				request.QueryString = append(request.QueryString, harNameValue{Name: name, Value: v})
+			}
+		}
+	}
+	if req.Body != "" {
+		mimeType, _ := lookupHeader(req.Headers, "Content-Type")
+		request.PostData = &harPostData{MimeType: mimeType, Text: req.Body}
+	}
+
+	mimeType, _ := lookupHeader(resp.Headers, "Content-Type")
+	response := harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(resp.Headers),
+		Content:     newHARContent(resp.Body, mimeType),
+		HeadersSize: -1,
+		BodySize:    len(resp.Body),
+	}
+	response.RedirectURL, _ = lookupHeader(resp.Headers, "Location")
+
+	return harEntry{
+		StartedDateTime: e.Time,
+		Time:            milliseconds(resp.Duration),
+		Request:         request,
+		Response:        response,
+		Timings:         harTimingsFrom(resp),
+	}
+}
+
+// harTimingsFrom converts recorded timings. Without them the whole duration counts as waiting.
+func harTimingsFrom(resp HTTPResponse) harTimings {
+	t := resp.Timings
+	if t == nil {
+		return harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: milliseconds(resp.Duration)}
+	}
+	timings := harTimings{
+		Blocked: milliseconds(t.Blocked),
+		DNS:     milliseconds(t.DNS),
+		// HAR counts the TLS handshake as part of connecting
+		Connect: milliseconds(t.Connect + t.TLS),
+		Send:    milliseconds(t.Send),
+		Wait:    milliseconds(t.Wait),
+		Receive: milliseconds(t.Receive),
+		SSL:     milliseconds(t.TLS),
+	}
+	if t.Reused {
+		timings.DNS, timings.Connect, timings.SSL = -1, -1, -1
+	} else if t.TLS == 0 {
+		timings.SSL = -1
+	}
+	return timings
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func harHeaders(headers map[string]string) []harNameValue {
+	list := []harNameValue{}
+	for _, name := range sortedKeys(headers) {
+		list = append(list, harNameValue{Name: name, Value: headers[name]})
+	}
+	return list
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseHAR reads the entries of a HAR file as history entries
+func parseHAR(data []byte) ([]HistoryEntry, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, err
+	}
+	if har.Log.Version == "" && har.Log.Entries == nil {
+		return nil, fmt.Errorf("not a HAR file")
+	}
+
+	var entries []HistoryEntry
+	for _, e := range har.Log.Entries {
+		req := HTTPRequest{
+			Method:  strings.ToUpper(e.Request.Method),
+			URL:     e.Request.URL,
+			Headers: make(map[string]string),
+		}
+		for _, h := range e.Request.Headers {
+			// HTTP/2 pseudo headers and computed headers are not sent by whelm
+			if strings.HasPrefix(h.Name, ":") || strings.EqualFold(h.Name, "Content-Length") {
+				continue
+			}
+			req.Headers[h.Name] = h.Value
+		}
+		if pd := e.Request.PostData; pd != nil {
+			req.Body = pd.Text
+			if req.Body == "" && len(pd.Params) > 0 {
+				var params [][2]string
+				for _, p := range pd.Params {
+					params = append(params, [2]string{p.Name, p.Value})
+				}
+				req.Body = encodeParams(params)
+			}
+			if pd.MimeType != "" && !hasHeader(req.Headers, "Content-Type") {
+				req.Headers["Content-Type"] = pd.MimeType
+			}
+		}
+
+		resp := HTTPResponse{
+			StatusCode: e.Response.Status,
+			Status:     strings.TrimSpace(fmt.Sprintf("%d %s", e.Response.Status, e.Response.StatusText)),
+			Headers:    make(map[string]string),
+			Body:       e.Response.Content.Text,
+			Duration:   time.Duration(e.Time * float64(time.Millisecond)),
+		}
+		for _, h := range e.Response.Headers {
+			if strings.HasPrefix(h.Name, ":") {
+				continue
+			}
+			if v, ok := resp.Headers[h.Name]; ok {
+				resp.Headers[h.Name] = v + ", " + h.Value
+			} else {
+				resp.Headers[h.Name] = h.Value
+			}
+		}
+		if e.Response.Content.Encoding == "base64" {
+			if decoded, err := base64.StdEncoding.DecodeString(resp.Body); err == nil {
+				resp.Body = string(decoded)
+			}
+		}
+		resp.Timings = timingsFromHAR(e.Timings)
+
+		entries = append(entries, HistoryEntry{Time: e.StartedDateTime, Request: req, Response: resp})
+	}
+	return entries, nil
+}
+
+func timingsFromHAR(t harTimings) *Timings {
+	d := func(ms float64) time.Duration {
+		if ms < 0 {
+			return 0
+		}
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	timings := &Timings{
+		Blocked: d(t.Blocked),
+		DNS:     d(t.DNS),
+		Connect: d(t.Connect) - d(t.SSL),
+		TLS:     d(t.SSL),
+		Send:    d(t.Send),
+		Wait:    d(t.Wait),
+		Receive: d(t.Receive),
+		Reused:  t.Connect < 0,
+	}
+	if timings.Connect < 0 {
+		timings.Connect = 0
+	}
+	return timings
+}
+
+// importHAR converts the requests of a HAR file into saved requests, one folder per host
+func importHAR(data []byte) (*importResult, error) {
+	entries, err := parseHAR(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newImportResult("har")
+	names := make(map[string]int)
+	for _, e := range entries {
+		req := e.Request
+		u, err := url.Parse(req.URL)
+		if err != nil || u.Host == "" {
+			result.note("%s %s: invalid URL", req.Method, req.URL)
+			continue
+		}
+		req.Folder = slugify(u.Hostname())
+
+		// Captures often repeat the same request; keep names unique within a host
+		name := req.Method + " " + u.EscapedPath()
+		key := req.Folder + "\x00" + name
+		names[key]++
+		if n := names[key]; n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+		req.Name = name
+
+		result.Requests = append(result.Requests, req)
+		result.History = append(result.History, e)
+	}
+	return result, nil
+}
+
+// exchangeFilter selects exchanges by host and method
+type exchangeFilter struct {
+	domains listFlag
+	methods listFlag
+}
+
+// match reports whether a request passes the filter. Domains also match their subdomains.
+func (f exchangeFilter) match(req HTTPRequest) bool {
+	if len(f.methods) > 0 && !containsFold(f.methods, req.Method) {
+		return false
+	}
+	if len(f.domains) == 0 {
+		return true
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return false
+	}
+	return inDomains(u.Hostname(), f.domains)
+}
+
+// inDomains reports whether host is one of domains or a subdomain of one
+func inDomains(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(d, "."))
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// listFlag is a flag that may be repeated or given a comma separated list
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// exportCommand writes the history as a HAR file
+func exportCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "har" {
+		fmt.Fprintf(stderr, "usage: whelm export har [-o FILE] [-domain HOST] [-method METHOD] [-last N]\n")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("export har", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("o", "", "file to write (default stdout)")
+	last := fs.Int("last", 0, "only export the N most recent exchanges")
+	var filter exchangeFilter
+	fs.Var(&filter.domains, "domain", "only export requests to this host or its subdomains")
+	fs.Var(&filter.methods, "method", "only export requests with this method")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	history, err := readHistory()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	var entries []HistoryEntry
+	for _, e := range history {
+		if filter.match(e.Request) {
+			entries = append(entries, e)
+		}
+	}
+	if *last > 0 && len(entries) > *last {
+		entries = entries[len(entries)-*last:]
+	}
+
+	w := stdout
+	var f *os.File
+	if *out != "" {
+		if f, err = os.Create(*out); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		w = f
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(newHAR(entries))
+	// Write errors may only show up when the file is closed
+	if f != nil {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	if *out != "" {
+		fmt.Fprintf(stdout, "Exported %d exchanges to %s\n", len(entries), *out)
+	}
+	return 0
+}
+
+// exportHAR writes exchanges to a timestamped HAR file in exportsDir
+func exportHAR(name string, entries []HistoryEntry) tea.Cmd {
+	return func() tea.Msg {
+		if err := os.MkdirAll(exportsDir, 0755); err != nil {
+			return errMsg{err}
+		}
+		file := filepath.Join(exportsDir, fmt.Sprintf("%s-%s.har", slugify(name), time.Now().Format("20060102-150405")))
+		if err := writeIndentedJSON(file, newHAR(entries)); err != nil {
+			return errMsg{err}
+		}
+		return harExportedMsg(file)
+	}
+}
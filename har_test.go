@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testHAR = `{
+	"log": {
+		"version": "1.2",
+		"creator": {"name": "devtools", "version": "1"},
+		"entries": [
+			{
+				"startedDateTime": "2024-05-01T10:00:00.000Z",
+				"time": 120.5,
+				"request": {
+					"method": "GET",
+					"url": "https://api.example.com/users?page=2",
+					"headers": [{"name": ":authority", "value": "api.example.com"}, {"name": "Accept", "value": "application/json"}]
+				},
+				"response": {
+					"status": 200,
+					"statusText": "OK",
+					"headers": [{"name": "Content-Type", "value": "application/json"}],
+					"content": {"mimeType": "application/json", "text": "eyJvayI6dHJ1ZX0=", "encoding": "base64"}
+				},
+				"timings": {"blocked": 1, "dns": 2, "connect": 30, "ssl": 20, "send": 0.5, "wait": 80, "receive": 7}
+			},
+			{
+				"startedDateTime": "2024-05-01T10:00:01.000Z",
+				"time": 50,
+				"request": {
+					"method": "POST",
+					"url": "https://api.example.com/users",
+					"postData": {"mimeType": "application/json", "text": "{\"name\":\"ada\"}"}
+				},
+				"response": {"status": 201, "statusText": "Created", "content": {"text": ""}},
+				"timings": {"send": 1, "wait": 40, "receive": 9}
+			},
+			{
+				"startedDateTime": "2024-05-01T10:00:02.000Z",
+				"time": 10,
+				"request": {"method": "GET", "url": "https://cdn.other.net/app.js"},
+				"response": {"status": 200, "statusText": "OK", "content": {"text": "x"}},
+				"timings": {"wait": 10}
+			}
+		]
+	}
+}`
+
+// TestParseHAR tests that HAR entries are converted into exchanges with timings
+func TestParseHAR(t *testing.T) {
+	entries, err := parseHAR([]byte(testHAR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	get := entries[0]
+	if _, ok := get.Request.Headers[":authority"]; ok || get.Request.Headers["Accept"] != "application/json" {
+		t.Errorf("Expected pseudo headers to be dropped, got %v", get.Request.Headers)
+	}
+	if get.Response.Body != `{"ok":true}` {
+		t.Errorf("Expected the base64 body to be decoded, got %q", get.Response.Body)
+	}
+	if get.Response.Status != "200 OK" || get.Response.Duration != 120500*time.Microsecond {
+		t.Errorf("Unexpected response %q in %s", get.Response.Status, get.Response.Duration)
+	}
+	if tm := get.Response.Timings; tm.TLS != 20*time.Millisecond || tm.Connect != 10*time.Millisecond {
+		t.Errorf("Expected TLS split from connect, got %+v", tm)
+	}
+
+	post := entries[1]
+	if post.Request.Body != `{"name":"ada"}` || post.Request.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected the post data as body, got %+v", post.Request)
+	}
+
+	if _, err := parseHAR([]byte(`{"foo": 1}`)); err == nil {
+		t.Errorf("Expected an error for a file that is not HAR")
+	}
+}
+
+// TestHARExportRoundTrip tests that exported HAR files can be imported again
+func TestHARExportRoundTrip(t *testing.T) {
+	entries, err := parseHAR([]byte(testHAR))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(newHAR(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"version":"1.2"`) || !strings.Contains(string(data), `"queryString":[{"name":"page","value":"2"}]`) {
+		t.Errorf("Expected a HAR 1.2 log with query parameters, got %s", data)
+	}
+
+	again, err := parseHAR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range entries {
+		if again[i].Request.URL != entries[i].Request.URL || again[i].Response.Body != entries[i].Response.Body {
+			t.Errorf("Entry %d changed in the round trip: %+v", i, again[i])
+		}
+		if again[i].Response.Timings.Wait != entries[i].Response.Timings.Wait {
+			t.Errorf("Expected wait timing %s, got %s", entries[i].Response.Timings.Wait, again[i].Response.Timings.Wait)
+		}
+	}
+
+	// Reused connections have no DNS, connect or SSL phase
+	timings := harTimingsFrom(HTTPResponse{Timings: &Timings{Wait: time.Millisecond, Reused: true}})
+	if timings.DNS != -1 || timings.Connect != -1 || timings.SSL != -1 || timings.Wait != 1 {
+		t.Errorf("Unexpected timings for a reused connection: %+v", timings)
+	}
+
+	// Binary bodies are base64 encoded and decoded again on import
+	binary := HistoryEntry{Time: time.Now(), Request: HTTPRequest{Method: "GET", URL: "http://example.test/logo.png"},
+		Response: HTTPResponse{StatusCode: 200, Headers: map[string]string{"Content-Type": "image/png"}, Body: "\x89PNG\r\n\x1a\n\xff\x00"}}
+	data, err = json.Marshal(newHAR([]HistoryEntry{binary}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"encoding":"base64"`) {
+		t.Errorf("Expected the binary body to be base64 encoded, got %s", data)
+	}
+	again, err = parseHAR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[0].Response.Body != binary.Response.Body {
+		t.Errorf("Expected the binary body to round trip, got %q", again[0].Response.Body)
+	}
+}
+
+// TestImportHARCommand tests that HAR files are imported as saved requests or history with filters
+func TestImportHARCommand(t *testing.T) {
+	chdirTemp(t)
+	if err := os.WriteFile("capture.har", []byte(testHAR), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"import", "har", "-domain", "example.com", "capture.har"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected import to succeed, got %d: %s", code, stderr.String())
+	}
+	names := savedNames(t)
+	if len(names) != 2 || names["GET /users"] != "har/api-example-com" {
+		t.Errorf("Expected the two example.com requests in a folder per host, got %v", names)
+	}
+
+	if code := runCLI([]string{"import", "har", "-history", "-method", "get", "capture.har"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected history import to succeed, got %d: %s", code, stderr.String())
+	}
+	history, err := readHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Request.Method != "GET" {
+		t.Errorf("Expected the two GET exchanges in history, got %d", len(history))
+	}
+
+	stdout.Reset()
+	if code := runCLI([]string{"export", "har", "-domain", "other.net"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected export to succeed, got %d: %s", code, stderr.String())
+	}
+	exported, err := parseHAR(stdout.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 || exported[0].Request.URL != "https://cdn.other.net/app.js" {
+		t.Errorf("Expected only the other.net exchange, got %+v", exported)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyFile is the log of sent requests and their responses, one JSON entry per line
+var historyFile = filepath.Join("history", "history.jsonl")
+
+// HistoryEntry is a request together with the response it received
+type HistoryEntry struct {
+	Time     time.Time    `json:"time"`
+	Request  HTTPRequest  `json:"request"`
+	Response HTTPResponse `json:"response"`
+}
+
+// Timings breaks down the duration of a request into the phases used by HAR
+type Timings struct {
+	Blocked time.Duration `json:"blocked"`
+	DNS     time.Duration `json:"dns"`
+	Connect time.Duration `json:"connect"`
+	TLS     time.Duration `json:"tls"`
+	Send    time.Duration `json:"send"`
+	Wait    time.Duration `json:"wait"`
+	Receive time.Duration `json:"receive"`
+	Reused  bool          `json:"reused,omitempty"`
+}
+
+// requestTrace records when each phase of a request started and finished.
+// The trace callbacks may run on the dialer's goroutines, so mu guards it.
+type requestTrace struct {
+	mu                                                  sync.Mutex
+	start, dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, gotConn, wroteRequest, firstByte time.Time
+	reused                                              bool
+}
+
+func (rt *requestTrace) clientTrace() *httptrace.ClientTrace {
+	record := func(t *time.Time) {
+		rt.mu.Lock()
+		defer rt.mu.Unlock()
+		*t = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { record(&rt.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { record(&rt.dnsDone) },
+		ConnectStart:      func(string, string) { record(&rt.connectStart) },
+		ConnectDone:       func(string, string, error) { record(&rt.connectDone) },
+		TLSHandshakeStart: func() { record(&rt.tlsStart) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.gotConn, rt.reused = time.Now(), info.Reused
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { record(&rt.wroteRequest) },
+		GotFirstResponseByte: func() { record(&rt.firstByte) },
+	}
+}
+
+// timings converts the recorded instants into phase durations, end being when the body was read
+func (rt *requestTrace) timings(end time.Time) *Timings {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	between := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return 0
+		}
+		return to.Sub(from)
+	}
+
+	// The handshake finishes just before the connection is handed over
+	if !rt.tlsStart.IsZero() {
+		rt.tlsDone = rt.gotConn
+	}
+	blockedUntil := rt.gotConn
+	for _, t := range []time.Time{rt.connectStart, rt.dnsStart} {
+		if !t.IsZero() {
+			blockedUntil = t
+		}
+	}
+
+	return &Timings{
+		Blocked: between(rt.start, blockedUntil),
+		DNS:     between(rt.dnsStart, rt.dnsDone),
+		Connect: between(rt.connectStart, rt.connectDone),
+		TLS:     between(rt.tlsStart, rt.tlsDone),
+		Send:    between(rt.gotConn, rt.wroteRequest),
+		Wait:    between(rt.wroteRequest, rt.firstByte),
+		Receive: between(rt.firstByte, end),
+		Reused:  rt.reused,
+	}
+}
+
+// historyMsg carries the history, newest entry first
+type historyMsg []HistoryEntry
+
+// recordHistory appends an exchange to the history file
+func recordHistory(req HTTPRequest, resp HTTPResponse) tea.Cmd {
+	return func() tea.Msg {
+		if err := appendHistory(HistoryEntry{Time: time.Now().Add(-resp.Duration), Request: req, Response: resp}); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// loadHistory reads the history for the history view
+func loadHistory() tea.Msg {
+	entries, err := readHistory()
+	if err != nil {
+		return errMsg{err}
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return historyMsg(entries)
+}
+
+// appendHistory adds entries to the end of the history file
+func appendHistory(entries ...HistoryEntry) error {
+	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	for _, entry := range entries {
+		// Saved request metadata is not part of the exchange
+		entry.Request.Folder, entry.Request.Path = "", ""
+		if err := encoder.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// readHistory reads every history entry, oldest first
+func readHistory() ([]HistoryEntry, error) {
+	f, err := os.Open(historyFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", historyFile, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// historyItem is a row of the history view
+type historyItem struct {
+	entry HistoryEntry
+}
+
+func (h historyItem) Title() string {
+	return h.entry.Request.Method + " " + h.entry.Request.URL
+}
+
+func (h historyItem) Description() string {
+	return fmt.Sprintf("%s • %s • %dms", h.entry.Response.Status,
+		h.entry.Time.Local().Format("2006-01-02 15:04:05"), h.entry.Response.Duration.Milliseconds())
+}
+
+func (h historyItem) FilterValue() string { return h.Title() }
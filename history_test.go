@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHistoryRoundTrip tests that appended exchanges are read back in order
+func TestHistoryRoundTrip(t *testing.T) {
+	chdirTemp(t)
+
+	if entries, err := readHistory(); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected empty history, got %d entries (%v)", len(entries), err)
+	}
+
+	first := HistoryEntry{Time: time.Now(), Request: HTTPRequest{Method: "GET", URL: "http://a.test/1", Path: "requests/a.json"}, Response: HTTPResponse{StatusCode: 200}}
+	second := HistoryEntry{Time: time.Now(), Request: HTTPRequest{Method: "POST", URL: "http://a.test/2"}, Response: HTTPResponse{StatusCode: 201}}
+	if err := appendHistory(first); err != nil {
+		t.Fatal(err)
+	}
+	if msg := recordHistory(second.Request, second.Response)(); msg != nil {
+		t.Fatalf("Expected no message from recordHistory, got %v", msg)
+	}
+
+	entries, err := readHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Request.URL != "http://a.test/1" || entries[1].Response.StatusCode != 201 {
+		t.Fatalf("Unexpected history: %+v", entries)
+	}
+	if entries[0].Request.Path != "" {
+		t.Errorf("Expected saved request paths to be dropped, got %q", entries[0].Request.Path)
+	}
+
+	// The history view lists the newest exchange first
+	msg, ok := loadHistory().(historyMsg)
+	if !ok || len(msg) != 2 || msg[0].Request.Method != "POST" {
+		t.Errorf("Expected newest entry first, got %+v", msg)
+	}
+}
+
+// TestExecuteRequestTimings tests that requests record the time spent in each phase
+func TestExecuteRequestTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := executeRequest(HTTPRequest{Method: "GET", URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	timings := resp.Timings
+	if timings == nil {
+		t.Fatal("Expected timings to be recorded")
+	}
+	if timings.Wait < 10*time.Millisecond {
+		t.Errorf("Expected at least 10ms waiting for the server, got %s", timings.Wait)
+	}
+	total := timings.Blocked + timings.DNS + timings.Connect + timings.TLS + timings.Send + timings.Wait + timings.Receive
+	if total > resp.Duration {
+		t.Errorf("Expected phases (%s) to fit within the duration %s", total, resp.Duration)
+	}
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// .http files, as used by VS Code REST Client and JetBrains HTTP Client,
+// hold several requests separated by ### lines:
+//
+//	@baseUrl = https://api.example.com
+//
+//	### List users
+//	# @name listUsers
+//	GET {{baseUrl}}/users
+//	Accept: application/json
+//
+// Requests read from one keep the file as their Path and their position in
+// it as their Index. Saving a request re-renders only its own block, so
+// comments and the layout of the rest of the file are preserved. Fields the
+// format cannot express, such as assertions, are kept in "# @" comments
+// holding JSON:
+//
+//	# @assertions [{"type":"status","value":"200"}]
+//
+// Basic auth is written in REST Client's "Basic user password" form, which
+// is read back as the request's auth.
+
+var (
+	httpVariableLine   = regexp.MustCompile(`^@([A-Za-z_][A-Za-z0-9_.\-]*)\s*=\s*(.*)$`)
+	httpNameAnnotation = regexp.MustCompile(`^(?:#|//)\s*@name\s+(\S+)`)
+	httpMetaAnnotation = regexp.MustCompile(`^(?:#|//)\s*@(assertions|extract|spec|ignore|mock)\s+(.+)$`)
+	httpRequestLine    = regexp.MustCompile(`^(GET|POST|PUT|DELETE|PATCH|HEAD|OPTIONS|CONNECT|TRACE)\s+(\S.*)$`)
+	httpVersionSuffix  = regexp.MustCompile(`\s+HTTP/[0-9.]+$`)
+)
+
+// isHTTPFile reports whether a path is in the .http format
+func isHTTPFile(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	return ext == ".http" || ext == ".rest"
+}
+
+// httpBlock is the text between two ### separators
+type httpBlock struct {
+	lines   []string
+	vars    map[string]string
+	request *HTTPRequest
+}
+
+// httpFile is a parsed .http file
+type httpFile struct {
+	blocks []httpBlock
+}
+
+// parseHTTPFile splits a file into blocks and parses the request in each
+func parseHTTPFile(data string) *httpFile {
+	f := &httpFile{}
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			f.blocks = append(f.blocks, parseHTTPBlock(lines))
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "###") {
+			flush()
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	// Trailing blank lines belong to the file, not to the last block
+	if n := len(f.blocks); n > 0 {
+		last := &f.blocks[n-1]
+		for len(last.lines) > 0 && strings.TrimSpace(last.lines[len(last.lines)-1]) == "" {
+			last.lines = last.lines[:len(last.lines)-1]
+		}
+	}
+	return f
+}
+
+func parseHTTPBlock(lines []string) httpBlock {
+	block := httpBlock{lines: lines, vars: make(map[string]string)}
+
+	title, name := "", ""
+	var meta HTTPRequest
+	i := 0
+	if strings.HasPrefix(lines[0], "###") {
+		title = strings.TrimSpace(strings.TrimLeft(lines[0], "#"))
+		i = 1
+	}
+
+	// Comments, annotations and variables come before the request line
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if m := httpNameAnnotation.FindStringSubmatch(line); m != nil {
+			name = m[1]
+			continue
+		}
+		if m := httpMetaAnnotation.FindStringSubmatch(line); m != nil {
+			meta.setHTTPMetadata(m[1], strings.TrimSpace(m[2]))
+			continue
+		}
+		if m := httpVariableLine.FindStringSubmatch(line); m != nil {
+			block.vars[m[1]] = strings.TrimSpace(m[2])
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		break
+	}
+	if i == len(lines) {
+		return block
+	}
+
+	req := meta
+	req.Method, req.Headers = "GET", make(map[string]string)
+	line := strings.TrimSpace(lines[i])
+	if m := httpRequestLine.FindStringSubmatch(line); m != nil {
+		req.Method, req.URL = m[1], m[2]
+	} else {
+		req.URL = httpVersionSuffix.ReplaceAllString(line, "")
+	}
+	i++
+
+	// Query parameters may continue on the following lines
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		req.URL += line
+	}
+	req.URL = httpVersionSuffix.ReplaceAllString(req.URL, "")
+
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+			if existing, ok := req.Headers[k]; ok {
+				v = existing + ", " + v
+			}
+			req.Headers[k] = v
+		}
+	}
+
+	for k, v := range req.Headers {
+		if auth := parseHTTPBasicAuth(k, v); auth != nil {
+			req.Auth = auth
+			delete(req.Headers, k)
+		}
+	}
+
+	body := lines[i:]
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	req.Body = strings.Join(body, "\n")
+
+	switch {
+	case name != "":
+		req.Name = name
+	case title != "":
+		req.Name = title
+	default:
+		req.Name = req.Method + " " + httpDisplayPath(req.URL)
+	}
+	block.request = &req
+	return block
+}
+
+// setHTTPMetadata sets a field read from a "# @" comment, ignoring values
+// that are not valid JSON
+func (req *HTTPRequest) setHTTPMetadata(key, value string) {
+	switch key {
+	case "assertions":
+		json.Unmarshal([]byte(value), &req.Assertions)
+	case "extract":
+		json.Unmarshal([]byte(value), &req.Extract)
+	case "spec":
+		req.Spec = value
+	case "ignore":
+		json.Unmarshal([]byte(value), &req.Ignore)
+	case "mock":
+		json.Unmarshal([]byte(value), &req.Mock)
+	}
+}
+
+// httpMetadataLines renders the fields .http files cannot express as "# @" comments
+func httpMetadataLines(req HTTPRequest) []string {
+	var lines []string
+	add := func(key string, v any) {
+		// Marshalling these plain types cannot fail
+		data, _ := json.Marshal(v)
+		lines = append(lines, "# @"+key+" "+string(data))
+	}
+	if len(req.Assertions) > 0 {
+		add("assertions", req.Assertions)
+	}
+	if len(req.Extract) > 0 {
+		add("extract", req.Extract)
+	}
+	if req.Spec != "" {
+		lines = append(lines, "# @spec "+req.Spec)
+	}
+	if len(req.Ignore) > 0 {
+		add("ignore", req.Ignore)
+	}
+	if req.Mock != nil {
+		add("mock", req.Mock)
+	}
+	return lines
+}
+
+// parseHTTPBasicAuth reads an Authorization header holding plain credentials,
+// as "Basic user password" or "Basic user:password". Encoded credentials are
+// left as a header.
+func parseHTTPBasicAuth(header, value string) *Auth {
+	scheme, creds, ok := strings.Cut(value, " ")
+	if !strings.EqualFold(header, "Authorization") || !ok || !strings.EqualFold(scheme, "Basic") {
+		return nil
+	}
+	creds = strings.TrimSpace(creds)
+	user, password, ok := strings.Cut(creds, " ")
+	if !ok {
+		user, password, ok = strings.Cut(creds, ":")
+	}
+	if !ok {
+		return nil
+	}
+	return &Auth{Type: authBasic, Username: user, Password: strings.TrimSpace(password)}
+}
+
+// httpDisplayPath shortens a URL to its path for generated request names
+func httpDisplayPath(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return rawURL
+}
+
+// variables returns the file variables. Like REST Client, a variable is
+// visible to every request in the file wherever it is defined.
+func (f *httpFile) variables() map[string]string {
+	vars := make(map[string]string)
+	for _, b := range f.blocks {
+		for k, v := range b.vars {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// requests returns the requests of the file with the file variables attached
+func (f *httpFile) requests() []HTTPRequest {
+	vars := f.variables()
+	var requests []HTTPRequest
+	for _, b := range f.blocks {
+		if b.request == nil {
+			continue
+		}
+		req := *b.request
+		req.Index = len(requests)
+		if len(vars) > 0 {
+			req.Variables = mergeVariables(vars)
+		}
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+// blockIndex returns the block holding the request at index, or -1
+func (f *httpFile) blockIndex(index int) int {
+	n := 0
+	for i, b := range f.blocks {
+		if b.request == nil {
+			continue
+		}
+		if n == index {
+			return i
+		}
+		n++
+	}
+	return -1
+}
+
+// setRequest replaces the request at req.Index, or appends req when the
+// index is past the end, and stores its variables as file variables
+func (f *httpFile) setRequest(req HTTPRequest) {
+	block := parseHTTPBlock(renderHTTPRequest(req))
+	if i := f.blockIndex(req.Index); i >= 0 {
+		// Variables defined in the replaced block stay in the file
+		block.lines = append(f.blocks[i].varLines(), block.lines...)
+		block.vars = f.blocks[i].vars
+		f.blocks[i] = block
+	} else {
+		f.blocks = append(f.blocks, block)
+	}
+	f.setVariables(req.Variables)
+}
+
+// insertRequest puts req back at position req.Index
+func (f *httpFile) insertRequest(req HTTPRequest) {
+	block := parseHTTPBlock(renderHTTPRequest(req))
+	if i := f.blockIndex(req.Index); i >= 0 {
+		f.blocks = append(f.blocks[:i], append([]httpBlock{block}, f.blocks[i:]...)...)
+	} else {
+		f.blocks = append(f.blocks, block)
+	}
+	f.setVariables(req.Variables)
+}
+
+// removeRequest drops the request at index, keeping any variables defined in its block
+func (f *httpFile) removeRequest(index int) {
+	i := f.blockIndex(index)
+	if i < 0 {
+		return
+	}
+	if lines := f.blocks[i].varLines(); len(lines) > 0 {
+		f.blocks[i] = httpBlock{lines: lines, vars: f.blocks[i].vars}
+		return
+	}
+	f.blocks = append(f.blocks[:i], f.blocks[i+1:]...)
+}
+
+// varLines returns the variable definitions of a block
+func (b httpBlock) varLines() []string {
+	var lines []string
+	for _, line := range b.lines {
+		if httpVariableLine.MatchString(strings.TrimSpace(line)) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// setVariables updates changed file variables where they are defined and
+// adds new ones at the top of the file
+func (f *httpFile) setVariables(vars map[string]string) {
+	current := f.variables()
+	var added []string
+	for _, k := range sortedKeys(vars) {
+		v := vars[k]
+		old, ok := current[k]
+		if ok && old == v {
+			continue
+		}
+		if !ok {
+			added = append(added, fmt.Sprintf("@%s = %s", k, v))
+			continue
+		}
+		for bi := range f.blocks {
+			b := &f.blocks[bi]
+			for li, line := range b.lines {
+				if m := httpVariableLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil && m[1] == k {
+					b.lines[li] = fmt.Sprintf("@%s = %s", k, v)
+					b.vars[k] = v
+				}
+			}
+		}
+	}
+	if len(added) > 0 {
+		added = append(added, "")
+		block := parseHTTPBlock(added)
+		f.blocks = append([]httpBlock{block}, f.blocks...)
+	}
+}
+
+// String renders the file, separating blocks with a blank line
+func (f *httpFile) String() string {
+	var parts []string
+	for _, b := range f.blocks {
+		lines := b.lines
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > 0 {
+			parts = append(parts, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// renderHTTPRequest formats a request as a ### block. Auth is written as headers.
+func renderHTTPRequest(req HTTPRequest) []string {
+	lines := []string{"### " + req.Name}
+	if !strings.ContainsAny(req.Name, " \t") {
+		lines = append(lines, "# @name "+req.Name)
+	}
+	lines = append(lines, httpMetadataLines(req)...)
+	lines = append(lines, req.Method+" "+req.URL)
+
+	headers := make(map[string]string)
+	for k, v := range req.Headers {
+		headers[k] = v
+	}
+	if auth := req.Auth; auth != nil {
+		switch auth.Type {
+		case authBasic:
+			if strings.ContainsAny(auth.Username, " \t:") {
+				// Only encoded credentials can hold these
+				headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password))
+			} else if auth.Password == "" {
+				headers["Authorization"] = "Basic " + auth.Username + ":"
+			} else {
+				headers["Authorization"] = "Basic " + auth.Username + " " + auth.Password
+			}
+		case authBearer:
+			headers["Authorization"] = "Bearer " + auth.Token
+		case authAPIKey:
+			if auth.Key != "" {
+				headers[auth.Key] = auth.Value
+			}
+		}
+	}
+	for _, k := range sortedKeys(headers) {
+		lines = append(lines, k+": "+headers[k])
+	}
+
+	if req.Body != "" {
+		lines = append(lines, "", req.Body)
+	}
+	return lines
+}
+
+// readHTTPFile reads the requests of a .http file
+func readHTTPFile(p string) ([]HTTPRequest, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return parseHTTPFile(string(data)).requests(), nil
+}
+
+// updateHTTPFile applies change to the file at p and writes it back
+func updateHTTPFile(p string, change func(f *httpFile)) error {
+	data, err := os.ReadFile(p)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f := parseHTTPFile(string(data))
+	change(f)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, []byte(f.String()), 0644)
+}
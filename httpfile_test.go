@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testHTTPFile = `@baseUrl = https://api.example.com
+@token = secret
+
+### List users
+# Fetches the first page
+GET {{baseUrl}}/users
+    ?page=1
+    &size=20 HTTP/1.1
+Authorization: Bearer {{token}}
+Accept: application/json
+
+###
+# @name createUser
+POST {{baseUrl}}/users
+Content-Type: application/json
+
+{
+  "name": "ada"
+}
+
+###
+{{baseUrl}}/health
+`
+
+// TestParseHTTPFile tests that requests, names and file variables are read from a .http file
+func TestParseHTTPFile(t *testing.T) {
+	requests := parseHTTPFile(testHTTPFile).requests()
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(requests))
+	}
+
+	list := requests[0]
+	if list.Name != "List users" || list.Method != "GET" || list.URL != "{{baseUrl}}/users?page=1&size=20" {
+		t.Errorf("Unexpected first request: %+v", list)
+	}
+	if list.Headers["Authorization"] != "Bearer {{token}}" || list.Body != "" {
+		t.Errorf("Expected headers and no body, got %v %q", list.Headers, list.Body)
+	}
+	if list.Variables["baseUrl"] != "https://api.example.com" || list.Variables["token"] != "secret" {
+		t.Errorf("Expected file variables, got %v", list.Variables)
+	}
+
+	create := requests[1]
+	if create.Name != "createUser" || create.Index != 1 || create.Body != "{\n  \"name\": \"ada\"\n}" {
+		t.Errorf("Unexpected second request: %+v", create)
+	}
+
+	health := requests[2]
+	if health.Method != "GET" || health.Name != "GET {{baseUrl}}/health" {
+		t.Errorf("Expected a GET request named after its URL, got %q %q", health.Method, health.Name)
+	}
+}
+
+// TestUpdateHTTPFile tests that saving one request leaves the rest of the file untouched
+func TestUpdateHTTPFile(t *testing.T) {
+	f := parseHTTPFile(testHTTPFile)
+	req := f.requests()[1]
+	req.Body = `{"name": "grace"}`
+	req.Variables["token"] = "rotated"
+	f.setRequest(req)
+
+	out := f.String()
+	if !strings.Contains(out, "# Fetches the first page\n") || !strings.Contains(out, "    &size=20 HTTP/1.1\n") {
+		t.Errorf("Expected other requests to keep their formatting, got:\n%s", out)
+	}
+	if !strings.Contains(out, "@token = rotated\n") {
+		t.Errorf("Expected the changed variable to be updated in place, got:\n%s", out)
+	}
+
+	again := parseHTTPFile(out).requests()
+	if len(again) != 3 || again[1].Name != "createUser" || again[1].Body != `{"name": "grace"}` {
+		t.Errorf("Expected the updated request to round trip, got %+v", again)
+	}
+
+	f.removeRequest(0)
+	if requests := f.requests(); len(requests) != 2 || requests[0].Name != "createUser" {
+		t.Errorf("Expected the first request to be removed, got %+v", requests)
+	}
+	if f.variables()["baseUrl"] == "" {
+		t.Errorf("Expected file variables to survive removing a request")
+	}
+}
+
+// TestHTTPFileSavedRequests tests that requests in .http files can be renamed, deleted and restored
+func TestHTTPFileSavedRequests(t *testing.T) {
+	chdirTemp(t)
+	file := filepath.Join(requestsDir, "api.http")
+	if err := os.MkdirAll(requestsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(testHTTPFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 3 || requests[2].Path != file {
+		t.Fatalf("Expected 3 requests from api.http, got %+v", requests)
+	}
+
+	runCmd(t, renameRequest(requests[2], "health"))
+	trashed := runCmd(t, trashRequest(requests[0])).(requestTrashedMsg)
+	if names := savedNames(t); len(names) != 2 || names["health"] != "" {
+		t.Errorf("Expected the rename and delete to apply to api.http, got %v", names)
+	}
+
+	runCmd(t, restoreRequest(trashEntry(trashed)))
+	requests, _, err = readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 3 || requests[0].Name != "List users" || requests[2].Name != "health" {
+		t.Errorf("Expected the deleted request restored in place, got %+v", requests)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.HasPrefix(string(data), "@baseUrl = https://api.example.com\n@token = secret\n\n### List users\n") {
+		t.Errorf("Expected the restored request after the file variables, got:\n%s", data)
+	}
+}
+
+// TestRunCommandHTTPFile tests that a .http file can be run directly
+func TestRunCommandHTTPFile(t *testing.T) {
+	chdirTemp(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	content := strings.Replace(testHTTPFile, "https://api.example.com", server.URL, 1)
+	if err := os.WriteFile("api.http", []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"run", "-dir", "api.http", "List users"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected the run to succeed, got %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "PASS List users GET "+server.URL+"/users?page=1&size=20 -> 200") {
+		t.Errorf("Expected variables to be substituted, got:\n%s", stdout.String())
+	}
+}
+
+// TestHTTPFileRoundTrip tests that basic auth and fields .http files cannot express survive saving
+func TestHTTPFileRoundTrip(t *testing.T) {
+	req := HTTPRequest{
+		Name:       "login",
+		Method:     "POST",
+		URL:        "{{baseUrl}}/login",
+		Headers:    map[string]string{"Accept": "application/json"},
+		Auth:       &Auth{Type: authBasic, Username: "{{user}}", Password: "p@ss word"},
+		Assertions: []Assertion{{Type: "status", Value: "200"}},
+		Extract:    map[string]string{"token": "$.token"},
+		Spec:       "../specs/api.yaml",
+		Ignore:     []string{"$.issued_at"},
+	}
+	f := &httpFile{}
+	f.setRequest(req)
+	out := f.String()
+	if !strings.Contains(out, "Authorization: Basic {{user}} p@ss word\n") {
+		t.Errorf("Expected basic auth in REST Client form, got:\n%s", out)
+	}
+
+	got := parseHTTPFile(out).requests()[0]
+	if got.Auth == nil || *got.Auth != *req.Auth || got.Headers["Authorization"] != "" {
+		t.Errorf("Expected basic auth to be read back, got %+v %v", got.Auth, got.Headers)
+	}
+	if len(got.Assertions) != 1 || got.Assertions[0].Value != "200" || got.Extract["token"] != "$.token" ||
+		got.Spec != req.Spec || len(got.Ignore) != 1 {
+		t.Errorf("Expected assertions, extract rules, spec and ignore paths to be kept, got %+v", got)
+	}
+
+	// Encoded credentials are sent as written
+	encoded := parseHTTPFile("GET /\nAuthorization: Basic dXNlcjpwYXNz\n").requests()[0]
+	if encoded.Auth != nil || encoded.Headers["Authorization"] != "Basic dXNlcjpwYXNz" {
+		t.Errorf("Expected encoded credentials to stay a header, got %+v", encoded)
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// importResult is what an importer produces before it is written to disk.
+// Folder paths are relative to the import's target folder.
+type importResult struct {
+	Name         string
+	Requests     []HTTPRequest
+	Folders      map[string]FolderConfig
+	Environments []Environment
+	History      []HistoryEntry
+	Report       []string
+}
+
+func newImportResult(name string) *importResult {
+	return &importResult{Name: name, Folders: make(map[string]FolderConfig)}
+}
+
+// note records a feature that could not be imported
+func (r *importResult) note(format string, args ...any) {
+	r.Report = append(r.Report, fmt.Sprintf(format, args...))
+}
+
+// RequestSource records which operation of an imported spec a request was
+// generated from, and what was generated, so a re-import can merge changes
+type RequestSource struct {
+	Kind      string       `json:"kind"`
+	Operation string       `json:"operation"`
+	Base      *HTTPRequest `json:"base,omitempty"`
+}
+
+func (s *RequestSource) key() string {
+	return s.Kind + " " + s.Operation
+}
+
+// filter drops the requests and exchanges that do not pass f
+func (r *importResult) filter(f exchangeFilter) {
+	var requests []HTTPRequest
+	for _, req := range r.Requests {
+		if f.match(req) {
+			requests = append(requests, req)
+		}
+	}
+	r.Requests = requests
+
+	var history []HistoryEntry
+	for _, e := range r.History {
+		if f.match(e.Request) {
+			history = append(history, e)
+		}
+	}
+	r.History = history
+}
+
+// importers maps a format name to the function that parses an export file
+var importers = map[string]func(data []byte) (*importResult, error){
+	"har":      importHAR,
+	"postman":  importPostman,
+	"insomnia": importInsomnia,
+	"openapi":  importOpenAPI,
+}
+
+// importCommand reads export files in a foreign format and writes them as
+// saved requests, or with -history as history entries
+func importCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, "usage: whelm import <%s> [-into FOLDER] [-history] [-domain HOST] [-method METHOD] FILE...\n", strings.Join(importerNames(), "|"))
+		return 2
+	}
+	format := args[0]
+	parse, ok := importers[format]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown import format %q, expected one of %s\n", format, strings.Join(importerNames(), ", "))
+		return 2
+	}
+
+	fs := flag.NewFlagSet("import "+format, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	into := fs.String("into", "", "folder to import into (default: derived from the collection name)")
+	toHistory := fs.Bool("history", false, "import recorded exchanges into history instead of saved requests")
+	var filter exchangeFilter
+	fs.Var(&filter.domains, "domain", "only import requests to this host or its subdomains")
+	fs.Var(&filter.methods, "method", "only import requests with this method")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintf(stderr, "error: no files to import\n")
+		return 2
+	}
+
+	for _, file := range fs.Args() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		result, err := parse(data)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %s: %v\n", file, err)
+			return 1
+		}
+		result.filter(filter)
+
+		if *toHistory {
+			if result.History == nil && len(result.Requests) > 0 {
+				fmt.Fprintf(stderr, "error: %s exports have no responses to import into history\n", format)
+				return 2
+			}
+			if err := appendHistory(result.History...); err != nil {
+				fmt.Fprintf(stderr, "error: %v\n", err)
+				return 1
+			}
+			fmt.Fprintf(stdout, "Imported %s into history: %d exchanges\n", file, len(result.History))
+			continue
+		}
+
+		if format == "openapi" {
+			// Responses of the generated requests are checked against the spec,
+			// which writeImport saves relative to the folder
+			root := result.Folders[""]
+			root.Spec = file
+			result.Folders[""] = root
+		}
+
+		target := cleanFolder(*into)
+		if target == "" && len(result.Requests) > 0 {
+			target = slugify(result.Name)
+		}
+		if err := validateFolder(target); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		if err := writeImport(result, target); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		printImportReport(stdout, file, target, result)
+	}
+
+	return 0
+}
+
+func importerNames() []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeImport saves the imported requests, folder settings and environments
+// below target, a folder relative to the requests directory. Requests that
+// were generated by an earlier import of the same spec are merged rather
+// than duplicated.
+func writeImport(result *importResult, target string) error {
+	existing, folders, err := readRequestTree(requestsDir)
+	if err != nil {
+		return err
+	}
+	previous := make(map[string]HTTPRequest)
+	for _, req := range existing {
+		if req.Source != nil && inFolder(req.Folder, target) {
+			previous[req.Source.key()] = req
+		}
+	}
+
+	for folder, config := range result.Folders {
+		name := importedFolder(target, folder)
+		dir := filepath.Join(requestsDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if current, ok := folders[name]; ok {
+			config = mergeFolderConfig(current, config)
+		}
+		if isEmptyFolderConfig(config) {
+			continue
+		}
+		config.Spec = relativeFolderPath(dir, config.Spec)
+		if err := writeIndentedJSON(filepath.Join(dir, folderConfigFile), config); err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, req := range result.Requests {
+		req.Folder = importedFolder(target, req.Folder)
+		req = withImportBase(req)
+		if req.Source != nil {
+			seen[req.Source.key()] = true
+			if local, ok := previous[req.Source.key()]; ok {
+				req = mergeImportedRequest(local, req)
+				if err := writeRequestFile(req); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		req.ID = newRequestID()
+		req.Path = requestPath(req)
+		if err := writeRequestFile(req); err != nil {
+			return err
+		}
+	}
+
+	var removed []string
+	for key, req := range previous {
+		if !seen[key] && req.Source.Kind == importKind(result) {
+			removed = append(removed, fmt.Sprintf("%s (%s) is no longer in the spec and was kept", req.Name, req.Source.Operation))
+		}
+	}
+	sort.Strings(removed)
+	result.Report = append(result.Report, removed...)
+
+	for _, env := range result.Environments {
+		if err := mergeEnvironment(env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importKind returns the source kind of the imported requests
+func importKind(result *importResult) string {
+	for _, req := range result.Requests {
+		if req.Source != nil {
+			return req.Source.Kind
+		}
+	}
+	return ""
+}
+
+// mergeFolderConfig adds imported folder settings to an existing folder,
+// keeping the values already set there
+func mergeFolderConfig(current, imported FolderConfig) FolderConfig {
+	if current.Auth == nil {
+		current.Auth = imported.Auth
+	}
+	if current.BaseURL == "" {
+		current.BaseURL = imported.BaseURL
+	}
+	if current.Spec == "" {
+		current.Spec = imported.Spec
+	}
+	current.Headers = mergeVariables(imported.Headers, current.Headers)
+	current.Variables = mergeVariables(imported.Variables, current.Variables)
+	if len(current.Headers) == 0 {
+		current.Headers = nil
+	}
+	if len(current.Variables) == 0 {
+		current.Variables = nil
+	}
+	return current
+}
+
+func isEmptyFolderConfig(c FolderConfig) bool {
+	return len(c.Headers) == 0 && c.Auth == nil && c.BaseURL == "" && len(c.Variables) == 0 && c.Spec == "" && len(c.Ignore) == 0
+}
+
+// writeIndentedJSON writes v to a file as indented JSON
+func writeIndentedJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// mergeEnvironment saves an environment, keeping variables of an existing
+// environment with the same name unless the new one sets them too
+func mergeEnvironment(env Environment) error {
+	if err := os.MkdirAll(environmentsDir, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(environmentsDir, slugify(env.Name)+".json")
+
+	var existing Environment
+	if data, err := os.ReadFile(file); err == nil {
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	existing.Variables = mergeVariables(existing.Variables, env.Variables)
+
+	return writeIndentedJSON(file, existing)
+}
+
+// printImportReport summarises an import and lists everything that was not carried over
+func printImportReport(w io.Writer, file, target string, result *importResult) {
+	fmt.Fprintf(w, "Imported %s into %s: %d requests, %d folders, %d environments\n",
+		file, folderLabel(target), len(result.Requests), len(result.Folders), len(result.Environments))
+	if len(result.Report) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Not imported:\n")
+	for _, line := range result.Report {
+		fmt.Fprintf(w, "  - %s\n", line)
+	}
+}
+
+// encodeParams joins name/value pairs as name=value&..., escaping them
+// except for {{variable}} references, which must survive for substitution,
+// and template tags left for the user to replace
+func encodeParams(params [][2]string) string {
+	var pairs []string
+	for _, p := range params {
+		pairs = append(pairs, escapeParam(p[0])+"="+escapeParam(p[1]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// paramTemplate matches the {{variable}} references and {% tags %} of a parameter
+var paramTemplate = regexp.MustCompile(`\{\{.*?\}\}|\{%.*?%\}`)
+
+// escapeParam query-escapes s, leaving its templates as written
+func escapeParam(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range paramTemplate.FindAllStringIndex(s, -1) {
+		b.WriteString(url.QueryEscape(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(url.QueryEscape(s[last:]))
+	return b.String()
+}
+
+// appendQuery adds name/value pairs to the query string of a URL
+func appendQuery(rawURL string, params [][2]string) string {
+	if len(params) == 0 {
+		return rawURL
+	}
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + encodeParams(params)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Insomnia v4 export format: a flat list of resources linked by parent IDs
+type insomniaExport struct {
+	Type      string             `json:"_type"`
+	Format    int                `json:"__export_format"`
+	Resources []insomniaResource `json:"resources"`
+}
+
+type insomniaResource struct {
+	ID             string          `json:"_id"`
+	Type           string          `json:"_type"`
+	ParentID       string          `json:"parentId"`
+	Name           string          `json:"name"`
+	Method         string          `json:"method"`
+	URL            string          `json:"url"`
+	Body           insomniaBody    `json:"body"`
+	Headers        []insomniaParam `json:"headers"`
+	Parameters     []insomniaParam `json:"parameters"`
+	Authentication insomniaAuth    `json:"authentication"`
+	Environment    map[string]any  `json:"environment"`
+	Data           map[string]any  `json:"data"`
+	MetaSortKey    float64         `json:"metaSortKey"`
+}
+
+type insomniaBody struct {
+	MimeType string          `json:"mimeType"`
+	Text     string          `json:"text"`
+	Params   []insomniaParam `json:"params"`
+	FileName string          `json:"fileName"`
+}
+
+type insomniaParam struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+type insomniaAuth struct {
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	Prefix   string `json:"prefix"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	AddTo    string `json:"addTo"`
+}
+
+var (
+	// {{ _.name }} references the active environment in Insomnia
+	insomniaVariable = regexp.MustCompile(`\{\{\s*_\.([A-Za-z0-9_.\-]+)\s*\}\}`)
+	insomniaTemplate = regexp.MustCompile(`\{%.*?%\}`)
+)
+
+// importInsomnia converts an Insomnia v4 export
+func importInsomnia(data []byte) (*importResult, error) {
+	var export insomniaExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	if export.Type != "export" || export.Format != 4 {
+		return nil, fmt.Errorf("not an Insomnia v4 export")
+	}
+
+	byID := make(map[string]insomniaResource)
+	children := make(map[string][]insomniaResource)
+	var workspaces []insomniaResource
+	for _, r := range export.Resources {
+		byID[r.ID] = r
+		children[r.ParentID] = append(children[r.ParentID], r)
+		if r.Type == "workspace" {
+			workspaces = append(workspaces, r)
+		}
+	}
+	for _, list := range children {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].MetaSortKey < list[j].MetaSortKey })
+	}
+
+	name := "insomnia"
+	if len(workspaces) > 0 {
+		name = workspaces[0].Name
+	}
+	result := newImportResult(name)
+	result.Folders[""] = FolderConfig{}
+
+	var walk func(parentID, folder string)
+	walk = func(parentID, folder string) {
+		for _, r := range children[parentID] {
+			switch r.Type {
+			case "request_group":
+				sub := path.Join(folder, slugify(r.Name))
+				config := FolderConfig{Variables: insomniaVariables(r.Environment)}
+				config.Auth = insomniaConvertAuth(r.Authentication, "folder "+r.Name, result)
+				result.Folders[sub] = config
+				walk(r.ID, sub)
+			case "request":
+				result.Requests = append(result.Requests, insomniaConvertRequest(r, folder, result))
+			case "environment":
+				insomniaImportEnvironment(r, byID, result)
+				walk(r.ID, folder)
+			case "cookie_jar":
+				// Cookies are session state, not part of the collection
+			case "api_spec":
+				result.note("API spec %s", r.Name)
+			default:
+				result.note("%s %s", strings.ReplaceAll(r.Type, "_", " "), r.Name)
+				walk(r.ID, folder)
+			}
+		}
+	}
+	for _, w := range workspaces {
+		walk(w.ID, "")
+	}
+
+	return result, nil
+}
+
+func insomniaConvertRequest(r insomniaResource, folder string, result *importResult) HTTPRequest {
+	req := HTTPRequest{
+		Name:    r.Name,
+		Method:  strings.ToUpper(r.Method),
+		Headers: make(map[string]string),
+		Folder:  folder,
+	}
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+
+	var params [][2]string
+	for _, p := range r.Parameters {
+		if !p.Disabled {
+			params = append(params, [2]string{p.Name, insomniaText(p.Value, r.Name, result)})
+		}
+	}
+	req.URL = appendQuery(insomniaText(r.URL, r.Name, result), params)
+
+	for _, h := range r.Headers {
+		if !h.Disabled {
+			req.Headers[h.Name] = insomniaText(h.Value, r.Name, result)
+		}
+	}
+
+	switch {
+	case r.Body.FileName != "":
+		result.note("%s: file request body", r.Name)
+	case r.Body.MimeType == "application/x-www-form-urlencoded":
+		var form [][2]string
+		for _, p := range r.Body.Params {
+			if !p.Disabled {
+				form = append(form, [2]string{p.Name, insomniaText(p.Value, r.Name, result)})
+			}
+		}
+		req.Body = encodeParams(form)
+	case r.Body.MimeType == "multipart/form-data":
+		result.note("%s: multipart form body", r.Name)
+	default:
+		req.Body = insomniaText(r.Body.Text, r.Name, result)
+	}
+	if r.Body.MimeType != "" && !hasHeader(req.Headers, "Content-Type") {
+		req.Headers["Content-Type"] = r.Body.MimeType
+	}
+
+	req.Auth = insomniaConvertAuth(r.Authentication, r.Name, result)
+	return req
+}
+
+func insomniaConvertAuth(auth insomniaAuth, owner string, result *importResult) *Auth {
+	if auth.Disabled {
+		return nil
+	}
+	switch auth.Type {
+	case "":
+		return nil
+	case "none":
+		return &Auth{Type: authNone}
+	case "basic":
+		return &Auth{Type: authBasic, Username: insomniaText(auth.Username, owner, result), Password: insomniaText(auth.Password, owner, result)}
+	case "bearer":
+		if auth.Prefix != "" && auth.Prefix != "Bearer" {
+			result.note("%s: bearer prefix %q", owner, auth.Prefix)
+		}
+		return &Auth{Type: authBearer, Token: insomniaText(auth.Token, owner, result)}
+	case "apikey":
+		if auth.AddTo != "" && auth.AddTo != "header" {
+			result.note("%s: API key auth added to %s", owner, auth.AddTo)
+			return nil
+		}
+		return &Auth{Type: authAPIKey, Key: auth.Key, Value: insomniaText(auth.Value, owner, result)}
+	default:
+		result.note("%s: %s auth", owner, auth.Type)
+		return nil
+	}
+}
+
+// insomniaImportEnvironment maps the base environment onto the root folder's
+// variables and every sub environment onto a whelm environment
+func insomniaImportEnvironment(r insomniaResource, byID map[string]insomniaResource, result *importResult) {
+	vars := insomniaVariables(r.Data)
+	if parent, ok := byID[r.ParentID]; ok && parent.Type == "workspace" {
+		root := result.Folders[""]
+		root.Variables = mergeVariables(root.Variables, vars)
+		result.Folders[""] = root
+		return
+	}
+	result.Environments = append(result.Environments, Environment{Name: r.Name, Variables: vars})
+}
+
+// insomniaVariables flattens nested environment data into dotted variable names
+func insomniaVariables(data map[string]any) map[string]string {
+	if len(data) == 0 {
+		return nil
+	}
+	vars := make(map[string]string)
+	var flatten func(prefix string, v any)
+	flatten = func(prefix string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				flatten(strings.TrimPrefix(prefix+"."+k, "."), child)
+			}
+		case string:
+			vars[prefix] = insomniaVariable.ReplaceAllString(v, "{{$1}}")
+		default:
+			vars[prefix] = formatJSONValue(v)
+		}
+	}
+	flatten("", data)
+	return vars
+}
+
+// insomniaText converts Insomnia variable references and reports template tags whelm cannot evaluate
+func insomniaText(s, owner string, result *importResult) string {
+	for _, tag := range insomniaTemplate.FindAllString(s, -1) {
+		result.note("%s: template tag %s", owner, tag)
+	}
+	return insomniaVariable.ReplaceAllString(s, "{{$1}}")
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// importOpenAPI generates a folder of requests per tag from an OpenAPI 3.x or
+// Swagger 2.0 document. Path, query and header parameters become variables
+// and every server becomes an environment with a baseUrl variable.
+func importOpenAPI(data []byte) (*importResult, error) {
+	spec, err := parseOpenAPISpec(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newImportResult(spec.title())
+	var servers []openAPIServer
+	for _, server := range spec.servers() {
+		// Relative URLs resolve against where the spec is served from,
+		// which a spec read from a file cannot tell
+		if !isAbsoluteURL(server.URL) {
+			result.note("relative server URL %q, set baseUrl to the API's address", server.URL)
+			continue
+		}
+		servers = append(servers, server)
+	}
+
+	root := FolderConfig{Variables: map[string]string{"baseUrl": ""}}
+	if len(servers) > 0 {
+		root.Variables["baseUrl"] = servers[0].URL
+	}
+	root.Auth = spec.convertSecurity(spec.doc["security"], "API", result)
+	result.Folders[""] = root
+
+	names := make(map[string]bool)
+	for _, server := range servers {
+		name := server.Description
+		if name == "" {
+			if u, err := url.Parse(server.URL); err == nil && u.Host != "" {
+				name = u.Host
+			} else {
+				name = server.URL
+			}
+		}
+		for i := 2; names[slugify(name)]; i++ {
+			name = fmt.Sprintf("%s %d", strings.TrimSuffix(name, fmt.Sprintf(" %d", i-1)), i)
+		}
+		names[slugify(name)] = true
+		result.Environments = append(result.Environments, Environment{Name: name, Variables: map[string]string{"baseUrl": server.URL}})
+	}
+
+	for _, o := range spec.operations() {
+		req := spec.operationRequest(o, result)
+		if _, ok := result.Folders[req.Folder]; !ok {
+			result.Folders[req.Folder] = FolderConfig{}
+		}
+		result.Requests = append(result.Requests, req)
+	}
+
+	if _, ok := spec.doc["webhooks"]; ok {
+		result.note("webhooks")
+	}
+
+	return result, nil
+}
+
+// operationRequest converts a single operation into a saved request
+func (s *openAPISpec) operationRequest(o openAPIOperation, result *importResult) HTTPRequest {
+	name := stringField(o.Op, "summary")
+	if name == "" {
+		name = stringField(o.Op, "operationId")
+	}
+	if name == "" {
+		name = o.Key()
+	}
+
+	folder := "untagged"
+	if tags, ok := o.Op["tags"].([]any); ok && len(tags) > 0 {
+		if tag, ok := tags[0].(string); ok && tag != "" {
+			folder = slugify(tag)
+		}
+	}
+
+	req := HTTPRequest{
+		Name:      name,
+		Method:    strings.ToUpper(o.Method),
+		URL:       "{{baseUrl}}" + pathParamPattern.ReplaceAllString(o.Path, "{{$1}}"),
+		Headers:   make(map[string]string),
+		Variables: make(map[string]string),
+		Folder:    folder,
+		Source:    &RequestSource{Kind: "openapi", Operation: o.Key()},
+	}
+
+	var query, form [][2]string
+	for _, param := range s.parameters(o) {
+		paramName := stringField(param, "name")
+		switch stringField(param, "in") {
+		case "path":
+			req.Variables[paramName] = s.parameterExample(param)
+		case "query":
+			query = append(query, [2]string{paramName, "{{" + paramName + "}}"})
+			req.Variables[paramName] = s.parameterExample(param)
+		case "header":
+			req.Headers[paramName] = "{{" + paramName + "}}"
+			req.Variables[paramName] = s.parameterExample(param)
+		case "body":
+			// Swagger 2.0 request body
+			req.Body = jsonExample(s.exampleFromSchema(param["schema"], 0))
+			req.Headers["Content-Type"] = s.consumes(o, "application/json")
+		case "formData":
+			if stringField(param, "type") == "file" {
+				result.note("%s: file upload parameter %s", name, paramName)
+				continue
+			}
+			form = append(form, [2]string{paramName, "{{" + paramName + "}}"})
+			req.Variables[paramName] = s.parameterExample(param)
+		default:
+			result.note("%s: %s parameter %s", name, stringField(param, "in"), paramName)
+		}
+	}
+	req.URL = appendQuery(req.URL, query)
+	if len(form) > 0 {
+		req.Body = encodeParams(form)
+		req.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+
+	if body := s.resolve(o.Op["requestBody"]); body != nil {
+		s.requestBodyExample(body, &req, result)
+	}
+
+	if security, ok := o.Op["security"]; ok {
+		req.Auth = s.convertSecurity(security, name, result)
+	}
+	if _, ok := o.Op["callbacks"]; ok {
+		result.note("%s: callbacks", name)
+	}
+
+	if len(req.Variables) == 0 {
+		req.Variables = nil
+	}
+	return req
+}
+
+// requestBodyExample fills the body of an OpenAPI 3 request from its preferred media type
+func (s *openAPISpec) requestBodyExample(body map[string]any, req *HTTPRequest, result *importResult) {
+	content := mapField(body, "content")
+	if len(content) == 0 {
+		return
+	}
+
+	mediaTypes := make([]string, 0, len(content))
+	for mt := range content {
+		mediaTypes = append(mediaTypes, mt)
+	}
+	sort.Strings(mediaTypes)
+	chosen := mediaTypes[0]
+	for _, mt := range mediaTypes {
+		if strings.Contains(mt, "json") {
+			chosen = mt
+			break
+		}
+	}
+
+	media := mapField(content, chosen)
+	example, ok := media["example"]
+	if !ok {
+		for _, ex := range mapField(media, "examples") {
+			if value, found := s.resolve(ex)["value"]; found {
+				example, ok = value, true
+				break
+			}
+		}
+	}
+	if !ok {
+		example = s.exampleFromSchema(media["schema"], 0)
+	}
+
+	switch {
+	case strings.Contains(chosen, "json"):
+		req.Body = jsonExample(example)
+	case chosen == "application/x-www-form-urlencoded":
+		obj, _ := example.(map[string]any)
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var form [][2]string
+		for _, k := range keys {
+			form = append(form, [2]string{k, fmt.Sprint(obj[k])})
+		}
+		req.Body = encodeParams(form)
+	case strings.HasPrefix(chosen, "text/"):
+		if text, ok := example.(string); ok {
+			req.Body = text
+		}
+	default:
+		result.note("%s: %s request body", req.Name, chosen)
+		return
+	}
+	req.Headers["Content-Type"] = chosen
+}
+
+// parameterExample returns an example value for a parameter, or "" when there is none
+func (s *openAPISpec) parameterExample(param map[string]any) string {
+	var example any
+	if ex, ok := param["example"]; ok {
+		example = ex
+	} else if schema, ok := param["schema"]; ok {
+		m := s.resolve(schema)
+		if ex, ok := m["example"]; ok {
+			example = ex
+		} else if def, ok := m["default"]; ok {
+			example = def
+		} else if enum, ok := m["enum"].([]any); ok && len(enum) > 0 {
+			example = enum[0]
+		}
+	} else if def, ok := param["default"]; ok {
+		example = def
+	}
+
+	switch v := example.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return formatJSONValue(v)
+	}
+}
+
+// consumes returns the first request media type of a Swagger 2.0 operation
+func (s *openAPISpec) consumes(o openAPIOperation, fallback string) string {
+	for _, list := range []any{o.Op["consumes"], s.doc["consumes"]} {
+		if types, ok := list.([]any); ok && len(types) > 0 {
+			if mt, ok := types[0].(string); ok {
+				return mt
+			}
+		}
+	}
+	return fallback
+}
+
+// convertSecurity maps the first security requirement onto whelm auth with
+// credentials left as variables. An empty requirement list disables auth.
+func (s *openAPISpec) convertSecurity(requirements any, owner string, result *importResult) *Auth {
+	list, ok := requirements.([]any)
+	if !ok {
+		return nil
+	}
+	if len(list) == 0 {
+		return &Auth{Type: authNone}
+	}
+	requirement, _ := list[0].(map[string]any)
+	if len(requirement) == 0 {
+		return &Auth{Type: authNone}
+	}
+
+	schemes := mapField(mapField(s.doc, "components"), "securitySchemes")
+	if s.swagger {
+		schemes = mapField(s.doc, "securityDefinitions")
+	}
+
+	names := make([]string, 0, len(requirement))
+	for name := range requirement {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	scheme := s.resolve(schemes[names[0]])
+
+	switch t := stringField(scheme, "type"); {
+	case t == "basic" || (t == "http" && strings.EqualFold(stringField(scheme, "scheme"), "basic")):
+		return &Auth{Type: authBasic, Username: "{{username}}", Password: "{{password}}"}
+	case t == "http" && strings.EqualFold(stringField(scheme, "scheme"), "bearer"):
+		return &Auth{Type: authBearer, Token: "{{token}}"}
+	case t == "apiKey" && stringField(scheme, "in") == "header":
+		return &Auth{Type: authAPIKey, Key: stringField(scheme, "name"), Value: "{{apiKey}}"}
+	default:
+		result.note("%s: %s security scheme %s", owner, t, names[0])
+		return nil
+	}
+}
+
+// jsonExample formats an example value as an indented JSON body
+func jsonExample(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// mergeImportedRequest updates a previously imported request with a fresh
+// import. Fields the user has not changed since the last import take the
+// new value; local edits are kept.
+func mergeImportedRequest(local, imported HTTPRequest) HTTPRequest {
+	base := HTTPRequest{}
+	if local.Source != nil && local.Source.Base != nil {
+		base = *local.Source.Base
+	}
+
+	pick := func(l, b, n string) string {
+		if l == b {
+			return n
+		}
+		return l
+	}
+
+	merged := local
+	merged.Name = pick(local.Name, base.Name, imported.Name)
+	merged.Method = pick(local.Method, base.Method, imported.Method)
+	merged.URL = pick(local.URL, base.URL, imported.URL)
+	merged.Body = pick(local.Body, base.Body, imported.Body)
+
+	merged.Headers = make(map[string]string)
+	for k, v := range local.Headers {
+		merged.Headers[k] = v
+	}
+	for k, v := range imported.Headers {
+		l, inLocal := local.Headers[k]
+		b, inBase := base.Headers[k]
+		if !inLocal && inBase {
+			// Deleted locally, which wins unless the spec changed the value
+			if v != b {
+				merged.Headers[k] = v
+			}
+			continue
+		}
+		merged.Headers[k] = pick(l, b, v)
+	}
+	for k := range base.Headers {
+		// Dropped from the spec and untouched locally
+		if _, ok := imported.Headers[k]; !ok && local.Headers[k] == base.Headers[k] {
+			delete(merged.Headers, k)
+		}
+	}
+
+	// Variable values are local settings; only new ones are added
+	merged.Variables = mergeVariables(imported.Variables, local.Variables)
+	if len(merged.Variables) == 0 {
+		merged.Variables = nil
+	}
+
+	if requestsEqual(HTTPRequest{Auth: local.Auth}, HTTPRequest{Auth: base.Auth}) {
+		merged.Auth = imported.Auth
+	}
+
+	merged.Source = imported.Source
+	return merged
+}
+
+// withImportBase records the generated request as the base for future merges
+func withImportBase(req HTTPRequest) HTTPRequest {
+	if req.Source == nil {
+		return req
+	}
+	base := req
+	base.Source = nil
+	base.Folder = ""
+	base.Path = ""
+	source := *req.Source
+	source.Base = &base
+	req.Source = &source
+	return req
+}
+
+// importedFolder returns the folder of an imported request relative to the target
+func importedFolder(target, folder string) string {
+	return strings.Trim(path.Join(target, folder), "/")
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestImportOpenAPI tests that operations become requests grouped by tag with parameters as variables
+func TestImportOpenAPI(t *testing.T) {
+	result, err := importOpenAPI([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]HTTPRequest)
+	for _, req := range result.Requests {
+		byName[req.Name] = req
+	}
+	if len(byName) != 4 {
+		t.Fatalf("Expected 4 requests, got %d", len(byName))
+	}
+
+	list := byName["List pets"]
+	if list.Folder != "pets" || list.URL != "{{baseUrl}}/pets?limit={{limit}}" || list.Variables["limit"] != "20" {
+		t.Errorf("Unexpected List pets request: %+v", list)
+	}
+	get := byName["Get pet"]
+	if get.URL != "{{baseUrl}}/pets/{{id}}" || get.Variables["id"] != "rex" {
+		t.Errorf("Expected the path parameter as a variable, got %+v", get)
+	}
+	if get.Auth == nil || get.Auth.Type != authNone {
+		t.Errorf("Expected empty security to disable auth, got %+v", get.Auth)
+	}
+	create := byName["createPet"]
+	if !strings.Contains(create.Body, `"name": "Rex"`) || create.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected an example JSON body, got %q %v", create.Body, create.Headers)
+	}
+	if create.Source == nil || create.Source.Operation != "POST /pets" {
+		t.Errorf("Expected the source operation to be recorded, got %+v", create.Source)
+	}
+	if byName["GET /health"].Folder != "untagged" {
+		t.Errorf("Expected untagged operations in the untagged folder, got %q", byName["GET /health"].Folder)
+	}
+
+	root := result.Folders[""]
+	if root.Variables["baseUrl"] != "https://api.example.com/v1" || root.Auth == nil || root.Auth.Type != authBearer {
+		t.Errorf("Unexpected root folder settings: %+v", root)
+	}
+	if len(result.Environments) != 2 || result.Environments[0].Name != "Production" || result.Environments[1].Name != "eu.staging.example.com" {
+		t.Errorf("Expected one environment per server, got %+v", result.Environments)
+	}
+	if report := strings.Join(result.Report, "\n"); !strings.Contains(report, "cookie parameter session") {
+		t.Errorf("Expected the cookie parameter to be reported, got:\n%s", report)
+	}
+}
+
+// TestImportOpenAPISwagger tests that Swagger 2.0 body and form parameters are imported
+func TestImportOpenAPISwagger(t *testing.T) {
+	spec := `{
+		"swagger": "2.0",
+		"info": {"title": "Legacy"},
+		"host": "legacy.example.com",
+		"securityDefinitions": {"key": {"type": "apiKey", "in": "header", "name": "X-API-Key"}},
+		"security": [{"key": []}],
+		"paths": {
+			"/users": {"post": {"summary": "Create user", "parameters": [{"in": "body", "name": "user", "schema": {"type": "object", "properties": {"email": {"type": "string", "format": "email"}}}}]}},
+			"/login": {"post": {"summary": "Login", "consumes": ["application/x-www-form-urlencoded"], "parameters": [{"in": "formData", "name": "user", "type": "string"}]}}
+		}
+	}`
+	result, err := importOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(result.Requests))
+	}
+	login, create := result.Requests[0], result.Requests[1]
+	if login.Body != "user={{user}}" {
+		t.Errorf("Expected a form body, got %q", login.Body)
+	}
+	if !strings.Contains(create.Body, "user@example.com") {
+		t.Errorf("Expected an example body from the schema, got %q", create.Body)
+	}
+	auth := result.Folders[""].Auth
+	if auth == nil || auth.Type != authAPIKey || auth.Key != "X-API-Key" {
+		t.Errorf("Expected API key auth on the root folder, got %+v", auth)
+	}
+}
+
+// TestImportOpenAPIMerge tests that re-importing a spec keeps local edits and picks up spec changes
+func TestImportOpenAPIMerge(t *testing.T) {
+	chdirTemp(t)
+	if err := os.WriteFile("spec.yaml", []byte(testOpenAPISpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"import", "openapi", "spec.yaml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected import to succeed, got %d: %s", code, stderr.String())
+	}
+
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range requests {
+		if req.Name == "List pets" {
+			req.Headers["X-Local"] = "1"
+			req.Variables["limit"] = "5"
+			if err := writeRequestFile(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The spec renames a parameter, adds an operation and drops another
+	updated := strings.Replace(testOpenAPISpec, "name: limit", "name: max", 1)
+	updated = strings.Replace(updated, "  /health:\n", "  /status:\n", 1)
+	if err := os.WriteFile("spec.yaml", []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := runCLI([]string{"import", "openapi", "spec.yaml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected re-import to succeed, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "GET /health) is no longer in the spec") {
+		t.Errorf("Expected the removed operation to be reported, got:\n%s", stdout.String())
+	}
+
+	requests, _, err = readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 5 {
+		t.Errorf("Expected 5 requests after re-import, got %d", len(requests))
+	}
+	for _, req := range requests {
+		if req.Name != "List pets" {
+			continue
+		}
+		if req.URL != "{{baseUrl}}/pets?max={{max}}" {
+			t.Errorf("Expected the URL to follow the spec, got %q", req.URL)
+		}
+		if req.Headers["X-Local"] != "1" || req.Variables["limit"] != "5" || req.Variables["max"] != "20" {
+			t.Errorf("Expected local edits to be kept, got %+v", req)
+		}
+	}
+}
+
+// TestImportOpenAPISpecPath tests that the imported spec is found from any working directory
+func TestImportOpenAPISpecPath(t *testing.T) {
+	dir := chdirTemp(t)
+	if err := os.MkdirAll("specs", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("specs", "api.yaml"), []byte(testOpenAPISpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"import", "openapi", "specs/api.yaml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected import to succeed, got %d: %s", code, stderr.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(requestsDir, "pet-store", folderConfigFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"spec": "../../specs/api.yaml"`) {
+		t.Errorf("Expected the spec relative to the folder, got:\n%s", data)
+	}
+
+	// Run from another directory, as workspace discovery allows
+	previous := requestsDir
+	requestsDir = filepath.Join(dir, requestsDir)
+	t.Cleanup(func() { requestsDir = previous })
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	requests, folders, err := readSavedRequests()
+	if err != nil || len(requests) == 0 {
+		t.Fatalf("Expected the imported requests, got %v", err)
+	}
+	req := inheritFolderSettings(requests[0], folders)
+	if _, err := loadCachedSpec(req.Spec); err != nil {
+		t.Errorf("Expected the spec to load, got %v", err)
+	}
+}
+
+// TestMergeImportedRequestDeletedHeader tests that a header deleted locally stays deleted while the spec keeps it
+func TestMergeImportedRequestDeletedHeader(t *testing.T) {
+	imported := withImportBase(HTTPRequest{Name: "Create pet", Method: "POST", URL: "{{baseUrl}}/pets",
+		Headers: map[string]string{"Content-Type": "application/json", "X-Version": "1"},
+		Source:  &RequestSource{Kind: "openapi", Operation: "POST /pets"}})
+	local := imported
+	local.Headers = map[string]string{"Content-Type": "application/json"}
+
+	merged := mergeImportedRequest(local, imported)
+	if _, ok := merged.Headers["X-Version"]; ok {
+		t.Errorf("Expected the deleted header to stay deleted, got %v", merged.Headers)
+	}
+
+	changed := imported
+	changed.Headers = map[string]string{"Content-Type": "application/json", "X-Version": "2"}
+	merged = mergeImportedRequest(local, changed)
+	if merged.Headers["X-Version"] != "2" {
+		t.Errorf("Expected a header changed in the spec to come back, got %v", merged.Headers)
+	}
+}
+
+// TestImportOpenAPIRelativeServer tests that a relative server URL is reported instead of becoming the base URL
+func TestImportOpenAPIRelativeServer(t *testing.T) {
+	result, err := importOpenAPI([]byte(`{"openapi": "3.0.0", "info": {"title": "API"}, "servers": [{"url": "/v1"}], "paths": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Folders[""].Variables["baseUrl"] != "" || len(result.Environments) != 0 {
+		t.Errorf("Expected no base URL or environment, got %v %+v", result.Folders[""].Variables, result.Environments)
+	}
+	if report := strings.Join(result.Report, "\n"); !strings.Contains(report, `relative server URL "/v1"`) {
+		t.Errorf("Expected the relative server to be reported, got:\n%s", report)
+	}
+}
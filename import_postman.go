@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Postman Collection v2.1 export format
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth"`
+	Variable []postmanKeyValue `json:"variable"`
+	Event    []postmanEvent    `json:"event"`
+
+	// Environment exports share the importer
+	Name   string            `json:"name"`
+	Values []postmanKeyValue `json:"values"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item"`
+	Request  *postmanRequest   `json:"request"`
+	Auth     *postmanAuth      `json:"auth"`
+	Variable []postmanKeyValue `json:"variable"`
+	Event    []postmanEvent    `json:"event"`
+	Response []json.RawMessage `json:"response"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body"`
+	Auth   *postmanAuth      `json:"auth"`
+	Proxy  json.RawMessage   `json:"proxy"`
+	Cert   json.RawMessage   `json:"certificate"`
+}
+
+// postmanURL is either a plain string or an object with a raw form
+type postmanURL struct {
+	Raw string `json:"raw"`
+}
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		u.Raw = s
+		return nil
+	}
+	var obj struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	u.Raw = obj.Raw
+	return nil
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+func (kv postmanKeyValue) enabled() bool {
+	return !kv.Disabled && (kv.Enabled == nil || *kv.Enabled)
+}
+
+func (kv postmanKeyValue) value() string {
+	switch v := kv.Value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return formatJSONValue(v)
+	}
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	URLEncoded []postmanKeyValue `json:"urlencoded"`
+	FormData   []postmanKeyValue `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Basic  []postmanKeyValue `json:"basic"`
+	Bearer []postmanKeyValue `json:"bearer"`
+	APIKey []postmanKeyValue `json:"apikey"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec []string `json:"exec"`
+	} `json:"script"`
+}
+
+// importPostman converts a Postman Collection v2.1 or Postman environment export
+func importPostman(data []byte) (*importResult, error) {
+	var c postmanCollection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	// Environment export
+	if c.Info.Name == "" && c.Values != nil {
+		result := newImportResult(c.Name)
+		env := Environment{Name: c.Name, Variables: make(map[string]string)}
+		for _, v := range c.Values {
+			if v.enabled() {
+				env.Variables[v.Key] = v.value()
+			}
+		}
+		result.Environments = append(result.Environments, env)
+		return result, nil
+	}
+
+	if c.Info.Schema != "" && !strings.Contains(c.Info.Schema, "v2.1") && !strings.Contains(c.Info.Schema, "v2.0") {
+		return nil, fmt.Errorf("unsupported Postman schema %s, expected v2.1", c.Info.Schema)
+	}
+
+	result := newImportResult(c.Info.Name)
+	root := FolderConfig{Variables: postmanVariables(c.Variable)}
+	root.Auth = postmanConvertAuth(c.Auth, "collection", result)
+	result.Folders[""] = root
+	postmanNoteEvents(c.Event, "collection", result)
+
+	postmanImportItems(c.Item, "", result)
+	return result, nil
+}
+
+func postmanImportItems(items []postmanItem, folder string, result *importResult) {
+	for _, item := range items {
+		// Folders have children instead of a request
+		if item.Request == nil {
+			sub := path.Join(folder, slugify(item.Name))
+			config := FolderConfig{Variables: postmanVariables(item.Variable)}
+			config.Auth = postmanConvertAuth(item.Auth, "folder "+item.Name, result)
+			result.Folders[sub] = config
+			postmanNoteEvents(item.Event, "folder "+item.Name, result)
+			postmanImportItems(item.Item, sub, result)
+			continue
+		}
+
+		result.Requests = append(result.Requests, postmanConvertRequest(item, folder, result))
+	}
+}
+
+func postmanConvertRequest(item postmanItem, folder string, result *importResult) HTTPRequest {
+	r := item.Request
+	req := HTTPRequest{
+		Name:    item.Name,
+		Method:  strings.ToUpper(r.Method),
+		URL:     r.URL.Raw,
+		Headers: make(map[string]string),
+		Folder:  folder,
+	}
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+
+	for _, h := range r.Header {
+		if h.enabled() {
+			req.Headers[h.Key] = h.value()
+		}
+	}
+
+	if r.Body != nil {
+		switch r.Body.Mode {
+		case "raw":
+			req.Body = r.Body.Raw
+			if r.Body.Options.Raw.Language == "json" && !hasHeader(req.Headers, "Content-Type") {
+				req.Headers["Content-Type"] = "application/json"
+			}
+		case "urlencoded":
+			var params [][2]string
+			for _, p := range r.Body.URLEncoded {
+				if p.enabled() {
+					params = append(params, [2]string{p.Key, p.value()})
+				}
+			}
+			req.Body = encodeParams(params)
+			if !hasHeader(req.Headers, "Content-Type") {
+				req.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+			}
+		case "graphql":
+			if r.Body.GraphQL != nil {
+				body := map[string]any{"query": r.Body.GraphQL.Query}
+				if r.Body.GraphQL.Variables != "" {
+					body["variables"] = json.RawMessage(r.Body.GraphQL.Variables)
+				}
+				data, _ := json.MarshalIndent(body, "", "  ")
+				req.Body = string(data)
+				if !hasHeader(req.Headers, "Content-Type") {
+					req.Headers["Content-Type"] = "application/json"
+				}
+			}
+		case "":
+		default:
+			result.note("%s: %s request body", item.Name, r.Body.Mode)
+		}
+	}
+
+	req.Auth = postmanConvertAuth(r.Auth, item.Name, result)
+	if vars := postmanVariables(item.Variable); len(vars) > 0 {
+		req.Variables = vars
+	}
+	postmanNoteEvents(item.Event, item.Name, result)
+	if len(item.Response) > 0 {
+		result.note("%s: %d saved example responses", item.Name, len(item.Response))
+	}
+	if len(r.Proxy) > 0 {
+		result.note("%s: proxy settings", item.Name)
+	}
+	if len(r.Cert) > 0 {
+		result.note("%s: client certificate", item.Name)
+	}
+
+	return req
+}
+
+func postmanConvertAuth(auth *postmanAuth, owner string, result *importResult) *Auth {
+	if auth == nil {
+		return nil
+	}
+	lookup := func(values []postmanKeyValue, key string) string {
+		for _, v := range values {
+			if v.Key == key {
+				return v.value()
+			}
+		}
+		return ""
+	}
+
+	switch auth.Type {
+	case "":
+		return nil
+	case "noauth":
+		return &Auth{Type: authNone}
+	case "basic":
+		return &Auth{Type: authBasic, Username: lookup(auth.Basic, "username"), Password: lookup(auth.Basic, "password")}
+	case "bearer":
+		return &Auth{Type: authBearer, Token: lookup(auth.Bearer, "token")}
+	case "apikey":
+		if in := lookup(auth.APIKey, "in"); in == "query" {
+			result.note("%s: API key auth sent in the query string", owner)
+			return nil
+		}
+		return &Auth{Type: authAPIKey, Key: lookup(auth.APIKey, "key"), Value: lookup(auth.APIKey, "value")}
+	default:
+		result.note("%s: %s auth", owner, auth.Type)
+		return nil
+	}
+}
+
+func postmanNoteEvents(events []postmanEvent, owner string, result *importResult) {
+	for _, e := range events {
+		if len(e.Script.Exec) == 0 || strings.TrimSpace(strings.Join(e.Script.Exec, "")) == "" {
+			continue
+		}
+		result.note("%s: %s script", owner, e.Listen)
+	}
+}
+
+func postmanVariables(values []postmanKeyValue) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	vars := make(map[string]string)
+	for _, v := range values {
+		if v.enabled() {
+			vars[v.Key] = v.value()
+		}
+	}
+	return vars
+}
+
+// hasHeader reports whether a header is set, ignoring the case of its name
+func hasHeader(headers map[string]string, name string) bool {
+	_, ok := lookupHeader(headers, name)
+	return ok
+}
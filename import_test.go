@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPostmanCollection = `{
+	"info": {"name": "Pet Store", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+	"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+	"variable": [{"key": "baseUrl", "value": "https://petstore.example.com"}],
+	"item": [
+		{
+			"name": "Pets",
+			"item": [
+				{
+					"name": "List pets",
+					"request": {
+						"method": "GET",
+						"header": [{"key": "Accept", "value": "application/json"}, {"key": "X-Debug", "value": "1", "disabled": true}],
+						"url": {"raw": "{{baseUrl}}/pets?limit=10"}
+					},
+					"event": [{"listen": "test", "script": {"exec": ["pm.test('ok')"]}}]
+				},
+				{
+					"name": "Create pet",
+					"request": {
+						"method": "POST",
+						"url": "{{baseUrl}}/pets",
+						"body": {"mode": "raw", "raw": "{\"name\": \"rex\"}", "options": {"raw": {"language": "json"}}},
+						"auth": {"type": "oauth2"}
+					}
+				}
+			]
+		},
+		{
+			"name": "Login",
+			"request": {
+				"method": "POST",
+				"url": "{{baseUrl}}/login",
+				"body": {"mode": "urlencoded", "urlencoded": [{"key": "user", "value": "ada"}, {"key": "pass", "value": "x"}]}
+			},
+			"response": [{"name": "ok"}]
+		}
+	]
+}`
+
+// TestImportPostman tests that folders, requests, auth and variables are mapped and unsupported features reported
+func TestImportPostman(t *testing.T) {
+	result, err := importPostman([]byte(testPostmanCollection))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Name != "Pet Store" || len(result.Requests) != 3 {
+		t.Fatalf("Expected 3 requests from Pet Store, got %q with %d", result.Name, len(result.Requests))
+	}
+
+	byName := make(map[string]HTTPRequest)
+	for _, req := range result.Requests {
+		byName[req.Name] = req
+	}
+
+	list := byName["List pets"]
+	if list.Folder != "pets" || list.URL != "{{baseUrl}}/pets?limit=10" || list.Headers["Accept"] != "application/json" {
+		t.Errorf("Unexpected list request: %+v", list)
+	}
+	if _, ok := list.Headers["X-Debug"]; ok {
+		t.Errorf("Expected disabled header to be skipped")
+	}
+	if create := byName["Create pet"]; create.Body != `{"name": "rex"}` || create.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Unexpected create request: %+v", create)
+	}
+	if login := byName["Login"]; login.Body != "user=ada&pass=x" || login.Headers["Content-Type"] != "application/x-www-form-urlencoded" {
+		t.Errorf("Unexpected login request: %+v", login)
+	}
+
+	root := result.Folders[""]
+	if root.Auth == nil || root.Auth.Token != "{{token}}" || root.Variables["baseUrl"] != "https://petstore.example.com" {
+		t.Errorf("Unexpected root folder settings: %+v", root)
+	}
+
+	report := strings.Join(result.Report, "\n")
+	for _, want := range []string{"List pets: test script", "Create pet: oauth2 auth", "Login: 1 saved example responses"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to mention %q, got:\n%s", want, report)
+		}
+	}
+}
+
+// TestImportPostmanEnvironment tests that Postman environment exports become whelm environments
+func TestImportPostmanEnvironment(t *testing.T) {
+	result, err := importPostman([]byte(`{"name": "Staging", "values": [
+		{"key": "baseUrl", "value": "https://staging.example.com", "enabled": true},
+		{"key": "unused", "value": "x", "enabled": false}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Environments) != 1 || result.Environments[0].Variables["baseUrl"] != "https://staging.example.com" {
+		t.Fatalf("Unexpected environments: %+v", result.Environments)
+	}
+	if _, ok := result.Environments[0].Variables["unused"]; ok {
+		t.Errorf("Expected disabled variable to be skipped")
+	}
+}
+
+// TestImportInsomnia tests that an Insomnia v4 export maps groups, requests and environments
+func TestImportInsomnia(t *testing.T) {
+	export := `{
+		"_type": "export", "__export_format": 4,
+		"resources": [
+			{"_id": "wrk_1", "_type": "workspace", "name": "Shop"},
+			{"_id": "env_base", "_type": "environment", "parentId": "wrk_1", "name": "Base", "data": {"host": "localhost"}},
+			{"_id": "env_prod", "_type": "environment", "parentId": "env_base", "name": "Production", "data": {"host": "shop.example.com", "api": {"version": 2}}},
+			{"_id": "fld_1", "_type": "request_group", "parentId": "wrk_1", "name": "Orders", "authentication": {"type": "basic", "username": "{{ _.user }}", "password": "pw"}},
+			{"_id": "req_1", "_type": "request", "parentId": "fld_1", "name": "Find order", "method": "get",
+				"url": "https://{{ _.host }}/orders", "parameters": [{"name": "id", "value": "{% uuid 'v4' %}"}],
+				"headers": [{"name": "Accept", "value": "application/json"}]},
+			{"_id": "req_2", "_type": "request", "parentId": "wrk_1", "name": "Upload", "method": "POST", "url": "https://{{ _.host }}/upload",
+				"body": {"mimeType": "multipart/form-data", "params": [{"name": "file", "type": "file"}]}},
+			{"_id": "uts_1", "_type": "unit_test_suite", "parentId": "wrk_1", "name": "Smoke"}
+		]
+	}`
+
+	result, err := importInsomnia([]byte(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Name != "Shop" || len(result.Requests) != 2 {
+		t.Fatalf("Expected 2 requests from Shop, got %q with %d", result.Name, len(result.Requests))
+	}
+	find := result.Requests[0]
+	if find.Name != "Find order" || find.Method != "GET" || find.Folder != "orders" || find.URL != "https://{{host}}/orders?id={% uuid 'v4' %}" {
+		t.Errorf("Unexpected request: %+v", find)
+	}
+	if auth := result.Folders["orders"].Auth; auth == nil || auth.Type != authBasic || auth.Username != "{{user}}" {
+		t.Errorf("Expected basic auth on the orders folder, got %+v", auth)
+	}
+	if result.Folders[""].Variables["host"] != "localhost" {
+		t.Errorf("Expected base environment on the root folder, got %v", result.Folders[""].Variables)
+	}
+	if len(result.Environments) != 1 || result.Environments[0].Variables["api.version"] != "2" {
+		t.Errorf("Expected flattened production environment, got %+v", result.Environments)
+	}
+
+	report := strings.Join(result.Report, "\n")
+	for _, want := range []string{"template tag {% uuid 'v4' %}", "Upload: multipart form body", "unit test suite Smoke"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to mention %q, got:\n%s", want, report)
+		}
+	}
+}
+
+// TestImportCommandWritesFiles tests that imported collections are written as saved requests
+func TestImportCommandWritesFiles(t *testing.T) {
+	dir := chdirTemp(t)
+	if err := os.WriteFile("collection.json", []byte(testPostmanCollection), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"import", "postman", "collection.json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected import to succeed, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "3 requests") || !strings.Contains(stdout.String(), "Not imported:") {
+		t.Errorf("Unexpected import output:\n%s", stdout.String())
+	}
+
+	requests, folders, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 3 {
+		t.Errorf("Expected 3 saved requests, got %d", len(requests))
+	}
+	if folders["pet-store"].Variables["baseUrl"] == "" {
+		t.Errorf("Expected collection variables in pet-store/folder.json, got %+v", folders["pet-store"])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "requests", "pet-store", "pets", "list-pets.json")); err != nil {
+		t.Errorf("Expected list-pets.json in the pets folder: %v", err)
+	}
+}
+
+// TestEncodeParams tests that form values are escaped while variable references are kept
+func TestEncodeParams(t *testing.T) {
+	got := encodeParams([][2]string{{"q", "a&b=c+d e"}, {"token", "{{token}}"}, {"note", "x {{user}}&y"}})
+	if want := "q=a%26b%3Dc%2Bd+e&token={{token}}&note=x+{{user}}%26y"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := appendQuery("/search?page=1", [][2]string{{"tag", "a b"}}); got != "/search?page=1&tag=a+b" {
+		t.Errorf("Expected the escaped parameter appended, got %q", got)
+	}
+}
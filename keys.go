@@ -0,0 +1,373 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Keymap presets
+const (
+	keymapDefault = "default"
+	keymapVim     = "vim"
+)
+
+// keyMap holds the bindings of every action of the interactive client,
+// grouped by the screen they are used on
+type keyMap struct {
+	// Shared by several screens
+	Back    key.Binding
+	Confirm key.Binding
+	Cancel  key.Binding
+	Yes     key.Binding
+	No      key.Binding
+
+	Palette  paletteKeys
+	Main     mainKeys
+	Tabs     tabKeys
+	Editor   editorKeys
+	Layout   layoutKeys
+	Response responseKeys
+	Requests requestKeys
+	History  historyKeys
+	Results  resultKeys
+	Review   reviewKeys
+	Listen   listenKeys
+	LoadTest loadTestKeys
+	Mock     mockKeys
+}
+
+type paletteKeys struct{ Open, Up, Down key.Binding }
+
+type mainKeys struct {
+	Edit, Send, Load, History, Run, RunSnapshots, LoadTest, Webhooks, Environment, Quit key.Binding
+}
+
+type tabKeys struct{ New, Close, Next, Previous, MoveLeft, MoveRight, GoTo key.Binding }
+
+type editorKeys struct{ NextField, Send, Save, External, Back key.Binding }
+
+type layoutKeys struct{ Focus, Shrink, Grow, Toggle key.Binding }
+
+type responseKeys struct{ Edit, Watch, Export, Compare, External key.Binding }
+
+type requestKeys struct {
+	Open, Expand, Collapse, Run, Rename, Move, Duplicate, Delete, Undo, Back key.Binding
+}
+
+type historyKeys struct{ Open, Mark, Compare, Export key.Binding }
+
+type resultKeys struct{ Open, Review key.Binding }
+
+type reviewKeys struct{ Accept, Reject key.Binding }
+
+type listenKeys struct{ Replay, Clear, Stop key.Binding }
+
+type loadTestKeys struct{ Stop, Export key.Binding }
+
+type mockKeys struct{ Stop key.Binding }
+
+// bind creates a binding described in the help by its first key
+func bind(help string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keys[0], help))
+}
+
+// defaultKeyMap returns the standard bindings
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Back:    bind("Back", "esc", "q"),
+		Confirm: bind("Confirm", "enter"),
+		Cancel:  bind("Cancel", "esc"),
+		Yes:     bind("Yes", "y", "Y"),
+		No:      bind("No", "n", "N", "esc"),
+
+		Palette: paletteKeys{
+			Open: bind("Commands", "ctrl+p"),
+			Up:   bind("Up", "up", "ctrl+k"),
+			Down: bind("Down", "down", "ctrl+j"),
+		},
+		Main: mainKeys{
+			Edit:         bind("Edit request", "e", "n"),
+			Send:         bind("Send request", "enter"),
+			Load:         bind("Load saved", "l"),
+			History:      bind("History", "h"),
+			Run:          bind("Run all", "r"),
+			RunSnapshots: bind("Run with snapshots", "S"),
+			LoadTest:     bind("Load test", "L"),
+			Webhooks:     bind("Webhooks", "w"),
+			Environment:  bind("Environment", "v"),
+			Quit:         bind("Quit", "q", "ctrl+c", "esc"),
+		},
+		Tabs: tabKeys{
+			New:       bind("New tab", "t"),
+			Close:     bind("Close tab", "ctrl+w"),
+			Next:      bind("Next tab", "tab"),
+			Previous:  bind("Previous tab", "shift+tab"),
+			MoveLeft:  bind("Move tab left", "<"),
+			MoveRight: bind("Move tab right", ">"),
+			GoTo: key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+				key.WithHelp("1-9", "Go to tab")),
+		},
+		Editor: editorKeys{
+			NextField: bind("Next field", "tab", "ctrl+n"),
+			Send:      bind("Send", "ctrl+s"),
+			Save:      bind("Save", "alt+s"),
+			External:  bind("Open in $EDITOR", "alt+e"),
+			Back:      bind("Back", "esc"),
+		},
+		Layout: layoutKeys{
+			Focus:  bind("Switch pane", "ctrl+o"),
+			Shrink: bind("Shrink editor", "alt+["),
+			Grow:   bind("Grow editor", "alt+]"),
+			Toggle: bind("Split view", "alt+l"),
+		},
+		Response: responseKeys{
+			Edit:     bind("Edit request", "e"),
+			Watch:    bind("Watch", "W"),
+			Export:   bind("Export as HAR", "E"),
+			Compare:  bind("Compare with history", "d"),
+			External: bind("Open in $EDITOR", "alt+e"),
+		},
+		Requests: requestKeys{
+			Open:      bind("Select/toggle folder", "enter"),
+			Expand:    bind("Expand", "right"),
+			Collapse:  bind("Collapse", "left"),
+			Run:       bind("Run folder", "r"),
+			Rename:    bind("Rename", "R"),
+			Move:      bind("Move", "m"),
+			Duplicate: bind("Duplicate", "c"),
+			Delete:    bind("Delete", "x", "delete"),
+			Undo:      bind("Undo delete", "u"),
+			Back:      bind("Cancel", "esc"),
+		},
+		History: historyKeys{
+			Open:    bind("Open", "enter"),
+			Mark:    bind("Mark for comparison", "m"),
+			Compare: bind("Compare with marked", "d"),
+			Export:  bind("Export history as HAR", "E"),
+		},
+		Results: resultKeys{
+			Open:   bind("View response", "enter"),
+			Review: bind("Review changed snapshots", "s"),
+		},
+		Review: reviewKeys{
+			Accept: bind("Accept new response", "a", "y"),
+			Reject: bind("Reject and keep snapshot", "r", "n"),
+		},
+		Listen: listenKeys{
+			Replay: bind("Open to replay", "enter"),
+			Clear:  bind("Clear", "c"),
+			Stop:   bind("Stop listening", "x"),
+		},
+		LoadTest: loadTestKeys{
+			Stop:   bind("Stop", "x"),
+			Export: bind("Export JSON and CSV", "e"),
+		},
+		Mock: mockKeys{
+			Stop: bind("Stop", "q", "ctrl+c", "esc"),
+		},
+	}
+}
+
+// vimKeyMap returns the default bindings with vim-style alternatives: i to
+// edit, h/l to fold folders, [ and ] for tabs, ctrl+w to switch panes and :
+// for the command palette
+func vimKeyMap() keyMap {
+	k := defaultKeyMap()
+	k.Palette.Open = bind("Commands", ":", "ctrl+p")
+	k.Main.Edit = bind("Edit request", "i", "e")
+	k.Tabs.Close = bind("Close tab", "x")
+	k.Tabs.Next = bind("Next tab", "]", "tab")
+	k.Tabs.Previous = bind("Previous tab", "[", "shift+tab")
+	k.Editor.NextField = bind("Next field", "tab", "ctrl+j")
+	k.Layout.Focus = bind("Switch pane", "ctrl+w")
+	k.Layout.Shrink = bind("Shrink editor", "alt+h")
+	k.Layout.Grow = bind("Grow editor", "alt+l")
+	k.Layout.Toggle = bind("Split view", "alt+z")
+	k.Response.Edit = bind("Edit request", "i", "e")
+	k.Requests.Expand = bind("Expand", "l", "right")
+	k.Requests.Collapse = bind("Collapse", "h", "left")
+	k.Requests.Delete = bind("Delete", "d", "x", "delete")
+	return k
+}
+
+// presetKeyMap returns the bindings of a preset
+func presetKeyMap(name string) (keyMap, error) {
+	switch name {
+	case "", keymapDefault:
+		return defaultKeyMap(), nil
+	case keymapVim:
+		return vimKeyMap(), nil
+	}
+	return keyMap{}, fmt.Errorf("unknown keymap %q, use %s or %s", name, keymapDefault, keymapVim)
+}
+
+// named returns the bindings by the names used in the config file
+func (k *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"back":    &k.Back,
+		"confirm": &k.Confirm,
+		"cancel":  &k.Cancel,
+		"yes":     &k.Yes,
+		"no":      &k.No,
+
+		"palette.open": &k.Palette.Open,
+		"palette.up":   &k.Palette.Up,
+		"palette.down": &k.Palette.Down,
+
+		"main.edit":          &k.Main.Edit,
+		"main.send":          &k.Main.Send,
+		"main.load":          &k.Main.Load,
+		"main.history":       &k.Main.History,
+		"main.run":           &k.Main.Run,
+		"main.run_snapshots": &k.Main.RunSnapshots,
+		"main.load_test":     &k.Main.LoadTest,
+		"main.webhooks":      &k.Main.Webhooks,
+		"main.environment":   &k.Main.Environment,
+		"main.quit":          &k.Main.Quit,
+
+		"tabs.new":        &k.Tabs.New,
+		"tabs.close":      &k.Tabs.Close,
+		"tabs.next":       &k.Tabs.Next,
+		"tabs.previous":   &k.Tabs.Previous,
+		"tabs.move_left":  &k.Tabs.MoveLeft,
+		"tabs.move_right": &k.Tabs.MoveRight,
+		"tabs.goto":       &k.Tabs.GoTo,
+
+		"editor.next_field": &k.Editor.NextField,
+		"editor.send":       &k.Editor.Send,
+		"editor.save":       &k.Editor.Save,
+		"editor.external":   &k.Editor.External,
+		"editor.back":       &k.Editor.Back,
+
+		"layout.focus":  &k.Layout.Focus,
+		"layout.shrink": &k.Layout.Shrink,
+		"layout.grow":   &k.Layout.Grow,
+		"layout.toggle": &k.Layout.Toggle,
+
+		"response.edit":     &k.Response.Edit,
+		"response.watch":    &k.Response.Watch,
+		"response.export":   &k.Response.Export,
+		"response.compare":  &k.Response.Compare,
+		"response.external": &k.Response.External,
+
+		"requests.open":      &k.Requests.Open,
+		"requests.expand":    &k.Requests.Expand,
+		"requests.collapse":  &k.Requests.Collapse,
+		"requests.run":       &k.Requests.Run,
+		"requests.rename":    &k.Requests.Rename,
+		"requests.move":      &k.Requests.Move,
+		"requests.duplicate": &k.Requests.Duplicate,
+		"requests.delete":    &k.Requests.Delete,
+		"requests.undo":      &k.Requests.Undo,
+		"requests.back":      &k.Requests.Back,
+
+		"history.open":    &k.History.Open,
+		"history.mark":    &k.History.Mark,
+		"history.compare": &k.History.Compare,
+		"history.export":  &k.History.Export,
+
+		"results.open":   &k.Results.Open,
+		"results.review": &k.Results.Review,
+
+		"review.accept": &k.Review.Accept,
+		"review.reject": &k.Review.Reject,
+
+		"listen.replay": &k.Listen.Replay,
+		"listen.clear":  &k.Listen.Clear,
+		"listen.stop":   &k.Listen.Stop,
+
+		"load_test.stop":   &k.LoadTest.Stop,
+		"load_test.export": &k.LoadTest.Export,
+
+		"mock.stop": &k.Mock.Stop,
+	}
+}
+
+// rebind replaces the keys of the named action; no keys unbinds it
+func (k *keyMap) rebind(name string, keys []string) error {
+	b, ok := k.named()[name]
+	if !ok {
+		return fmt.Errorf("unknown action %q", name)
+	}
+	if len(keys) == 0 {
+		b.Unbind()
+		return nil
+	}
+	desc := b.Help().Desc
+	b.SetKeys(keys...)
+	b.SetHelp(strings.Join(keys, "/"), desc)
+	b.SetEnabled(true)
+	return nil
+}
+
+// typing reports whether a key types text into an input rather than
+// running a command, so that letters can be bound without losing them
+func (m model) typing(msg tea.KeyMsg) bool {
+	if msg.Type != tea.KeyRunes || msg.Alt {
+		return false
+	}
+	switch m.state {
+	case stateEditRequest:
+		return !isListFocused(m)
+	case stateLoadRequest:
+		return m.requestList.SettingFilter()
+	case stateHistory:
+		return m.historyList.SettingFilter()
+	case stateListen:
+		return m.capturedList.SettingFilter()
+	}
+	return false
+}
+
+// keyMsg builds the message for a key written as Bubble Tea names it,
+// e.g. "enter", "ctrl+w", "alt+s" or "W"
+func keyMsg(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "shift+tab":
+		return tea.KeyMsg{Type: tea.KeyShiftTab}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "delete":
+		return tea.KeyMsg{Type: tea.KeyDelete}
+	}
+	if name, ok := strings.CutPrefix(s, "ctrl+"); ok && len(name) == 1 && name[0] >= 'a' && name[0] <= 'z' {
+		return tea.KeyMsg{Type: tea.KeyCtrlA + tea.KeyType(name[0]-'a')}
+	}
+	if name, ok := strings.CutPrefix(s, "alt+"); ok && name != "" {
+		msg := keyMsg(name)
+		msg.Alt = true
+		return msg
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// helpLine renders the help of the bound actions as one line
+func helpLine(bindings ...key.Binding) string {
+	var parts []string
+	for _, b := range bindings {
+		if h := b.Help(); b.Enabled() && h.Key != "" {
+			parts = append(parts, h.Key+": "+h.Desc)
+		}
+	}
+	return helpStyle.Render("  "+strings.Join(parts, " • ")) + "\n"
+}
+
+// withHelp returns a binding described differently in a help line
+func withHelp(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+// scrollHelp describes the arrow keys of scrolled views in help lines
+var scrollHelp = key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑/↓", "Scroll"))
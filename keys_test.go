@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestKeyMsg tests that keys written by name produce messages with the same name
+func TestKeyMsg(t *testing.T) {
+	for _, key := range []string{"enter", "esc", "tab", "shift+tab", "up", "ctrl+w", "ctrl+p", "alt+s", "alt+]", "W", "1"} {
+		if got := keyMsg(key).String(); got != key {
+			t.Errorf("Expected key %q, got %q", key, got)
+		}
+	}
+}
+
+// TestReboundKeys tests that actions follow their configured keys and the help shows them
+func TestReboundKeys(t *testing.T) {
+	keys, err := Config{Keys: map[string]keyList{"editor.send": {"ctrl+r"}, "tabs.close": nil}}.keyMap()
+	if err != nil {
+		t.Fatalf("Expected the config to apply, got %v", err)
+	}
+	m := initialModel()
+	m.keys = keys
+	m.setRequest(HTTPRequest{Method: "GET", URL: "http://example.test/users"})
+	m, _ = pressKey(m, "e")
+
+	view := m.View()
+	if !strings.Contains(view, "ctrl+r: Send") || strings.Contains(view, "ctrl+s: Send") {
+		t.Errorf("Expected the help to show the rebound key, got:\n%s", view)
+	}
+	m, cmd := pressKey(m, "ctrl+s")
+	if m.loading || cmd != nil {
+		t.Errorf("Expected the old key to do nothing")
+	}
+	m, cmd = pressKey(m, "ctrl+r")
+	if !m.loading || cmd == nil {
+		t.Errorf("Expected the new key to send the request")
+	}
+
+	m.state = stateMain
+	m, _ = pressKey(m, "t")
+	m, _ = pressKey(m, "ctrl+w")
+	if len(m.tabs) != 2 || strings.Contains(m.View(), "Close tab") {
+		t.Errorf("Expected the unbound close key to do nothing and be left out of the help")
+	}
+}
+
+// TestVimKeyMap tests the vim preset, whose letter keys are not taken while typing
+func TestVimKeyMap(t *testing.T) {
+	m := initialModel()
+	m.keys = vimKeyMap()
+
+	m, _ = pressKey(m, "i")
+	if m.state != stateEditRequest || !m.urlInput.Focused() {
+		t.Fatalf("Expected i to edit the request, got state %d", m.state)
+	}
+	m = typeQuery(m, "http://example.test")
+	if m.state != stateEditRequest || m.currentRequest.URL != "http://example.test" {
+		t.Errorf("Expected : to be typed into the URL, got state %d with %q", m.state, m.currentRequest.URL)
+	}
+
+	m, _ = pressKey(m, "esc")
+	m, _ = pressKey(m, ":")
+	if m.state != stateCommandPalette {
+		t.Errorf("Expected : to open the palette, got state %d", m.state)
+	}
+	m, _ = pressKey(m, ":")
+	if m.state != stateCommandPalette || m.promptInput.Value() != ":" {
+		t.Errorf("Expected : to be typed into the palette search")
+	}
+}
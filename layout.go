@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Layouts of the request editor and response
+const (
+	layoutFull    = iota // one of them fills the screen
+	layoutColumns        // editor on the left, response on the right
+	layoutRows           // editor on top, response below
+)
+
+const (
+	// splitMinWidth is the narrowest window showing the panes side by side
+	splitMinWidth = 120
+	// splitMinHeight is the shortest window stacking the panes
+	splitMinHeight = 50
+	// defaultSplitRatio is the share of the window given to the editor
+	defaultSplitRatio = 0.5
+	// splitStep is how much a resize key moves the divider
+	splitStep = 0.05
+)
+
+// layout picks how the editor and response are shown for the window size
+func (m model) layout() int {
+	switch {
+	case m.fullScreen || m.width == 0:
+		return layoutFull
+	case m.width >= splitMinWidth:
+		return layoutColumns
+	case m.height >= splitMinHeight:
+		return layoutRows
+	}
+	return layoutFull
+}
+
+// editorWidth is the width of the editor pane, borders included
+func (m model) editorWidth() int {
+	if m.layout() != layoutColumns {
+		return m.width
+	}
+	return int(float64(m.width) * m.splitRatio)
+}
+
+// resize fits the editor inputs and response view to the layout
+func (m *model) resize() {
+	// Room taken by the tab bar
+	height := m.height - 1
+
+	switch m.layout() {
+	case layoutColumns:
+		left, right := m.editorWidth(), m.width-m.editorWidth()
+		m.urlInput.Width = left - 8
+		m.headerInput.SetWidth(left - 6)
+		m.bodyInput.SetWidth(left - 6)
+		m.bodyInput.SetHeight(10)
+		m.responseView.Width = right - 4
+		m.responseView.Height = max(3, height-m.responseChrome(right))
+
+	case layoutRows:
+		m.urlInput.Width = m.width - 8
+		m.headerInput.SetWidth(m.width - 6)
+		m.bodyInput.SetWidth(m.width - 6)
+		// The divider sets the body height; the response gets what is left
+		fixed := paneHeight(m.editorPane(), m.width) - m.bodyInput.Height()
+		m.bodyInput.SetHeight(max(3, int(float64(height)*m.splitRatio)-fixed))
+		m.responseView.Width = m.width - 4
+		m.responseView.Height = max(3, height-paneHeight(m.editorPane(), m.width)-m.responseChrome(m.width))
+
+	default:
+		m.urlInput.Width = 40
+		m.headerInput.SetWidth(m.width - 4)
+		m.bodyInput.SetWidth(m.width - 4)
+		m.bodyInput.SetHeight(10)
+		m.responseView.Width = m.width
+		m.responseView.Height = m.height - 4
+	}
+}
+
+// affectsLayout reports whether a message can change the size of the panes.
+// Animation ticks only redraw, so they skip measuring the panes.
+func affectsLayout(msg tea.Msg) bool {
+	switch msg.(type) {
+	case spinner.TickMsg, cursor.BlinkMsg:
+		return false
+	}
+	return true
+}
+
+// paneHeight is the height of a pane drawn in a box of the given width
+func paneHeight(pane string, width int) int {
+	return lipgloss.Height(paneStyle.Width(width - 2).Render(strings.TrimRight(pane, "\n")))
+}
+
+// responseChrome is the height of the boxed response pane around the response itself
+func (m model) responseChrome(width int) int {
+	return paneHeight(m.responsePane(), width) - m.responseView.Height
+}
+
+// handleLayoutKey handles the keys that switch focus between the panes, resize them and toggle the split
+func (m *model) handleLayoutKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Layout.Focus):
+		if m.state == stateViewResponse {
+			m.state = stateEditRequest
+			m.urlInput.Focus()
+			return textinput.Blink, true
+		}
+		m.urlInput.Blur()
+		m.headerInput.Blur()
+		m.bodyInput.Blur()
+		m.state = stateViewResponse
+		return nil, true
+	case key.Matches(msg, m.keys.Layout.Shrink, m.keys.Layout.Grow):
+		if m.layout() == layoutFull {
+			return nil, true
+		}
+		delta := splitStep
+		if key.Matches(msg, m.keys.Layout.Shrink) {
+			delta = -splitStep
+		}
+		m.splitRatio = min(0.8, max(0.2, m.splitRatio+delta))
+		return nil, true
+	case key.Matches(msg, m.keys.Layout.Toggle):
+		m.fullScreen = !m.fullScreen
+		return nil, true
+	}
+	return nil, false
+}
+
+// layoutHelp returns the layout keys for the help line
+func (m model) layoutHelp() []key.Binding {
+	switch {
+	case m.layout() != layoutFull:
+		return []key.Binding{m.keys.Layout.Shrink, m.keys.Layout.Grow, withHelp(m.keys.Layout.Toggle, "Full screen")}
+	case m.fullScreen:
+		return []key.Binding{m.keys.Layout.Toggle}
+	}
+	return nil
+}
+
+// splitView shows the editor and response together, highlighting the focused pane
+func (m model) splitView() string {
+	editorStyle, responseStyle := paneStyle, focusedPaneStyle
+	if m.state == stateEditRequest {
+		editorStyle, responseStyle = focusedPaneStyle, paneStyle
+	}
+
+	if m.layout() == layoutColumns {
+		height := m.height - 3
+		left, right := m.editorWidth(), m.width-m.editorWidth()
+		editor := editorStyle.Width(left - 2).Height(height).MaxHeight(height + 2).Render(strings.TrimRight(m.editorPane(), "\n"))
+		response := responseStyle.Width(right - 2).Height(height).MaxHeight(height + 2).Render(strings.TrimRight(m.responsePane(), "\n"))
+		return lipgloss.JoinHorizontal(lipgloss.Top, editor, response)
+	}
+
+	editor := editorStyle.Width(m.width - 2).Render(strings.TrimRight(m.editorPane(), "\n"))
+	response := responseStyle.Width(m.width - 2).Render(strings.TrimRight(m.responsePane(), "\n"))
+	return lipgloss.JoinVertical(lipgloss.Left, editor, response)
+}
+
+// editorPane renders the request editor
+func (m model) editorPane() string {
+	s := titleStyle.Render("Edit Request") + m.modifiedMarker()
+	s += "\n\n"
+
+	// URL input
+	s += "  URL:\n"
+	if m.urlInput.Focused() {
+		s += focusedInputStyle.Render(m.urlInput.View()) + "\n\n"
+	} else {
+		s += urlInputStyle.Render(m.urlInput.View()) + "\n\n"
+	}
+
+	// Method selection
+	if isListFocused(m) {
+		s += focusedInputStyle.Render(m.methodList.View()) + "\n\n"
+	} else {
+		s += m.methodList.View() + "\n\n"
+	}
+
+	// Headers
+	s += headerStyle.Render("  Headers:") + "\n"
+	if m.headerInput.Focused() {
+		s += focusedInputStyle.Render(m.headerInput.View()) + "\n\n"
+	} else {
+		s += m.headerInput.View() + "\n\n"
+	}
+
+	// Body
+	s += headerStyle.Render("  Body:") + "\n"
+	if m.bodyInput.Focused() {
+		s += focusedInputStyle.Render(m.bodyInput.View()) + "\n\n"
+	} else {
+		s += m.bodyInput.View() + "\n\n"
+	}
+
+	k := m.keys
+	s += helpLine(append([]key.Binding{k.Editor.NextField, k.Editor.Send, k.Editor.Save, k.Editor.External, withHelp(k.Layout.Focus, "Response"), k.Editor.Back},
+		m.layoutHelp()...)...)
+
+	return s
+}
+
+// responsePane renders the response of the current request
+func (m model) responsePane() string {
+	s := titleStyle.Render("Response")
+	s += "\n\n"
+	if m.loading {
+		s += fmt.Sprintf("  %s Sending request...\n\n", m.spinner.View())
+	}
+	if m.watch != nil {
+		s += m.watch.view() + "\n"
+	}
+	s += m.responseView.View()
+	s += "\n\n"
+	if m.status != "" {
+		s += "  " + m.status + "\n"
+	}
+	if m.err != nil {
+		s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
+	}
+	k := m.keys
+	watch := k.Response.Watch
+	if m.watch != nil && m.watch.running {
+		watch = withHelp(watch, "Stop watching")
+	}
+	s += helpLine(append([]key.Binding{k.Back, k.Response.Edit, k.Response.Compare, k.Response.Export, watch, k.Response.External, withHelp(k.Layout.Focus, "Editor")},
+		m.layoutHelp()...)...)
+
+	return s
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// resizeModel sends a window size to the model
+func resizeModel(m model, width, height int) model {
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	return updated.(model)
+}
+
+// TestLayout tests that the layout follows the window size
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		width, height int
+		expected      int
+	}{
+		{160, 50, layoutColumns},
+		{120, 30, layoutColumns},
+		{80, 60, layoutRows},
+		{80, 24, layoutFull},
+		{119, 49, layoutFull},
+	}
+	for _, tt := range tests {
+		m := resizeModel(initialModel(), tt.width, tt.height)
+		if got := m.layout(); got != tt.expected {
+			t.Errorf("Expected layout %d for %dx%d, got %d", tt.expected, tt.width, tt.height, got)
+		}
+	}
+
+	m := resizeModel(initialModel(), 160, 50)
+	m.fullScreen = true
+	if m.layout() != layoutFull {
+		t.Errorf("Expected the full screen layout once the split is turned off")
+	}
+}
+
+// TestSplitView tests that the split view shows both panes, switches focus and resizes
+func TestSplitView(t *testing.T) {
+	m := resizeModel(initialModel(), 160, 50)
+	m.setRequest(HTTPRequest{Method: "GET", URL: "http://example.test/users"})
+	m.state = stateEditRequest
+	m.urlInput.Focus()
+
+	view := m.View()
+	if !strings.Contains(view, "Edit Request") || !strings.Contains(view, "Response") {
+		t.Errorf("Expected both panes in the split view, got:\n%s", view)
+	}
+	if lines := strings.Count(view, "\n") + 1; lines > 50 {
+		t.Errorf("Expected the split view to fit the window, got %d lines", lines)
+	}
+	if m.editorWidth() != 80 || m.responseView.Width != 76 {
+		t.Errorf("Expected even panes, got editor %d and response %d", m.editorWidth(), m.responseView.Width)
+	}
+
+	// Sending keeps the editor focused and shows the response beside it
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = updated.(model)
+	if m.state != stateEditRequest || !m.loading {
+		t.Errorf("Expected the editor to stay focused while sending, got state %d", m.state)
+	}
+	updated, _ = m.Update(responseMsg{tab: m.tabs[m.activeTab].id, request: m.currentRequest,
+		response: HTTPResponse{StatusCode: 200, Status: "200 OK", Body: "users"}})
+	m = updated.(model)
+	if m.state != stateEditRequest || m.response.Body != "users" {
+		t.Errorf("Expected the response beside the editor, got state %d", m.state)
+	}
+
+	// The status line takes room from the response as soon as it is shown
+	height := m.responseView.Height
+	updated, _ = m.Update(harExportedMsg("exports/users.har"))
+	m = updated.(model)
+	if m.responseView.Height != height-1 {
+		t.Errorf("Expected the response to shrink by the status line, got %d from %d", m.responseView.Height, height)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = updated.(model)
+	if m.state != stateViewResponse || m.urlInput.Focused() {
+		t.Errorf("Expected ctrl+o to focus the response, got state %d", m.state)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = updated.(model)
+	if m.state != stateEditRequest || !m.urlInput.Focused() {
+		t.Errorf("Expected ctrl+o to focus the editor, got state %d", m.state)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("]"), Alt: true})
+	m = updated.(model)
+	if m.editorWidth() != 88 || m.responseView.Width != 68 {
+		t.Errorf("Expected the editor to grow, got editor %d and response %d", m.editorWidth(), m.responseView.Width)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l"), Alt: true})
+	m = updated.(model)
+	if m.layout() != layoutFull || strings.Contains(m.View(), "Status: 200") {
+		t.Errorf("Expected alt+l to show the editor full screen")
+	}
+}
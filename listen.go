@@ -0,0 +1,275 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultListenAddr is where the webhook catcher listens unless told otherwise
+const defaultListenAddr = "localhost:9000"
+
+// maxCapturedBody bounds the part of an inbound body that is kept
+const maxCapturedBody = 10 << 20
+
+// maxCaptured bounds the inbound requests kept in the webhook list
+const maxCaptured = 500
+
+// CapturedRequest is an inbound request received by the webhook catcher
+type CapturedRequest struct {
+	Time       time.Time
+	Method     string
+	Host       string
+	Path       string
+	Headers    map[string]string
+	Body       string
+	RemoteAddr string
+	Status     int
+}
+
+// request converts a captured request into an outgoing one, addressed to
+// the host it was sent to so it can be pointed at a real service and re-sent
+func (c CapturedRequest) request() HTTPRequest {
+	headers := make(map[string]string)
+	for k, v := range c.Headers {
+		switch strings.ToLower(k) {
+		case "host", "content-length", "connection", "accept-encoding", "transfer-encoding":
+			continue
+		}
+		headers[k] = v
+	}
+	return HTTPRequest{
+		Name:    fmt.Sprintf("Webhook %s %s", c.Method, httpDisplayPath(c.Path)),
+		Method:  c.Method,
+		URL:     "http://" + c.Host + c.Path,
+		Headers: headers,
+		Body:    c.Body,
+	}
+}
+
+// capturedItem is a row of the webhook list
+type capturedItem struct {
+	capture CapturedRequest
+}
+
+func (c capturedItem) Title() string {
+	return c.capture.Method + " " + c.capture.Path
+}
+
+func (c capturedItem) Description() string {
+	return fmt.Sprintf("%s • from %s • %d bytes • answered %d",
+		c.capture.Time.Local().Format("15:04:05"), c.capture.RemoteAddr, len(c.capture.Body), c.capture.Status)
+}
+
+func (c capturedItem) FilterValue() string { return c.Title() }
+
+// webhookListener records inbound requests and answers them with canned
+// responses: the first matching route, or the fallback response
+type webhookListener struct {
+	addr     string
+	server   *http.Server
+	routes   []mockRoute
+	fallback MockResponse
+	captured chan CapturedRequest
+
+	// mu guards closing captured against handlers still delivering to it
+	mu     sync.Mutex
+	closed bool
+}
+
+// capturedMsg delivers an inbound request to the webhook list
+type capturedMsg CapturedRequest
+
+// listenStartedMsg reports that the webhook catcher is accepting requests
+type listenStartedMsg struct{ listener *webhookListener }
+
+// listenFailedMsg reports that the webhook catcher could not listen
+type listenFailedMsg struct {
+	listener *webhookListener
+	err      error
+}
+
+func newWebhookListener(routes []mockRoute, fallback MockResponse) *webhookListener {
+	return &webhookListener{routes: routes, fallback: fallback, captured: make(chan CapturedRequest, 256)}
+}
+
+func (l *webhookListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(io.LimitReader(r.Body, maxCapturedBody))
+	capture := CapturedRequest{
+		Time:       time.Now(),
+		Method:     r.Method,
+		Host:       r.Host,
+		Path:       r.URL.RequestURI(),
+		Headers:    make(map[string]string),
+		Body:       string(body),
+		RemoteAddr: r.RemoteAddr,
+	}
+	for k, v := range r.Header {
+		capture.Headers[k] = strings.Join(v, ", ")
+	}
+
+	resp, params := l.fallback, map[string]string{}
+	route := mockRoute{}
+	for _, candidate := range l.routes {
+		if p, ok := candidate.match(r); ok {
+			route, resp, params = candidate, candidate.Response, p
+			break
+		}
+	}
+
+	capture.Status = resp.Status
+	if capture.Status == 0 {
+		capture.Status = http.StatusOK
+	}
+	out := []byte(resp.Body)
+	if route.body != nil {
+		r.Body = io.NopCloser(strings.NewReader(capture.Body))
+		if rendered, err := route.render(r, params); err == nil {
+			out = rendered
+		}
+	}
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(capture.Status)
+	w.Write(out)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	select {
+	case l.captured <- capture:
+	default:
+	}
+}
+
+// parseListenSpec reads the webhook prompt: an address, optionally followed
+// by the status and body of the canned response, e.g. ":9000 201 accepted"
+func parseListenSpec(s string) (string, MockResponse, error) {
+	addr, rest, _ := strings.Cut(strings.TrimSpace(s), " ")
+	if addr == "" {
+		addr = defaultListenAddr
+	}
+	resp := MockResponse{Status: http.StatusOK}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return addr, resp, nil
+	}
+	status, body, _ := strings.Cut(rest, " ")
+	code, err := strconv.Atoi(status)
+	if err != nil || code < 100 || code > 999 {
+		return "", MockResponse{}, fmt.Errorf("invalid status %q", status)
+	}
+	resp.Status, resp.Body = code, strings.TrimSpace(body)
+	return addr, resp, nil
+}
+
+// formatListenSpec is the inverse of parseListenSpec
+func formatListenSpec(addr string, resp MockResponse) string {
+	if resp.Body == "" && (resp.Status == 0 || resp.Status == http.StatusOK) {
+		return addr
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %d %s", addr, status, resp.Body))
+}
+
+// start listens on addr and serves in the background
+func (l *webhookListener) start(addr string) tea.Cmd {
+	return func() tea.Msg {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return listenFailedMsg{listener: l, err: err}
+		}
+		l.addr = ln.Addr().String()
+		l.server = &http.Server{Handler: l}
+		go l.server.Serve(ln)
+		return listenStartedMsg{listener: l}
+	}
+}
+
+// wait waits for the next inbound request. Once the listener is stopped it
+// returns nil, ending the wait.
+func (l *webhookListener) wait() tea.Msg {
+	capture, ok := <-l.captured
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !ok || l.closed {
+		return nil
+	}
+	return capturedMsg(capture)
+}
+
+// stop closes the listener and ends any wait for inbound requests
+func (l *webhookListener) stop() {
+	if l.server != nil {
+		l.server.Close()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.captured)
+	}
+}
+
+// listenCommand starts the interactive client with the webhook catcher running
+func listenCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("listen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", defaultListenAddr, "address to listen on")
+	status := fs.Int("status", http.StatusOK, "status of the default response")
+	body := fs.String("body", "", "body of the default response")
+	headers := make(map[string]string)
+	fs.Func("header", "header of the default response as Name: value (repeatable)", func(v string) error {
+		name, value, ok := strings.Cut(v, ":")
+		if !ok {
+			return fmt.Errorf("expected Name: value, got %q", v)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		return nil
+	})
+	responses := fs.String("responses", "", "directory of saved requests whose mock responses answer matching webhooks")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var routes []mockRoute
+	if *responses != "" {
+		requests, _, err := readRequestTree(*responses)
+		if err == nil {
+			routes, err = buildMockRoutes(requests, nil)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	m, err := newClientModel()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 2
+	}
+	m.state = stateListen
+	m.listener = newWebhookListener(routes, MockResponse{Status: *status, Headers: headers, Body: *body})
+	m.listenAddr = *addr
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestWebhookListener tests that inbound requests are captured and answered by matching routes or the fallback
+func TestWebhookListener(t *testing.T) {
+	routes, err := buildMockRoutes([]HTTPRequest{
+		{Name: "Stripe", Method: "POST", URL: "http://x.test/hooks/stripe", Mock: &MockResponse{Status: 202, Body: "got {{.JSON.type}}"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := newWebhookListener(routes, MockResponse{Status: 204, Headers: map[string]string{"X-Caught": "yes"}})
+	server := httptest.NewServer(listener)
+	defer server.Close()
+
+	send := func(path, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest("POST", server.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Signature", "abc")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	next := func() CapturedRequest {
+		t.Helper()
+		select {
+		case c := <-listener.captured:
+			return c
+		case <-time.After(time.Second):
+			t.Fatal("Expected a captured request")
+			return CapturedRequest{}
+		}
+	}
+
+	resp := send("/hooks/stripe", `{"type": "charge.succeeded"}`)
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 202 || string(data) != "got charge.succeeded" {
+		t.Errorf("Expected route response, got %d %q", resp.StatusCode, data)
+	}
+	capture := next()
+	if capture.Method != "POST" || capture.Path != "/hooks/stripe" || capture.Status != 202 {
+		t.Errorf("Expected captured POST /hooks/stripe answered 202, got %+v", capture)
+	}
+
+	resp = send("/hooks/github?delivery=1", `{"zen": "ok"}`)
+	resp.Body.Close()
+	if resp.StatusCode != 204 || resp.Header.Get("X-Caught") != "yes" {
+		t.Errorf("Expected fallback response, got %d %v", resp.StatusCode, resp.Header)
+	}
+	capture = next()
+	if capture.Path != "/hooks/github?delivery=1" || capture.Body != `{"zen": "ok"}` {
+		t.Errorf("Expected captured path and body, got %+v", capture)
+	}
+
+	req := capture.request()
+	if req.Method != "POST" || req.URL != server.URL+"/hooks/github?delivery=1" {
+		t.Errorf("Expected replay of POST %s/hooks/github?delivery=1, got %s %s", server.URL, req.Method, req.URL)
+	}
+	if req.Headers["X-Signature"] != "abc" || req.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected replayed headers, got %v", req.Headers)
+	}
+	if _, ok := req.Headers["Content-Length"]; ok {
+		t.Errorf("Expected Content-Length to be dropped, got %v", req.Headers)
+	}
+}
+
+// TestListenModel tests that captured requests are listed and open in the editor for replay
+func TestListenModel(t *testing.T) {
+	m := initialModel()
+	m.listener = newWebhookListener(nil, MockResponse{})
+	m.state = stateListen
+
+	capture := CapturedRequest{
+		Time:    time.Now(),
+		Method:  "PUT",
+		Host:    "localhost:9000",
+		Path:    "/hooks/orders",
+		Headers: map[string]string{"X-Event": "order.updated", "Host": "localhost:9000"},
+		Body:    `{"id": 1}`,
+		Status:  200,
+	}
+	updated, cmd := m.Update(capturedMsg(capture))
+	m = updated.(model)
+	if len(m.captured) != 1 || len(m.capturedList.Items()) != 1 {
+		t.Fatalf("Expected 1 captured request, got %d", len(m.captured))
+	}
+	if cmd == nil {
+		t.Errorf("Expected to keep waiting for webhooks")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != stateEditRequest {
+		t.Fatalf("Expected the editor, got state %d", m.state)
+	}
+	if m.currentRequest.Method != "PUT" || m.urlInput.Value() != "http://localhost:9000/hooks/orders" {
+		t.Errorf("Expected PUT http://localhost:9000/hooks/orders, got %s %s", m.currentRequest.Method, m.urlInput.Value())
+	}
+	if !strings.Contains(m.headerInput.Value(), "X-Event: order.updated") || strings.Contains(m.headerInput.Value(), "Host") {
+		t.Errorf("Expected replayed headers without Host, got %q", m.headerInput.Value())
+	}
+	if m.bodyInput.Value() != `{"id": 1}` {
+		t.Errorf("Expected body to be kept, got %q", m.bodyInput.Value())
+	}
+
+	m.state = stateListen
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updated.(model)
+	if len(m.captured) != 0 {
+		t.Errorf("Expected the list to be cleared, got %d", len(m.captured))
+	}
+	for range maxCaptured + 10 {
+		updated, _ = m.Update(capturedMsg(capture))
+		m = updated.(model)
+	}
+	if len(m.captured) != maxCaptured {
+		t.Errorf("Expected at most %d captured requests, got %d", maxCaptured, len(m.captured))
+	}
+
+	listener := m.listener
+	waited := make(chan tea.Msg)
+	go func() { waited <- listener.wait() }()
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(model)
+	if m.listener != nil || m.state != stateMain {
+		t.Errorf("Expected the listener to stop and return to main")
+	}
+	select {
+	case msg := <-waited:
+		if msg != nil {
+			t.Errorf("Expected the pending wait to end without a message, got %v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected stopping the listener to end the pending wait")
+	}
+}
+
+// TestParseListenSpec tests that the webhook prompt reads an address and an optional canned response
+func TestParseListenSpec(t *testing.T) {
+	addr, resp, err := parseListenSpec(":9100 201 {\"ok\": true}")
+	if err != nil || addr != ":9100" || resp.Status != 201 || resp.Body != `{"ok": true}` {
+		t.Errorf("Expected :9100 answering 201 with a body, got %q %+v %v", addr, resp, err)
+	}
+	if formatListenSpec(addr, resp) != `:9100 201 {"ok": true}` {
+		t.Errorf("Expected the spec to round trip, got %q", formatListenSpec(addr, resp))
+	}
+	addr, resp, err = parseListenSpec("  ")
+	if err != nil || addr != defaultListenAddr || resp.Status != 200 || formatListenSpec(addr, resp) != defaultListenAddr {
+		t.Errorf("Expected the default address answering 200, got %q %+v %v", addr, resp, err)
+	}
+	if _, _, err := parseListenSpec(":9100 ok"); err == nil {
+		t.Errorf("Expected an invalid status to be rejected")
+	}
+}
+
+// TestListenFailed tests that a listener which cannot start is dropped and the address asked for again
+func TestListenFailed(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	m := initialModel()
+	m.state = stateListenAddr
+	m.promptInput.SetValue(ln.Addr().String() + " 202 queued")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != stateListen || m.listener == nil || m.listener.fallback.Status != 202 {
+		t.Fatalf("Expected to start listening with a 202 response, got state %d", m.state)
+	}
+
+	updated, _ = m.Update(runCmd(t, cmd))
+	m = updated.(model)
+	if m.listener != nil || m.state != stateListenAddr || m.err == nil {
+		t.Errorf("Expected the dead listener to be dropped and the address prompt shown, got state %d", m.state)
+	}
+	if m.promptInput.Value() != ln.Addr().String()+" 202 queued" {
+		t.Errorf("Expected the prompt to keep the spec, got %q", m.promptInput.Value())
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultLoadRequests is the number of requests sent when neither a count nor a duration is given
+const defaultLoadRequests = 100
+
+// loadHistogramBuckets is the number of bars in the latency histogram
+const loadHistogramBuckets = 10
+
+// LoadOptions configures a load test
+type LoadOptions struct {
+	Requests    int           `json:"requests,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+	Concurrency int           `json:"concurrency"`
+	Rate        float64       `json:"rate,omitempty"`
+}
+
+// loadFlags defines the load test flags, shared by whelm load and the TUI prompt
+func loadFlags(fs *flag.FlagSet) *LoadOptions {
+	opts := &LoadOptions{}
+	fs.IntVar(&opts.Requests, "n", 0, "number of requests to send (default 100 unless -d is given)")
+	fs.DurationVar(&opts.Duration, "d", 0, "keep sending requests for this long")
+	fs.IntVar(&opts.Concurrency, "c", 10, "number of requests in flight at once")
+	fs.Float64Var(&opts.Rate, "rate", 0, "maximum requests per second (default unlimited)")
+	return opts
+}
+
+// parseLoadOptions reads load test flags typed into the TUI, e.g. "-n 500 -c 20 -rate 50"
+func parseLoadOptions(s string) (LoadOptions, error) {
+	fs := flag.NewFlagSet("load", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts := loadFlags(fs)
+	if err := fs.Parse(strings.Fields(s)); err != nil {
+		return LoadOptions{}, err
+	}
+	if fs.NArg() > 0 {
+		return LoadOptions{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return *opts, nil
+}
+
+// String renders the options as the flags that produce them
+func (o LoadOptions) String() string {
+	var parts []string
+	if o.Requests > 0 {
+		parts = append(parts, fmt.Sprintf("-n %d", o.Requests))
+	}
+	if o.Duration > 0 {
+		parts = append(parts, "-d "+o.Duration.String())
+	}
+	parts = append(parts, fmt.Sprintf("-c %d", o.Concurrency))
+	if o.Rate > 0 {
+		parts = append(parts, "-rate "+strconv.FormatFloat(o.Rate, 'f', -1, 64))
+	}
+	return strings.Join(parts, " ")
+}
+
+// LoadSample is the outcome of one request of a load test. Start is the
+// offset from the beginning of the test.
+type LoadSample struct {
+	Start   time.Duration `json:"start"`
+	Latency time.Duration `json:"latency"`
+	Status  int           `json:"status,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// LatencyBucket is a bar of the latency histogram, counting samples in [From, To)
+type LatencyBucket struct {
+	From  time.Duration `json:"from"`
+	To    time.Duration `json:"to"`
+	Count int           `json:"count"`
+}
+
+// LoadStats summarises the samples of a load test so far
+type LoadStats struct {
+	Method     string          `json:"method"`
+	URL        string          `json:"url"`
+	Options    LoadOptions     `json:"options"`
+	Started    time.Time       `json:"started"`
+	Elapsed    time.Duration   `json:"elapsed"`
+	Done       bool            `json:"done"`
+	Sent       int             `json:"sent"`
+	Errors     int             `json:"errors"`
+	Statuses   map[int]int     `json:"statuses"`
+	Throughput float64         `json:"throughput"`
+	Min        time.Duration   `json:"min"`
+	P50        time.Duration   `json:"p50"`
+	P90        time.Duration   `json:"p90"`
+	P99        time.Duration   `json:"p99"`
+	Max        time.Duration   `json:"max"`
+	Histogram  []LatencyBucket `json:"histogram"`
+}
+
+// Failed reports whether any request failed to complete or got a server error
+func (s LoadStats) Failed() bool {
+	if s.Errors > 0 {
+		return true
+	}
+	for status := range s.Statuses {
+		if status >= 500 {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTest sends a request repeatedly and records how each one went
+type loadTest struct {
+	request HTTPRequest
+	options LoadOptions
+	started time.Time
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu       sync.Mutex
+	samples  []LoadSample
+	finished time.Time
+}
+
+func newLoadTest(req HTTPRequest, opts LoadOptions) *loadTest {
+	if opts.Requests <= 0 && opts.Duration <= 0 {
+		opts.Requests = defaultLoadRequests
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	// Assertions and contracts are for functional checks, not load
+	req.Assertions, req.Spec = nil, ""
+	ctx, cancel := context.WithCancel(context.Background())
+	return &loadTest{request: req, options: opts, ctx: ctx, cancel: cancel}
+}
+
+// run sends requests from opts.Concurrency workers until the count or
+// duration is reached or the test is stopped, at most opts.Rate a second
+func (t *loadTest) run() {
+	t.mu.Lock()
+	t.started = time.Now()
+	t.mu.Unlock()
+
+	jobs := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < t.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				t.send()
+			}
+		}()
+	}
+
+	var tick, deadline <-chan time.Time
+	if t.options.Rate > 0 {
+		// Rates beyond a request a nanosecond are as good as unlimited
+		ticker := time.NewTicker(max(time.Duration(float64(time.Second)/t.options.Rate), 1))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	if t.options.Duration > 0 {
+		timer := time.NewTimer(t.options.Duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+produce:
+	for i := 0; t.options.Requests <= 0 || i < t.options.Requests; i++ {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-deadline:
+				break produce
+			case <-t.ctx.Done():
+				break produce
+			}
+		}
+		select {
+		case jobs <- struct{}{}:
+		case <-deadline:
+			break produce
+		case <-t.ctx.Done():
+			break produce
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	t.mu.Lock()
+	t.finished = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *loadTest) send() {
+	start := time.Now()
+	resp, err := executeRequest(t.request)
+	sample := LoadSample{Start: start.Sub(t.started), Latency: time.Since(start)}
+	if err != nil {
+		sample.Error = err.Error()
+	} else {
+		sample.Status = resp.StatusCode
+		sample.Latency = resp.Duration
+	}
+	t.mu.Lock()
+	t.samples = append(t.samples, sample)
+	t.mu.Unlock()
+}
+
+// stop stops sending new requests; those in flight still complete
+func (t *loadTest) stop() {
+	t.cancel()
+}
+
+// stats summarises the samples recorded so far
+func (t *loadTest) stats() LoadStats {
+	t.mu.Lock()
+	samples := append([]LoadSample(nil), t.samples...)
+	started, finished := t.started, t.finished
+	t.mu.Unlock()
+
+	s := LoadStats{
+		Method:   t.request.Method,
+		URL:      t.request.URL,
+		Options:  t.options,
+		Started:  started,
+		Done:     !finished.IsZero(),
+		Sent:     len(samples),
+		Statuses: make(map[int]int),
+	}
+	switch {
+	case s.Done:
+		s.Elapsed = finished.Sub(started)
+	case !started.IsZero():
+		s.Elapsed = time.Since(started)
+	}
+	if s.Elapsed > 0 {
+		s.Throughput = float64(s.Sent) / s.Elapsed.Seconds()
+	}
+
+	var latencies []time.Duration
+	for _, sample := range samples {
+		if sample.Error != "" {
+			s.Errors++
+			continue
+		}
+		s.Statuses[sample.Status]++
+		latencies = append(latencies, sample.Latency)
+	}
+	if len(latencies) == 0 {
+		return s
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.Min, s.Max = latencies[0], latencies[len(latencies)-1]
+	s.P50, s.P90, s.P99 = percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99)
+	s.Histogram = latencyHistogram(latencies, loadHistogramBuckets)
+	return s
+}
+
+// recorded returns a copy of the samples recorded so far
+func (t *loadTest) recorded() []LoadSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]LoadSample(nil), t.samples...)
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+// latencyHistogram spreads sorted latencies over n equal-width buckets from the fastest to the slowest
+func latencyHistogram(sorted []time.Duration, n int) []LatencyBucket {
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if lo == hi {
+		return []LatencyBucket{{From: lo, To: hi, Count: len(sorted)}}
+	}
+	width := (hi - lo + time.Duration(n) - 1) / time.Duration(n)
+	buckets := make([]LatencyBucket, n)
+	for i := range buckets {
+		buckets[i].From = lo + time.Duration(i)*width
+		buckets[i].To = buckets[i].From + width
+	}
+	for _, d := range sorted {
+		buckets[min(int((d-lo)/width), n-1)].Count++
+	}
+	return buckets
+}
+
+// formatLoadStats renders load test statistics with a latency histogram
+func formatLoadStats(s LoadStats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s (%s)\n\n", s.Method, s.URL, s.Options)
+
+	progress := strconv.Itoa(s.Sent)
+	if s.Options.Requests > 0 {
+		progress += "/" + strconv.Itoa(s.Options.Requests)
+	}
+	fmt.Fprintf(&b, "Sent:       %s in %s\n", progress, s.Elapsed.Round(100*time.Millisecond))
+	fmt.Fprintf(&b, "Throughput: %.1f req/s\n", s.Throughput)
+	fmt.Fprintf(&b, "Errors:     %d\n", s.Errors)
+
+	var statuses []string
+	codes := make([]int, 0, len(s.Statuses))
+	for code := range s.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		statuses = append(statuses, fmt.Sprintf("%d × %d", code, s.Statuses[code]))
+	}
+	if len(statuses) > 0 {
+		fmt.Fprintf(&b, "Statuses:   %s\n", strings.Join(statuses, ", "))
+	}
+	if len(s.Histogram) == 0 {
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Latency:    min %s • p50 %s • p90 %s • p99 %s • max %s\n\n",
+		roundLatency(s.Min), roundLatency(s.P50), roundLatency(s.P90), roundLatency(s.P99), roundLatency(s.Max))
+	peak := 0
+	for _, bucket := range s.Histogram {
+		peak = max(peak, bucket.Count)
+	}
+	for _, bucket := range s.Histogram {
+		bar := strings.Repeat("█", bucket.Count*40/peak)
+		fmt.Fprintf(&b, "%10s – %-10s │%s %d\n", roundLatency(bucket.From), roundLatency(bucket.To), bar, bucket.Count)
+	}
+	return b.String()
+}
+
+// roundLatency keeps three significant figures or so
+func roundLatency(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= 10*time.Millisecond:
+		return d.Round(100 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// loadReport is the JSON export of a load test
+type loadReport struct {
+	LoadStats
+	Samples []LoadSample `json:"samples"`
+}
+
+// writeLoadJSON writes the statistics and every sample as indented JSON
+func writeLoadJSON(w io.Writer, stats LoadStats, samples []LoadSample) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(loadReport{LoadStats: stats, Samples: samples})
+}
+
+// writeLoadCSV writes one row per sample, with times in milliseconds
+func writeLoadCSV(w io.Writer, samples []LoadSample) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"start_ms", "latency_ms", "status", "error"})
+	ms := func(d time.Duration) string { return strconv.FormatFloat(milliseconds(d), 'f', 3, 64) }
+	for _, s := range samples {
+		status := ""
+		if s.Status != 0 {
+			status = strconv.Itoa(s.Status)
+		}
+		cw.Write([]string{ms(s.Start), ms(s.Latency), status, s.Error})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeLoadFile creates path and writes an export into it
+func writeLoadFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// loadTickMsg refreshes the live statistics of a running load test
+type loadTickMsg struct{ test *loadTest }
+
+// loadDoneMsg reports that a load test finished
+type loadDoneMsg struct{ test *loadTest }
+
+func loadTick(t *loadTest) tea.Cmd {
+	return tea.Tick(250*time.Millisecond, func(time.Time) tea.Msg { return loadTickMsg{test: t} })
+}
+
+// startLoadTest runs a load test in the background
+func startLoadTest(t *loadTest) tea.Cmd {
+	return tea.Batch(
+		func() tea.Msg {
+			t.run()
+			return loadDoneMsg{test: t}
+		},
+		loadTick(t),
+	)
+}
+
+// exportLoadTest writes JSON and CSV exports of a load test into reportsDir
+func exportLoadTest(t *loadTest) tea.Cmd {
+	return func() tea.Msg {
+		if err := os.MkdirAll(reportsDir, 0755); err != nil {
+			return errMsg{err}
+		}
+		stats, samples := t.stats(), t.recorded()
+		name := "load"
+		if slug := slugify(t.request.Name); slug != "" {
+			name += "-" + slug
+		}
+		base := filepath.Join(reportsDir, name+"-"+stats.Started.Format("20060102-150405"))
+		if err := writeLoadFile(base+".json", func(w io.Writer) error { return writeLoadJSON(w, stats, samples) }); err != nil {
+			return errMsg{err}
+		}
+		if err := writeLoadFile(base+".csv", func(w io.Writer) error { return writeLoadCSV(w, samples) }); err != nil {
+			return errMsg{err}
+		}
+		return reportsWrittenMsg(base + ".{json,csv}")
+	}
+}
+
+// loadCommand load tests a saved request and exits non-zero when requests
+// failed or got server errors
+func loadCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("load", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", requestsDir, "directory of saved requests")
+	envName := fs.String("env", "", "environment to run against")
+	jsonPath := fs.String("json", "", "write the statistics and samples as JSON to this file")
+	csvPath := fs.String("csv", "", "write the samples as CSV to this file")
+	opts := loadFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(stderr, "usage: whelm load [flags] NAME\n")
+		return 2
+	}
+
+	all, folders, err := readRequestTree(*dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	req, ok := findRequest(all, fs.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "error: no saved request named %q\n", fs.Arg(0))
+		return 1
+	}
+	req = inheritFolderSettings(req, folders)
+
+	var env Environment
+	if *envName != "" {
+		envs, err := readEnvironments()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		if env, ok = findEnvironment(envs, *envName); !ok {
+			fmt.Fprintf(stderr, "error: no environment named %q\n", *envName)
+			return 1
+		}
+	}
+	req = applyVariables(req, mergeVariables(req.Variables, env.Variables))
+
+	t := newLoadTest(req, *opts)
+	t.run()
+	stats, samples := t.stats(), t.recorded()
+	fmt.Fprint(stdout, formatLoadStats(stats))
+
+	if *jsonPath != "" {
+		if err := writeLoadFile(*jsonPath, func(w io.Writer) error { return writeLoadJSON(w, stats, samples) }); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	if *csvPath != "" {
+		if err := writeLoadFile(*csvPath, func(w io.Writer) error { return writeLoadCSV(w, samples) }); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	if stats.Failed() {
+		return 1
+	}
+	return 0
+}
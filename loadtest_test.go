@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestLoadTest tests that a load test sends the requested number of requests over reused connections
+func TestLoadTest(t *testing.T) {
+	var count, conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1)%10 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	test := newLoadTest(HTTPRequest{Name: "Ping", Method: "GET", URL: server.URL}, LoadOptions{Requests: 50, Concurrency: 5})
+	test.run()
+	stats := test.stats()
+
+	if !stats.Done || stats.Sent != 50 || count.Load() != 50 {
+		t.Errorf("Expected 50 requests, got %d sent and %d received", stats.Sent, count.Load())
+	}
+	if stats.Statuses[200] != 45 || stats.Statuses[503] != 5 || stats.Errors != 0 {
+		t.Errorf("Expected 45 × 200 and 5 × 503, got %v with %d errors", stats.Statuses, stats.Errors)
+	}
+	if !stats.Failed() {
+		t.Errorf("Expected server errors to fail the load test")
+	}
+	// A worker may dial just before another returns its connection to the pool
+	if n := conns.Load(); n > 10 {
+		t.Errorf("Expected connections to be reused, got %d for 50 requests", n)
+	}
+	if stats.Min > stats.P50 || stats.P50 > stats.P90 || stats.P90 > stats.P99 || stats.P99 > stats.Max {
+		t.Errorf("Expected ordered percentiles, got %+v", stats)
+	}
+	total := 0
+	for _, b := range stats.Histogram {
+		total += b.Count
+	}
+	if total != 50 {
+		t.Errorf("Expected the histogram to count 50 samples, got %d", total)
+	}
+	if out := formatLoadStats(stats); !strings.Contains(out, "Sent:       50/50") || !strings.Contains(out, "503 × 5") {
+		t.Errorf("Expected progress and statuses in the summary, got:\n%s", out)
+	}
+}
+
+// TestLoadTestLimits tests that the rate limit and duration bound a load test
+func TestLoadTestLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	test := newLoadTest(HTTPRequest{Method: "GET", URL: server.URL}, LoadOptions{Duration: 500 * time.Millisecond, Concurrency: 4, Rate: 20})
+	start := time.Now()
+	test.run()
+	elapsed := time.Since(start)
+	if elapsed < 500*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Expected the test to run for about 500ms, took %s", elapsed)
+	}
+	if sent := test.stats().Sent; sent < 5 || sent > 11 {
+		t.Errorf("Expected about 10 requests at 20 per second, got %d", sent)
+	}
+
+	test = newLoadTest(HTTPRequest{Method: "GET", URL: server.URL}, LoadOptions{Duration: time.Minute, Concurrency: 1, Rate: 10})
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		test.stop()
+	}()
+	start = time.Now()
+	test.run()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected stop to end the test, took %s", elapsed)
+	}
+	// A rate above a request a nanosecond must not panic
+	test = newLoadTest(HTTPRequest{Method: "GET", URL: server.URL}, LoadOptions{Requests: 3, Concurrency: 1, Rate: 2e9})
+	test.run()
+	if sent := test.stats().Sent; sent != 3 {
+		t.Errorf("Expected 3 requests at an unbounded rate, got %d", sent)
+	}
+}
+
+// TestLoadStatsHelpers tests percentiles, histogram buckets and option parsing
+func TestLoadStatsHelpers(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	if p := percentile(latencies, 50); p != 50*time.Millisecond {
+		t.Errorf("Expected p50 of 50ms, got %s", p)
+	}
+	if p := percentile(latencies, 99); p != 99*time.Millisecond {
+		t.Errorf("Expected p99 of 99ms, got %s", p)
+	}
+	if p := percentile(latencies[:1], 90); p != time.Millisecond {
+		t.Errorf("Expected the only sample, got %s", p)
+	}
+
+	buckets := latencyHistogram(latencies, 10)
+	if len(buckets) != 10 || buckets[0].From != time.Millisecond || buckets[9].Count == 0 {
+		t.Errorf("Expected 10 buckets from 1ms, got %+v", buckets)
+	}
+	if same := latencyHistogram([]time.Duration{time.Second, time.Second}, 10); len(same) != 1 || same[0].Count != 2 {
+		t.Errorf("Expected a single bucket for equal latencies, got %+v", same)
+	}
+
+	opts, err := parseLoadOptions("-n 500 -c 20 -rate 12.5 -d 1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LoadOptions{Requests: 500, Concurrency: 20, Rate: 12.5, Duration: time.Minute}
+	if opts != want {
+		t.Errorf("Expected %+v, got %+v", want, opts)
+	}
+	if again, _ := parseLoadOptions(opts.String()); again != opts {
+		t.Errorf("Expected String to round-trip, got %q", opts.String())
+	}
+	if _, err := parseLoadOptions("-n lots"); err == nil {
+		t.Errorf("Expected an error for an invalid count")
+	}
+}
+
+// TestLoadExport tests that samples are exported as JSON and CSV
+func TestLoadExport(t *testing.T) {
+	samples := []LoadSample{
+		{Start: 0, Latency: 12500 * time.Microsecond, Status: 200},
+		{Start: time.Millisecond, Latency: 3 * time.Millisecond, Error: "connection refused"},
+	}
+	stats := LoadStats{Method: "GET", URL: "http://x.test", Sent: 2, Errors: 1, Statuses: map[int]int{200: 1}}
+
+	var buf bytes.Buffer
+	if err := writeLoadJSON(&buf, stats, samples); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Sent     int            `json:"sent"`
+		Statuses map[string]int `json:"statuses"`
+		Samples  []LoadSample   `json:"samples"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Sent != 2 || report.Statuses["200"] != 1 || len(report.Samples) != 2 {
+		t.Errorf("Expected statistics and samples in the JSON export, got %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeLoadCSV(&buf, samples); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"start_ms", "latency_ms", "status", "error"},
+		{"0.000", "12.500", "200", ""},
+		{"1.000", "3.000", "", "connection refused"},
+	}
+	for i := range want {
+		if i >= len(rows) || strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("Expected row %d to be %v, got %v", i, want[i], rows)
+		}
+	}
+}
+
+// TestLoadTestModel tests that the load test screen is configured from the current request and shows live statistics
+func TestLoadTestModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	m := initialModel()
+	m.setRequest(HTTPRequest{Method: "GET", URL: server.URL})
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	m = updated.(model)
+	if m.state != stateLoadConfig || m.promptInput.Value() != "-n 100 -c 10" {
+		t.Fatalf("Expected the load options prompt, got state %d with %q", m.state, m.promptInput.Value())
+	}
+
+	m.promptInput.SetValue("-n 20 -c 2")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != stateLoadTest || m.loadTest == nil || cmd == nil {
+		t.Fatalf("Expected the load test to start")
+	}
+
+	m.loadTest.run()
+	updated, _ = m.Update(loadTickMsg{test: m.loadTest})
+	m = updated.(model)
+	if !m.loadStats.Done || m.loadStats.Sent != 20 {
+		t.Errorf("Expected 20 requests sent, got %+v", m.loadStats)
+	}
+	if !strings.Contains(m.View(), "p99") {
+		t.Errorf("Expected latency percentiles in the view")
+	}
+
+	// Ticks of an earlier test are ignored
+	stale := newLoadTest(HTTPRequest{Method: "GET", URL: server.URL}, LoadOptions{})
+	updated, _ = m.Update(loadTickMsg{test: stale})
+	if updated.(model).loadStats.Sent != 20 {
+		t.Errorf("Expected a stale tick to be ignored")
+	}
+}
@@ -1,23 +1,26 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
 )
 
 const (
@@ -27,6 +30,20 @@ const (
 	stateViewResponse
 	stateSaveRequest
 	stateLoadRequest
+	stateRunResults
+	stateRenameRequest
+	stateMoveRequest
+	stateConfirmDelete
+	stateConfirmOverwrite
+	stateHistory
+	stateDiff
+	stateReviewSnapshot
+	stateListenAddr
+	stateListen
+	stateLoadConfig
+	stateLoadTest
+	stateWatchConfig
+	stateCommandPalette
 )
 
 // HTTP methods
@@ -40,24 +57,26 @@ var httpMethods = []string{
 	"OPTIONS",
 }
 
-// Styles
-var (
-	titleStyle        = lipgloss.NewStyle().MarginLeft(2).Bold(true)
-	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
-	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
-	helpStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	urlInputStyle     = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("63"))
-	headerStyle       = lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("0")).Underline(false)
-	focusedInputStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("205"))
-)
-
 // HTTPRequest represents an HTTP request
 type HTTPRequest struct {
-	Name    string            `json:"name"`
-	Method  string            `json:"method"`
-	URL     string            `json:"url"`
-	Headers map[string]string `json:"headers"`
-	Body    string            `json:"body"`
+	ID         string            `json:"id,omitempty"`
+	Name       string            `json:"name"`
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	BodyFile   string            `json:"body_file,omitempty"`
+	Assertions []Assertion       `json:"assertions,omitempty"`
+	Extract    map[string]string `json:"extract,omitempty"`
+	Auth       *Auth             `json:"auth,omitempty"`
+	Variables  map[string]string `json:"variables,omitempty"`
+	Source     *RequestSource    `json:"source,omitempty"`
+	Spec       string            `json:"spec,omitempty"`
+	Ignore     []string          `json:"ignore,omitempty"`
+	Mock       *MockResponse     `json:"mock,omitempty"`
+	Folder     string            `json:"-"`
+	Path       string            `json:"-"`
+	Index      int               `json:"-"`
 }
 
 // HTTPResponse represents an HTTP response
@@ -67,6 +86,10 @@ type HTTPResponse struct {
 	Headers    map[string]string `json:"headers"`
 	Body       string            `json:"body"`
 	Error      string            `json:"error,omitempty"`
+	Duration   time.Duration     `json:"duration"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	Contract   *ContractResult   `json:"contract,omitempty"`
+	Timings    *Timings          `json:"timings,omitempty"`
 }
 
 // Model represents the application state
@@ -75,17 +98,53 @@ type model struct {
 	width          int
 	height         int
 	currentRequest HTTPRequest
+	loadedRequest  HTTPRequest
 	response       HTTPResponse
 	methodList     list.Model
 	urlInput       textinput.Model
 	bodyInput      textarea.Model
 	headerInput    textarea.Model
 	nameInput      textinput.Model
+	promptInput    textinput.Model
 	responseView   viewport.Model
 	spinner        spinner.Model
 	loading        bool
+	running        bool
+	splitRatio     float64
+	fullScreen     bool
+	tabs           []tab
+	activeTab      int
+	nextTabID      int
 	savedRequests  []HTTPRequest
+	folders        map[string]FolderConfig
+	expanded       map[string]bool
+	target         HTTPRequest
+	pending        HTTPRequest
+	trash          []trashEntry
 	requestList    list.Model
+	environments   []Environment
+	envIndex       int
+	variables      map[string]string
+	collection     CollectionResult
+	resultsTable   table.Model
+	history        []HistoryEntry
+	historyList    list.Model
+	diffBase       *HistoryEntry
+	diffView       viewport.Model
+	reviewIndex    int
+	listener       *webhookListener
+	listenAddr     string
+	captured       []CapturedRequest
+	capturedList   list.Model
+	loadTest       *loadTest
+	loadStats      LoadStats
+	watch          *watcher
+	keys           keyMap
+	palette        paletteItems
+	paletteMatches fuzzy.Matches
+	paletteCursor  int
+	paletteReturn  int
+	status         string
 	err            error
 }
 
@@ -98,9 +157,20 @@ func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.title }
 
 // Messages
-type responseMsg HTTPResponse
+
+// responseMsg carries the outcome of a request sent from a tab
+type responseMsg struct {
+	tab      int
+	request  HTTPRequest
+	response HTTPResponse
+	err      error
+}
+
 type errMsg struct{ error }
-type savedRequestsMsg []HTTPRequest
+type savedRequestsMsg struct {
+	requests []HTTPRequest
+	folders  map[string]FolderConfig
+}
 
 func (e errMsg) Error() string { return e.error.Error() }
 
@@ -110,7 +180,7 @@ func initialModel() model {
 	for _, method := range httpMethods {
 		methodItems = append(methodItems, item{title: method})
 	}
-	methodList := list.New(methodItems, list.NewDefaultDelegate(), 0, 0)
+	methodList := themedList(methodItems)
 	methodList.Title = "HTTP Method"
 	methodList.SetShowStatusBar(false)
 	methodList.SetFilteringEnabled(false)
@@ -138,6 +208,10 @@ func initialModel() model {
 	nameInput.Focus()
 	nameInput.Width = 40
 
+	// Initialize prompt input for renaming and moving requests
+	promptInput := textinput.New()
+	promptInput.Width = 40
+
 	// Initialize response view
 	responseView := viewport.New(80, 20)
 	responseView.SetContent("")
@@ -145,14 +219,40 @@ func initialModel() model {
 	// Initialize spinner
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	s.Style = lipgloss.NewStyle().Foreground(theme.Focus)
 
 	// Initialize request list
-	requestList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	requestList := themedList([]list.Item{})
 	requestList.Title = "Saved Requests"
 	requestList.SetShowStatusBar(false)
 	requestList.SetShowHelp(true)
 
+	// Initialize history list
+	historyList := themedList([]list.Item{})
+	historyList.Title = "History"
+	historyList.SetShowStatusBar(false)
+	historyList.SetShowHelp(true)
+
+	// Initialize webhook list
+	capturedList := themedList([]list.Item{})
+	capturedList.Title = "Webhooks"
+	capturedList.SetShowStatusBar(false)
+	capturedList.SetShowHelp(true)
+
+	// Initialize collection results table
+	resultsTable := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "#", Width: 3},
+			{Title: "Name", Width: 24},
+			{Title: "Method", Width: 7},
+			{Title: "Status", Width: 6},
+			{Title: "Time", Width: 8},
+			{Title: "Result", Width: 30},
+		}),
+		table.WithFocused(true),
+		table.WithStyles(themedTableStyles()),
+	)
+
 	return model{
 		state: stateMain,
 		currentRequest: HTTPRequest{
@@ -166,35 +266,167 @@ func initialModel() model {
 		bodyInput:     bodyInput,
 		headerInput:   headerInput,
 		nameInput:     nameInput,
+		promptInput:   promptInput,
 		responseView:  responseView,
 		spinner:       s,
 		loading:       false,
+		splitRatio:    defaultSplitRatio,
+		keys:          defaultKeyMap(),
+		tabs:          []tab{{id: 1, state: stateMain}},
+		nextTabID:     1,
 		savedRequests: []HTTPRequest{},
+		folders:       map[string]FolderConfig{},
+		expanded:      make(map[string]bool),
 		requestList:   requestList,
+		envIndex:      -1,
+		variables:     make(map[string]string),
+		resultsTable:  resultsTable,
+		historyList:   historyList,
+		diffView:      viewport.New(80, 20),
+		capturedList:  capturedList,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(
+	cmds := []tea.Cmd{
 		loadSavedRequests,
+		loadEnvironments,
 		textinput.Blink,
 		textarea.Blink,
-	)
+	}
+	if m.listener != nil {
+		cmds = append(cmds, m.listener.start(m.listenAddr))
+	}
+	return tea.Batch(cmds...)
 }
 
 func isListFocused(m model) bool {
 	return !m.urlInput.Focused() && !m.headerInput.Focused() && !m.bodyInput.Focused()
 }
 
+// environment returns the active environment, or an empty one when none is selected
+func (m model) environment() Environment {
+	if m.envIndex < 0 || m.envIndex >= len(m.environments) {
+		return Environment{}
+	}
+	return m.environments[m.envIndex]
+}
+
+// resolvedRequest returns the current request with folder settings applied
+// and folder, environment and extracted variables substituted
+func (m model) resolvedRequest() HTTPRequest {
+	req := inheritFolderSettings(m.currentRequest, m.folders)
+	return applyVariables(req, mergeVariables(req.Variables, m.environment().Variables, m.variables))
+}
+
+// setRequest makes req the current request and fills the editor inputs from it
+func (m *model) setRequest(req HTTPRequest) {
+	m.currentRequest = req
+	m.loadedRequest = req
+	m.urlInput.SetValue(req.URL)
+	m.headerInput.SetValue(formatHeaders(req.Headers))
+	m.bodyInput.SetValue(req.Body)
+	m.methodList.Select(indexOf(req.Method, httpMethods))
+}
+
+// showDiff opens the diff view comparing two exchanges, older first. Paths
+// ignored by either request are left out.
+func (m *model) showDiff(a, b HistoryEntry) {
+	if b.Time.Before(a.Time) {
+		a, b = b, a
+	}
+	ignore := append(append([]string(nil), a.Request.Ignore...), b.Request.Ignore...)
+	d := diffResponses(a.Response, b.Response, ignore)
+	m.diffView.SetContent(diffTitle(a, b) + "\n\n" + formatDiff(d, ignore))
+	m.diffView.GotoTop()
+	m.status = ""
+	m.state = stateDiff
+}
+
+// reviewSnapshot shows the changes to the snapshot of a run result for accepting or rejecting
+func (m *model) reviewSnapshot(i int) {
+	r := m.collection.Results[i]
+	m.reviewIndex = i
+	m.resultsTable.SetCursor(i)
+	m.diffView.SetContent(fmt.Sprintf("%s %s %s\n%s\n\n", r.Request.Name, r.Request.Method, r.Request.URL, r.Snapshot.Path) +
+		formatDiff(*r.Snapshot.Diff, r.Request.Ignore))
+	m.diffView.GotoTop()
+	m.status = ""
+	m.state = stateReviewSnapshot
+}
+
+// isModified reports whether the current request differs from the saved one it was loaded from
+func (m model) isModified() bool {
+	return m.loadedRequest.Path != "" && !requestsEqual(m.currentRequest, m.loadedRequest)
+}
+
+// modifiedMarker returns the unsaved changes indicator shown next to titles
+func (m model) modifiedMarker() string {
+	if !m.isModified() {
+		return ""
+	}
+	return warningStyle.Render(" ● unsaved changes")
+}
+
+// folderRequests returns the saved requests in a folder and its subfolders with folder settings applied
+func (m model) folderRequests(folder string) []HTTPRequest {
+	var requests []HTTPRequest
+	for _, req := range m.savedRequests {
+		if inFolder(req.Folder, folder) {
+			requests = append(requests, inheritFolderSettings(req, m.folders))
+		}
+	}
+	return requests
+}
+
+// selectedTreeItem returns the row selected in the saved request tree
+func (m model) selectedTreeItem() (treeItem, bool) {
+	t, ok := m.requestList.SelectedItem().(treeItem)
+	return t, ok
+}
+
+// refreshRequestTree rebuilds the rows of the saved request tree
+func (m *model) refreshRequestTree() {
+	items := []list.Item{}
+	for _, t := range buildRequestTree(m.savedRequests, m.folders, m.expanded) {
+		items = append(items, t)
+	}
+	m.requestList.SetItems(items)
+}
+
+// Update handles a message, then fits the panes to what they now show, as
+// the window, the watch panel, the status line and responses change size
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	if m, ok := updated.(model); ok && affectsLayout(msg) {
+		m.resize()
+		return m, cmd
+	}
+	return updated, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// Handle tab navigation specially to ensure it works correctly
-		if m.state == stateEditRequest && (msg.String() == "ctrl+n" || msg.Type == tea.KeyCtrlN || msg.String() == "tab" || msg.Type == tea.KeyTab) {
-			// If tab is pressed, handle field navigation directly instead of passing to component
+		if m.state == stateCommandPalette {
+			return m.handlePaletteKey(msg)
+		}
+		if key.Matches(msg, m.keys.Palette.Open) && canOpenPalette(m.state) && !m.typing(msg) {
+			return m, m.openPalette()
+		}
+
+		if (m.state == stateEditRequest || m.state == stateViewResponse) && !m.typing(msg) {
+			if cmd, ok := m.handleLayoutKey(msg); ok {
+				return m, cmd
+			}
+		}
+
+		// Handle field navigation specially to ensure it works correctly
+		if m.state == stateEditRequest && key.Matches(msg, m.keys.Editor.NextField) {
+			// Handle field navigation directly instead of passing the key to the component
 			if m.urlInput.Focused() {
 				// Navigate from URL to Method List
 				m.urlInput.Blur()
@@ -220,88 +452,515 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch m.state {
 		case stateMain:
-			switch msg.String() {
-			case "q", "ctrl+c", "esc":
+			if cmd, ok := m.handleTabKey(msg); ok {
+				return m, cmd
+			}
+			switch {
+			case key.Matches(msg, m.keys.Main.Quit):
 				return m, tea.Quit
-			case "e", "n":
+			case key.Matches(msg, m.keys.Main.Edit):
 				m.state = stateEditRequest
 				m.urlInput.Focus()
 				return m, nil
-			case "l":
+			case key.Matches(msg, m.keys.Main.Load):
 				m.state = stateLoadRequest
 				return m, nil
-			case "enter":
+			case key.Matches(msg, m.keys.Main.History):
+				m.state = stateHistory
+				m.status = ""
+				return m, loadHistory
+			case key.Matches(msg, m.keys.Main.Webhooks):
+				// Show the webhook catcher, asking where to listen the first time
+				if m.listener != nil {
+					m.state = stateListen
+					return m, nil
+				}
+				m.promptInput.SetValue(defaultListenAddr)
+				m.promptInput.Focus()
+				m.state = stateListenAddr
+				return m, textinput.Blink
+			case key.Matches(msg, m.keys.Main.LoadTest):
+				// Load test the current request
+				if m.currentRequest.URL != "" {
+					m.promptInput.SetValue(LoadOptions{Requests: defaultLoadRequests, Concurrency: 10}.String())
+					m.promptInput.Focus()
+					m.state = stateLoadConfig
+					return m, textinput.Blink
+				}
+			case key.Matches(msg, m.keys.Main.Environment):
+				// Cycle through environments, including none
+				m.envIndex++
+				if m.envIndex >= len(m.environments) {
+					m.envIndex = -1
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Main.Run, m.keys.Main.RunSnapshots):
+				if len(m.savedRequests) > 0 {
+					opts := RunOptions{Environment: m.environment()}
+					if key.Matches(msg, m.keys.Main.RunSnapshots) {
+						opts.Snapshots = snapshotCheck
+					}
+					m.running = true
+					return m, tea.Batch(
+						m.spinner.Tick,
+						runCollectionCmd(filepath.Base(requestsDir), m.folderRequests(""), opts),
+					)
+				}
+			case key.Matches(msg, m.keys.Main.Send):
 				if m.currentRequest.URL != "" {
 					m.loading = true
 					return m, tea.Batch(
 						m.spinner.Tick,
-						sendRequest(m.currentRequest),
+						sendRequest(m.tabs[m.activeTab].id, m.resolvedRequest()),
 					)
 				}
 			}
 
 		case stateEditRequest:
-			switch msg.String() {
-			case "esc":
+			switch {
+			case key.Matches(msg, m.keys.Editor.Back):
 				m.state = stateMain
 				return m, nil
-			case "enter":
-				// Enter key when method list is active (nothing else is focused)
-				if !m.urlInput.Focused() && !m.headerInput.Focused() && !m.bodyInput.Focused() {
-					m.currentRequest.Method = httpMethods[m.methodList.Index()]
-					m.headerInput.Focus()
-					return m, nil
-				}
-			case "s":
-				if msg.Alt {
-					m.state = stateSaveRequest
-					m.nameInput.Focus()
-					return m, nil
+			case msg.Type == tea.KeyEnter && isListFocused(m):
+				// Enter picks the method when the method list is active (nothing else is focused)
+				m.currentRequest.Method = httpMethods[m.methodList.Index()]
+				m.headerInput.Focus()
+				return m, nil
+			case key.Matches(msg, m.keys.Editor.External) && !m.typing(msg):
+				return m, m.openInEditor(m.editorTarget())
+			case key.Matches(msg, m.keys.Editor.Save) && !m.typing(msg):
+				m.state = stateSaveRequest
+				m.nameInput.Focus()
+				return m, nil
+			case key.Matches(msg, m.keys.Editor.Send) && !m.typing(msg):
+				// With the response beside the editor, editing can go on while it is sent
+				if m.layout() == layoutFull {
+					m.state = stateMain
 				}
-			case "ctrl+s":
-				m.state = stateMain
 				m.loading = true
 				return m, tea.Batch(
 					m.spinner.Tick,
-					sendRequest(m.currentRequest),
+					sendRequest(m.tabs[m.activeTab].id, m.resolvedRequest()),
 				)
 			}
 
 		case stateViewResponse:
-			switch msg.String() {
-			case "esc", "q":
+			if cmd, ok := m.handleTabKey(msg); ok {
+				return m, cmd
+			}
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				// Leaving the response stops watching it
+				m.watch = nil
 				m.state = stateMain
 				return m, nil
-			case "e":
+			case key.Matches(msg, m.keys.Response.Watch):
+				if m.watch != nil && m.watch.running {
+					m.watch.running = false
+					return m, nil
+				}
+				m.promptInput.SetValue(defaultWatchSpec)
+				m.promptInput.Focus()
+				m.state = stateWatchConfig
+				return m, textinput.Blink
+			case key.Matches(msg, m.keys.Response.Edit):
 				m.state = stateEditRequest
 				return m, nil
+			case key.Matches(msg, m.keys.Response.External):
+				// The response is only viewed; edits to it are dropped
+				return m, m.openInEditor(editResponse)
+			case key.Matches(msg, m.keys.Response.Export):
+				// Export this exchange as HAR
+				entry := HistoryEntry{Time: time.Now().Add(-m.response.Duration), Request: m.resolvedRequest(), Response: m.response}
+				name := m.currentRequest.Name
+				if name == "" {
+					name = "exchange"
+				}
+				return m, exportHAR(name, []HistoryEntry{entry})
+			case key.Matches(msg, m.keys.Response.Compare):
+				// Pick an exchange from history to compare this response with
+				entry := HistoryEntry{Time: time.Now().Add(-m.response.Duration), Request: m.resolvedRequest(), Response: m.response}
+				m.diffBase = &entry
+				m.state = stateHistory
+				m.status = "Select an exchange to compare with the current response"
+				return m, loadHistory
+			}
+
+		case stateHistory:
+			// Let the list handle keys while the filter is being typed
+			if m.historyList.SettingFilter() {
+				break
+			}
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				m.state = stateMain
+				return m, nil
+			case key.Matches(msg, m.keys.History.Open):
+				// Open the exchange; it can be edited and re-sent from there
+				if h, ok := m.historyList.SelectedItem().(historyItem); ok {
+					m.setRequest(h.entry.Request)
+					m.response = h.entry.Response
+					m.responseView.SetContent(formatResponse(h.entry.Request, h.entry.Response))
+					m.status = ""
+					m.state = stateViewResponse
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.History.Export):
+				// Export the whole history, oldest first
+				entries := make([]HistoryEntry, len(m.history))
+				for i, e := range m.history {
+					entries[len(entries)-1-i] = e
+				}
+				return m, exportHAR("history", entries)
+			case key.Matches(msg, m.keys.History.Mark):
+				// Mark the exchange to compare others with
+				if h, ok := m.historyList.SelectedItem().(historyItem); ok {
+					entry := h.entry
+					m.diffBase = &entry
+					m.status = "Marked " + h.Title() + " for comparison"
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.History.Compare):
+				h, ok := m.historyList.SelectedItem().(historyItem)
+				if !ok {
+					return m, nil
+				}
+				if m.diffBase == nil {
+					m.status = "Mark an exchange with " + m.keys.History.Mark.Help().Key + " first"
+					return m, nil
+				}
+				m.showDiff(*m.diffBase, h.entry)
+				return m, nil
+			}
+
+		case stateDiff:
+			if key.Matches(msg, m.keys.Back) {
+				m.state = stateHistory
+				return m, nil
 			}
 
 		case stateSaveRequest:
-			switch msg.String() {
-			case "esc":
+			switch {
+			case key.Matches(msg, m.keys.Cancel):
 				m.state = stateEditRequest
 				return m, nil
-			case "enter":
-				if m.nameInput.Value() != "" {
-					m.currentRequest.Name = m.nameInput.Value()
+			case key.Matches(msg, m.keys.Confirm):
+				name := strings.TrimSpace(m.nameInput.Value())
+				if err := validateRequestName(name); err != nil {
+					m.err = err
+					return m, nil
+				}
+
+				// Saving under a new name creates a new request rather than renaming the loaded one
+				req := m.currentRequest
+				if m.loadedRequest.Path == "" || name != m.loadedRequest.Name {
+					req.ID = ""
+					req.Path = ""
+				}
+				req.Name = name
+
+				// Ask before replacing a different request with the same name
+				if existing, ok := findByName(m.savedRequests, req.Folder, name); ok && !sameRequest(existing, req) {
+					m.target = existing
+					m.pending = req
+					m.state = stateConfirmOverwrite
+					return m, nil
+				}
+
+				m.err = nil
+				m.state = stateEditRequest
+				m.nameInput.Reset()
+				return m, saveRequest(req)
+			}
+
+		case stateConfirmOverwrite:
+			switch {
+			case key.Matches(msg, m.keys.Yes):
+				req := m.pending
+				req.ID = m.target.ID
+				req.Path = m.target.Path
+				req.Index = m.target.Index
+				m.err = nil
+				m.state = stateEditRequest
+				m.nameInput.Reset()
+				return m, saveRequest(req)
+			case key.Matches(msg, m.keys.No):
+				m.state = stateSaveRequest
+				return m, nil
+			}
+			return m, nil
+
+		case stateRenameRequest, stateMoveRequest:
+			switch {
+			case key.Matches(msg, m.keys.Cancel):
+				m.promptInput.Blur()
+				m.state = stateLoadRequest
+				return m, nil
+			case key.Matches(msg, m.keys.Confirm):
+				value := strings.TrimSpace(m.promptInput.Value())
+				m.promptInput.Blur()
+				if m.state == stateRenameRequest {
+					m.state = stateLoadRequest
+					if value == "" || value == m.target.Name {
+						return m, nil
+					}
+					return m, renameRequest(m.target, value)
+				}
+				m.state = stateLoadRequest
+				return m, moveRequest(m.target, value)
+			}
+
+		case stateConfirmDelete:
+			switch {
+			case key.Matches(msg, m.keys.Yes):
+				m.state = stateLoadRequest
+				return m, trashRequest(m.target)
+			case key.Matches(msg, m.keys.No):
+				m.state = stateLoadRequest
+				return m, nil
+			}
+			return m, nil
+
+		case stateRunResults:
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				m.state = stateMain
+				return m, nil
+			case key.Matches(msg, m.keys.Results.Open):
+				// Open the selected result in the response view
+				i := m.resultsTable.Cursor()
+				if i >= 0 && i < len(m.collection.Results) && !m.collection.Results[i].Skipped {
+					r := m.collection.Results[i]
+					m.currentRequest = r.Request
+					m.response = r.Response
+					m.responseView.SetContent(formatResponse(r.Request, r.Response))
+					m.state = stateViewResponse
+					return m, nil
+				}
+			case key.Matches(msg, m.keys.Results.Review):
+				// Review changed snapshots, starting at the selected row
+				i := nextChangedSnapshot(m.collection.Results, m.resultsTable.Cursor())
+				if i < 0 {
+					i = nextChangedSnapshot(m.collection.Results, 0)
+				}
+				if i < 0 {
+					m.status = "No changed snapshots to review"
+					return m, nil
+				}
+				m.reviewSnapshot(i)
+				return m, nil
+			}
+
+		case stateListenAddr:
+			switch {
+			case key.Matches(msg, m.keys.Cancel):
+				m.promptInput.Blur()
+				m.err = nil
+				m.state = stateMain
+				return m, nil
+			case key.Matches(msg, m.keys.Confirm):
+				addr, resp, err := parseListenSpec(m.promptInput.Value())
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.promptInput.Blur()
+				m.err = nil
+				m.listener = newWebhookListener(nil, resp)
+				m.listenAddr = addr
+				m.state = stateListen
+				return m, m.listener.start(m.listenAddr)
+			}
+
+		case stateWatchConfig:
+			switch {
+			case key.Matches(msg, m.keys.Cancel):
+				m.promptInput.Blur()
+				m.state = stateViewResponse
+				return m, nil
+			case key.Matches(msg, m.keys.Confirm):
+				w, err := parseWatchSpec(m.promptInput.Value())
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.promptInput.Blur()
+				m.err = nil
+				req := m.resolvedRequest()
+				w.ignore = req.Ignore
+				// The first send is compared with the response on screen
+				if m.response.StatusCode != 0 {
+					resp := m.response
+					w.last = &resp
+				}
+				m.watch = w
+				m.state = stateViewResponse
+				return m, watchSend(w, req)
+			}
+
+		case stateLoadConfig:
+			switch {
+			case key.Matches(msg, m.keys.Cancel):
+				m.promptInput.Blur()
+				m.state = stateMain
+				return m, nil
+			case key.Matches(msg, m.keys.Confirm):
+				opts, err := parseLoadOptions(m.promptInput.Value())
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.promptInput.Blur()
+				m.err = nil
+				m.status = ""
+				m.loadTest = newLoadTest(m.resolvedRequest(), opts)
+				m.loadStats = LoadStats{}
+				m.state = stateLoadTest
+				return m, startLoadTest(m.loadTest)
+			}
+
+		case stateLoadTest:
+			switch {
+			case key.Matches(msg, m.keys.LoadTest.Stop):
+				m.loadTest.stop()
+				return m, nil
+			case key.Matches(msg, m.keys.LoadTest.Export):
+				return m, exportLoadTest(m.loadTest)
+			case key.Matches(msg, m.keys.Back):
+				// Leaving stops the test
+				m.loadTest.stop()
+				m.state = stateMain
+				return m, nil
+			}
+			return m, nil
+
+		case stateListen:
+			// Let the list handle keys while the filter is being typed
+			if m.capturedList.SettingFilter() {
+				break
+			}
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				// The catcher keeps listening in the background
+				m.state = stateMain
+				return m, nil
+			case key.Matches(msg, m.keys.Listen.Replay):
+				// Open the captured request in the editor to replay it
+				if c, ok := m.capturedList.SelectedItem().(capturedItem); ok {
+					m.setRequest(c.capture.request())
 					m.state = stateEditRequest
-					m.nameInput.Reset()
-					return m, saveRequest(m.currentRequest)
+					m.urlInput.Focus()
+					return m, textinput.Blink
 				}
+				return m, nil
+			case key.Matches(msg, m.keys.Listen.Clear):
+				m.captured = nil
+				m.capturedList.SetItems(nil)
+				return m, nil
+			case key.Matches(msg, m.keys.Listen.Stop):
+				if m.listener != nil {
+					m.listener.stop()
+					m.listener = nil
+				}
+				m.status = "Stopped listening"
+				m.state = stateMain
+				return m, nil
+			}
+
+		case stateReviewSnapshot:
+			r := m.collection.Results[m.reviewIndex]
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				m.state = stateRunResults
+				return m, nil
+			case key.Matches(msg, m.keys.Review.Accept):
+				return m, acceptSnapshot(m.reviewIndex, *r.Snapshot)
+			case key.Matches(msg, m.keys.Review.Reject):
+				return m, func() tea.Msg { return snapshotReviewedMsg{index: m.reviewIndex, status: snapshotRejected} }
 			}
 
 		case stateLoadRequest:
-			switch msg.String() {
-			case "esc":
+			// Let the list handle keys while the filter is being typed
+			if m.requestList.SettingFilter() {
+				break
+			}
+			t, selected := m.selectedTreeItem()
+			switch {
+			case key.Matches(msg, m.keys.Requests.Back):
 				m.state = stateMain
 				return m, nil
-			case "enter":
-				if len(m.savedRequests) > 0 && m.requestList.Index() >= 0 {
-					m.currentRequest = m.savedRequests[m.requestList.Index()]
-					m.state = stateMain
+			case key.Matches(msg, m.keys.Requests.Open):
+				if !selected {
+					break
+				}
+				if t.isFolder() {
+					m.expanded[t.folder] = !m.expanded[t.folder]
+					m.refreshRequestTree()
+					return m, nil
+				}
+				m.setRequest(m.savedRequests[t.request])
+				m.state = stateMain
+				return m, nil
+			case key.Matches(msg, m.keys.Requests.Rename):
+				if selected && !t.isFolder() {
+					m.target = m.savedRequests[t.request]
+					m.promptInput.SetValue(m.target.Name)
+					m.promptInput.Focus()
+					m.state = stateRenameRequest
+					return m, textinput.Blink
+				}
+			case key.Matches(msg, m.keys.Requests.Move):
+				if selected && !t.isFolder() {
+					m.target = m.savedRequests[t.request]
+					m.promptInput.SetValue(m.target.Folder)
+					m.promptInput.Focus()
+					m.state = stateMoveRequest
+					return m, textinput.Blink
+				}
+			case key.Matches(msg, m.keys.Requests.Duplicate):
+				if selected && !t.isFolder() {
+					return m, duplicateRequest(m.savedRequests[t.request], m.savedRequests)
+				}
+			case key.Matches(msg, m.keys.Requests.Delete):
+				if selected && !t.isFolder() {
+					m.target = m.savedRequests[t.request]
+					m.state = stateConfirmDelete
 					return m, nil
 				}
+			case key.Matches(msg, m.keys.Requests.Undo):
+				// Undo the most recent delete
+				if len(m.trash) > 0 {
+					entry := m.trash[len(m.trash)-1]
+					m.trash = m.trash[:len(m.trash)-1]
+					return m, restoreRequest(entry)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Requests.Expand):
+				if selected && t.isFolder() && !m.expanded[t.folder] {
+					m.expanded[t.folder] = true
+					m.refreshRequestTree()
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Requests.Collapse):
+				if selected && t.isFolder() && m.expanded[t.folder] {
+					m.expanded[t.folder] = false
+					m.refreshRequestTree()
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Requests.Run):
+				// Run the selected folder, or the folder containing the selected request
+				if selected {
+					m.running = true
+					m.state = stateMain
+					name := t.folder
+					if name == "" {
+						name = filepath.Base(requestsDir)
+					}
+					return m, tea.Batch(
+						m.spinner.Tick,
+						runCollectionCmd(name, m.folderRequests(t.folder), RunOptions{Environment: m.environment()}),
+					)
+				}
 			}
 		}
 
@@ -311,77 +970,240 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.methodList.SetSize(30, 10)
 		m.requestList.SetSize(msg.Width, msg.Height-4)
+		m.historyList.SetSize(msg.Width, msg.Height-4)
+		m.capturedList.SetSize(msg.Width, msg.Height-6)
+		m.diffView.Width = msg.Width
+		m.diffView.Height = msg.Height - 6
 
-		m.responseView.Width = msg.Width
-		m.responseView.Height = msg.Height - 4
-
-		m.bodyInput.SetWidth(msg.Width - 4)
-		m.headerInput.SetWidth(msg.Width - 4)
+		m.resultsTable.SetHeight(msg.Height - 8)
 
 	case responseMsg:
-		m.loading = false
-		m.response = HTTPResponse(msg)
-		m.state = stateViewResponse
+		// Responses for closed tabs are dropped
+		i := m.tabIndex(msg.tab)
+		if i < 0 {
+			return m, nil
+		}
+		cmd := m.inTab(i, func() tea.Cmd {
+			m.loading = false
+			if msg.err != nil {
+				m.err = msg.err
+				return nil
+			}
+			m.response = msg.response
+			if m.layout() == layoutFull || m.state != stateEditRequest {
+				m.state = stateViewResponse
+			}
 
-		// Format response content to include request details
-		content := fmt.Sprintf("Request:\n%s %s\n\n",
-			lipgloss.NewStyle().Bold(true).Render(m.currentRequest.Method),
-			m.currentRequest.URL)
+			m.responseView.SetContent(formatResponse(m.currentRequest, m.response))
+			m.responseView.GotoTop()
+			m.status = ""
 
-		// Add request headers
-		if len(m.currentRequest.Headers) > 0 {
-			content += "Request Headers:\n"
-			for k, v := range m.currentRequest.Headers {
-				content += fmt.Sprintf("%s: %s\n", k, v)
+			// Keep extracted values for subsequent requests
+			extracted, err := extractVariables(msg.request.Extract, m.response)
+			for k, v := range extracted {
+				m.variables[k] = v
 			}
-			content += "\n"
+			m.err = err
+			return recordHistory(msg.request, m.response)
+		})
+		return m, cmd
+
+	case editorClosedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
 		}
+		switch msg.target {
+		case editBody:
+			m.bodyInput.SetValue(msg.content)
+			m.currentRequest.Body = msg.content
+		case editHeaders:
+			m.headerInput.SetValue(msg.content)
+			m.currentRequest.Headers = parseHeaders(msg.content)
+		}
+		m.err = nil
+		return m, nil
 
-		// Add request body if present
-		if m.currentRequest.Body != "" {
-			content += "Request Body:\n"
-			content += m.currentRequest.Body
-			content += "\n\n"
+	case historyMsg:
+		m.history = []HistoryEntry(msg)
+		items := []list.Item{}
+		for _, e := range m.history {
+			items = append(items, historyItem{entry: e})
 		}
+		m.historyList.SetItems(items)
+		if m.state == stateCommandPalette {
+			m.palette = m.buildPalette()
+			m.filterPalette()
+		}
+		return m, nil
 
-		// Add response details
-		content += fmt.Sprintf("Response Status: %d %s\n\n", m.response.StatusCode, m.response.Status)
+	case harExportedMsg:
+		m.status = fmt.Sprintf("Exported to %s", string(msg))
+		return m, nil
 
-		if len(m.response.Headers) > 0 {
-			content += "Response Headers:\n"
-			for k, v := range m.response.Headers {
-				content += fmt.Sprintf("%s: %s\n", k, v)
+	case collectionResultMsg:
+		m.running = false
+		m.collection = CollectionResult(msg)
+		m.resultsTable.SetRows(collectionRows(m.collection))
+		m.resultsTable.SetCursor(0)
+		m.state = stateRunResults
+		return m, writeReports(m.collection)
+
+	case watchResponseMsg:
+		// Responses of a watch that was stopped or replaced are dropped
+		i := m.watchTab(msg.watch)
+		if i < 0 || !msg.watch.running {
+			return m, nil
+		}
+		cmd := m.inTab(i, func() tea.Cmd {
+			msg.watch.record(msg.resp, msg.err)
+			if msg.err == nil {
+				m.response = msg.resp
+				m.responseView.SetContent(formatResponse(m.currentRequest, m.response))
+			}
+			if !msg.watch.running {
+				return nil
 			}
-			content += "\n"
+			return watchTick(msg.watch)
+		})
+		return m, cmd
+
+	case watchTickMsg:
+		i := m.watchTab(msg.watch)
+		if i < 0 || !msg.watch.running {
+			return m, nil
+		}
+		cmd := m.inTab(i, func() tea.Cmd {
+			return watchSend(msg.watch, m.resolvedRequest())
+		})
+		return m, cmd
+
+	case loadTickMsg:
+		// Ticks of a test that was replaced are dropped
+		if msg.test != m.loadTest {
+			return m, nil
 		}
+		m.loadStats = msg.test.stats()
+		if m.loadStats.Done {
+			return m, nil
+		}
+		return m, loadTick(msg.test)
 
-		content += "Response Body:\n" + m.response.Body
+	case loadDoneMsg:
+		if msg.test == m.loadTest {
+			m.loadStats = msg.test.stats()
+		}
+		return m, nil
 
-		m.responseView.SetContent(content)
+	case listenStartedMsg:
+		m.status = "Listening on http://" + msg.listener.addr
+		return m, msg.listener.wait
+
+	case listenFailedMsg:
+		// Drop the dead listener and ask for another address
+		if msg.listener != m.listener {
+			return m, nil
+		}
+		m.err = msg.err
+		m.promptInput.SetValue(formatListenSpec(m.listenAddr, m.listener.fallback))
+		m.listener = nil
+		if m.state != stateListen {
+			return m, nil
+		}
+		m.promptInput.Focus()
+		m.state = stateListenAddr
+		return m, textinput.Blink
+
+	case capturedMsg:
+		m.captured = append([]CapturedRequest{CapturedRequest(msg)}, m.captured...)
+		if len(m.captured) > maxCaptured {
+			m.captured = m.captured[:maxCaptured]
+		}
+		items := make([]list.Item, len(m.captured))
+		for i, c := range m.captured {
+			items[i] = capturedItem{capture: c}
+		}
+		m.capturedList.SetItems(items)
+		if m.listener == nil {
+			return m, nil
+		}
+		return m, m.listener.wait
+
+	case snapshotReviewedMsg:
+		// Record the decision and move on to the next changed snapshot
+		if s := m.collection.Results[msg.index].Snapshot; s != nil {
+			s.Status = msg.status
+		}
+		m.resultsTable.SetRows(collectionRows(m.collection))
+		next := nextChangedSnapshot(m.collection.Results, msg.index+1)
+		if next < 0 {
+			next = nextChangedSnapshot(m.collection.Results, 0)
+		}
+		if next < 0 {
+			m.status = "All changed snapshots reviewed"
+			m.state = stateRunResults
+			return m, nil
+		}
+		m.reviewSnapshot(next)
 		return m, nil
 
-	case savedRequestsMsg:
-		m.savedRequests = []HTTPRequest(msg)
+	case reportsWrittenMsg:
+		m.status = fmt.Sprintf("Reports written to %s", string(msg))
+		return m, nil
 
-		// Update request list items
-		items := []list.Item{}
-		for _, req := range m.savedRequests {
-			items = append(items, item{
-				title: req.Name,
-				desc:  fmt.Sprintf("%s %s", req.Method, req.URL),
-			})
+	case environmentsMsg:
+		m.environments = []Environment(msg)
+		if m.envIndex >= len(m.environments) {
+			m.envIndex = -1
 		}
-		m.requestList.SetItems(items)
+		return m, nil
+
+	case requestMovedMsg:
+		// Follow the current request if it was the one renamed or moved
+		if msg.from != "" && m.currentRequest.Path == msg.from && m.currentRequest.Index == msg.request.Index {
+			m.currentRequest.Name = msg.request.Name
+			m.currentRequest.Folder = msg.request.Folder
+			m.currentRequest.Path = msg.request.Path
+			m.loadedRequest.Name = msg.request.Name
+			m.loadedRequest.Folder = msg.request.Folder
+			m.loadedRequest.Path = msg.request.Path
+		}
+		m.err = nil
+		return m, loadSavedRequests
+
+	case requestSavedMsg:
+		m.currentRequest.ID = msg.ID
+		m.currentRequest.Name = msg.Name
+		m.currentRequest.Path = msg.Path
+		m.loadedRequest = HTTPRequest(msg)
+		m.err = nil
+		return m, loadSavedRequests
+
+	case requestTrashedMsg:
+		m.trash = append(m.trash, trashEntry(msg))
+		// The current request no longer has a saved copy
+		if m.currentRequest.Path == msg.request.Path {
+			m.loadedRequest = HTTPRequest{}
+		}
+		m.err = nil
+		return m, loadSavedRequests
+
+	case savedRequestsMsg:
+		m.savedRequests = msg.requests
+		m.folders = msg.folders
+
+		// Update request tree items
+		m.refreshRequestTree()
 
 		return m, nil
 
 	case errMsg:
-		m.loading = false
+		m.running = false
 		m.err = msg
 		return m, nil
 
 	case spinner.TickMsg:
-		if m.loading {
+		if m.loading || m.running {
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
 		}
@@ -392,8 +1214,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case stateEditRequest:
 		// Skip component updates for navigation keys to avoid them being consumed by the components
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
-			// Don't pass Tab or Ctrl+N to components to prevent them from capturing these keys
-			if keyMsg.String() == "ctrl+n" || keyMsg.Type == tea.KeyCtrlN || keyMsg.String() == "tab" || keyMsg.Type == tea.KeyTab {
+			// Don't pass the next field keys to components to prevent them from capturing these keys
+			if key.Matches(keyMsg, m.keys.Editor.NextField) {
 				return m, nil
 			}
 		}
@@ -432,6 +1254,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.nameInput, cmd = m.nameInput.Update(msg)
 		cmds = append(cmds, cmd)
 
+	case stateRenameRequest, stateMoveRequest, stateListenAddr, stateLoadConfig, stateWatchConfig:
+		m.promptInput, cmd = m.promptInput.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case stateListen:
+		m.capturedList, cmd = m.capturedList.Update(msg)
+		cmds = append(cmds, cmd)
+
 	case stateViewResponse:
 		m.responseView, cmd = m.responseView.Update(msg)
 		cmds = append(cmds, cmd)
@@ -439,14 +1269,34 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case stateLoadRequest:
 		m.requestList, cmd = m.requestList.Update(msg)
 		cmds = append(cmds, cmd)
+
+	case stateRunResults:
+		m.resultsTable, cmd = m.resultsTable.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case stateHistory:
+		m.historyList, cmd = m.historyList.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case stateDiff, stateReviewSnapshot:
+		m.diffView, cmd = m.diffView.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m model) View() string {
+	return m.tabBar() + m.view()
+}
+
+// view renders the current state below the tab bar
+func (m model) view() string {
 	switch m.state {
 	case stateMain:
+		if m.running {
+			return fmt.Sprintf("\n  %s Running requests...\n\n", m.spinner.View())
+		}
 		if m.loading {
 			return fmt.Sprintf("\n  %s Sending request...\n\n", m.spinner.View())
 		}
@@ -455,89 +1305,244 @@ func (m model) View() string {
 		s += "\n\n"
 
 		if m.currentRequest.URL != "" {
-			s += fmt.Sprintf("  Current Request: %s %s\n",
-				lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Render(m.currentRequest.Method),
-				m.currentRequest.URL)
+			s += fmt.Sprintf("  Current Request: %s %s%s\n",
+				accentStyle.Render(m.currentRequest.Method),
+				m.currentRequest.URL, m.modifiedMarker())
 		} else {
 			s += "  No request configured\n"
 		}
 
+		if env := m.environment(); env.Name != "" {
+			s += fmt.Sprintf("  Environment: %s\n", env.Label())
+		} else {
+			s += "  Environment: none\n"
+		}
+		if label := workspaceLabel(); label != "" {
+			s += fmt.Sprintf("  Workspace: %s\n", label)
+		}
+		if m.listener != nil && m.listener.addr != "" {
+			s += fmt.Sprintf("  Webhooks: listening on http://%s (%d captured)\n", m.listener.addr, len(m.captured))
+		}
+
 		s += "\n"
-		s += helpStyle.Render("  e: Edit request • enter: Send request • l: Load saved • q: Quit\n")
+		k := m.keys
+		s += helpLine(k.Main.Edit, k.Main.Send, k.Main.Load, k.Main.History, k.Main.Run, k.Main.RunSnapshots, k.Main.LoadTest,
+			k.Main.Webhooks, k.Main.Environment, k.Palette.Open, k.Main.Quit)
+		s += helpLine(k.Tabs.New, k.Tabs.Next, k.Tabs.Previous, k.Tabs.GoTo, k.Tabs.MoveLeft, k.Tabs.MoveRight, k.Tabs.Close)
 
 		if m.err != nil {
-			s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(fmt.Sprintf("  Error: %v", m.err))
+			s += "\n" + errorStyle.Render(fmt.Sprintf("  Error: %v", m.err))
 		}
 
 		return s
 
-	case stateEditRequest:
-		s := titleStyle.Render("Edit Request")
-		s += "\n\n"
+	case stateCommandPalette:
+		return m.paletteView()
 
-		// URL input
-		s += "  URL:\n"
-		if m.urlInput.Focused() {
-			s += focusedInputStyle.Render(m.urlInput.View()) + "\n\n"
-		} else {
-			s += urlInputStyle.Render(m.urlInput.View()) + "\n\n"
+	case stateEditRequest, stateViewResponse:
+		if m.layout() != layoutFull {
+			return m.splitView()
+		}
+		if m.state == stateEditRequest {
+			return m.editorPane()
 		}
+		return m.responsePane()
 
-		// Method selection
-		if isListFocused(m) {
-			s += focusedInputStyle.Render(m.methodList.View()) + "\n\n"
+	case stateSaveRequest:
+		s := titleStyle.Render("Save Request")
+		s += "\n\n"
+		s += "  Name:\n"
+		if m.nameInput.Focused() {
+			s += focusedInputStyle.Render(m.nameInput.View()) + "\n\n"
 		} else {
-			s += m.methodList.View() + "\n\n"
+			s += urlInputStyle.Render(m.nameInput.View()) + "\n\n"
+		}
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
 		}
+		s += helpLine(withHelp(m.keys.Confirm, "Save"), m.keys.Cancel)
 
-		// Headers
-		s += headerStyle.Render("  Headers:") + "\n"
-		if m.headerInput.Focused() {
-			s += focusedInputStyle.Render(m.headerInput.View()) + "\n\n"
-		} else {
-			s += m.headerInput.View() + "\n\n"
+		return s
+
+	case stateConfirmOverwrite:
+		s := titleStyle.Render("Overwrite Request")
+		s += "\n\n"
+		s += fmt.Sprintf("  A request named %q already exists in %s.\n", m.target.Name, folderLabel(m.target.Folder))
+		s += "  Overwrite it? (y/n)\n\n"
+		s += helpLine(withHelp(m.keys.Yes, "Overwrite"), withHelp(m.keys.No, "Choose another name"))
+
+		return s
+
+	case stateRunResults:
+		passed, failed, skipped := m.collection.Counts()
+		s := titleStyle.Render("Run Results")
+		s += "\n\n"
+		s += fmt.Sprintf("  %s • %d passed • %d failed • %d skipped • %dms\n\n",
+			m.collection.Name, passed, failed, skipped, m.collection.Duration.Milliseconds())
+		s += m.resultsTable.View()
+		s += "\n\n"
+		if m.status != "" {
+			s += "  " + m.status + "\n"
 		}
+		s += helpLine(m.keys.Results.Open, m.keys.Results.Review, m.keys.Back)
 
-		// Body
-		s += headerStyle.Render("  Body:") + "\n"
-		if m.bodyInput.Focused() {
-			s += focusedInputStyle.Render(m.bodyInput.View()) + "\n\n"
-		} else {
-			s += m.bodyInput.View() + "\n\n"
+		return s
+
+	case stateLoadRequest:
+		s := titleStyle.Render("Load Request")
+		if label := workspaceLabel(); label != "" {
+			s += helpStyle.Render("  " + label)
+		}
+		s += "\n\n"
+		s += m.requestList.View()
+		s += "\n"
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
 		}
+		k := m.keys.Requests
+		s += helpLine(k.Open, k.Expand, k.Collapse, k.Run, k.Back)
+		s += helpLine(k.Rename, k.Duplicate, k.Move, k.Delete, k.Undo)
 
-		s += helpStyle.Render("  ctrl+n/tab: Next field • ctrl+s: Send • alt+s: Save • esc: Back\n")
+		return s
+
+	case stateHistory:
+		s := titleStyle.Render("History")
+		s += "\n\n"
+		s += m.historyList.View()
+		s += "\n"
+		if m.status != "" {
+			s += "  " + m.status + "\n"
+		}
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
+		}
+		s += helpLine(m.keys.History.Open, m.keys.History.Mark, m.keys.History.Compare, m.keys.History.Export, m.keys.Back)
 
 		return s
 
-	case stateViewResponse:
-		s := titleStyle.Render("Response")
+	case stateDiff:
+		s := titleStyle.Render("Compare Responses")
 		s += "\n\n"
-		s += m.responseView.View()
+		s += m.diffView.View()
 		s += "\n\n"
-		s += helpStyle.Render("  q: Back • e: Edit request\n")
+		s += helpLine(scrollHelp, m.keys.Back)
 
 		return s
 
-	case stateSaveRequest:
-		s := titleStyle.Render("Save Request")
+	case stateWatchConfig:
+		s := titleStyle.Render("Watch")
 		s += "\n\n"
-		s += "  Name:\n"
-		if m.nameInput.Focused() {
-			s += focusedInputStyle.Render(m.nameInput.View()) + "\n\n"
+		s += fmt.Sprintf("  Re-send %s %s every:\n", m.currentRequest.Method, m.currentRequest.URL)
+		s += focusedInputStyle.Render(m.promptInput.View()) + "\n\n"
+		s += helpStyle.Render("  e.g. 10s, or 5s until status 200, body /ready/, header X-Version == 2, $.status == \"up\"\n")
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
+		}
+		s += helpLine(withHelp(m.keys.Confirm, "Start watching"), m.keys.Cancel)
+
+		return s
+
+	case stateLoadConfig:
+		s := titleStyle.Render("Load Test")
+		s += "\n\n"
+		s += fmt.Sprintf("  %s %s\n\n", m.currentRequest.Method, m.currentRequest.URL)
+		s += "  Options (-n requests, -d duration, -c concurrency, -rate per second):\n"
+		s += focusedInputStyle.Render(m.promptInput.View()) + "\n\n"
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n\n"
+		}
+		s += helpLine(withHelp(m.keys.Confirm, "Start"), m.keys.Cancel)
+
+		return s
+
+	case stateLoadTest:
+		title := "Load Test"
+		if m.loadStats.Done {
+			title += " (done)"
 		} else {
-			s += urlInputStyle.Render(m.nameInput.View()) + "\n\n"
+			title = m.spinner.View() + " " + title
+		}
+		s := titleStyle.Render(title)
+		s += "\n\n"
+		for _, line := range strings.Split(formatLoadStats(m.loadStats), "\n") {
+			s += "  " + line + "\n"
+		}
+		if m.status != "" {
+			s += "  " + m.status + "\n"
 		}
-		s += helpStyle.Render("  enter: Save • esc: Cancel\n")
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
+		}
+		s += helpLine(m.keys.LoadTest.Stop, m.keys.LoadTest.Export, m.keys.Back)
 
 		return s
 
-	case stateLoadRequest:
-		s := titleStyle.Render("Load Request")
+	case stateListenAddr:
+		s := titleStyle.Render("Listen for Webhooks")
 		s += "\n\n"
-		s += m.requestList.View()
+		s += "  Address to listen on, optionally followed by the status and body to answer with:\n"
+		s += focusedInputStyle.Render(m.promptInput.View()) + "\n\n"
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n\n"
+		}
+		s += helpLine(withHelp(m.keys.Confirm, "Start listening"), m.keys.Cancel)
+
+		return s
+
+	case stateListen:
+		s := titleStyle.Render("Webhooks")
+		s += "\n\n"
+		s += m.capturedList.View()
 		s += "\n"
-		s += helpStyle.Render("  enter: Select • esc: Cancel\n")
+		if m.status != "" {
+			s += "  " + m.status + "\n"
+		}
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
+		}
+		s += helpLine(m.keys.Listen.Replay, m.keys.Listen.Clear, m.keys.Listen.Stop, m.keys.Back)
+
+		return s
+
+	case stateReviewSnapshot:
+		changed := 0
+		for _, r := range m.collection.Results {
+			if r.Snapshot != nil && r.Snapshot.Status == snapshotChanged {
+				changed++
+			}
+		}
+		s := titleStyle.Render("Review Snapshot")
+		s += helpStyle.Render(fmt.Sprintf("  %d changed", changed))
+		s += "\n\n"
+		s += m.diffView.View()
+		s += "\n\n"
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)) + "\n"
+		}
+		s += helpLine(m.keys.Review.Accept, m.keys.Review.Reject, scrollHelp, m.keys.Back)
+
+		return s
+
+	case stateRenameRequest, stateMoveRequest:
+		title, label := "Rename Request", "  New name:\n"
+		if m.state == stateMoveRequest {
+			title, label = "Move Request", "  Target folder (empty for top level):\n"
+		}
+		s := titleStyle.Render(title)
+		s += "\n\n"
+		s += fmt.Sprintf("  %s\n\n", m.target.Name)
+		s += label
+		s += focusedInputStyle.Render(m.promptInput.View()) + "\n\n"
+		s += helpLine(m.keys.Confirm, m.keys.Cancel)
+
+		return s
+
+	case stateConfirmDelete:
+		s := titleStyle.Render("Delete Request")
+		s += "\n\n"
+		s += fmt.Sprintf("  Move %q from %s to the trash? (y/n)\n\n", m.target.Name, folderLabel(m.target.Folder))
+		s += helpLine(withHelp(m.keys.Yes, "Delete"), withHelp(m.keys.No, "Cancel"),
+			withHelp(m.keys.Requests.Undo, "Restore deleted requests later"))
 
 		return s
 
@@ -546,115 +1551,200 @@ func (m model) View() string {
 	}
 }
 
-func sendRequest(req HTTPRequest) tea.Cmd {
-	return func() tea.Msg {
-		client := &http.Client{
-			Timeout: 30 * time.Second,
-		}
+// formatResponse renders the request details, response and assertion results for the response view
+func formatResponse(req HTTPRequest, resp HTTPResponse) string {
+	content := fmt.Sprintf("Request:\n%s %s\n\n",
+		lipgloss.NewStyle().Bold(true).Render(req.Method),
+		req.URL)
 
-		var reqBody io.Reader
-		if req.Body != "" {
-			reqBody = strings.NewReader(req.Body)
+	// Add request headers
+	if len(req.Headers) > 0 {
+		content += "Request Headers:\n"
+		for k, v := range req.Headers {
+			content += fmt.Sprintf("%s: %s\n", k, v)
 		}
+		content += "\n"
+	}
 
-		httpReq, err := http.NewRequest(req.Method, req.URL, reqBody)
-		if err != nil {
-			return errMsg{err}
-		}
+	// Add request body if present
+	if req.Body != "" {
+		content += "Request Body:\n"
+		content += highlightJSON(req.Body)
+		content += "\n\n"
+	}
 
-		// Add headers
-		for k, v := range req.Headers {
-			httpReq.Header.Add(k, v)
+	// Add response details
+	content += fmt.Sprintf("Response Status: %s (%dms)\n",
+		statusStyle(resp.StatusCode).Render(fmt.Sprintf("%d %s", resp.StatusCode, resp.Status)), resp.Duration.Milliseconds())
+	if t := resp.Timings; t != nil {
+		content += helpStyle.Render(fmt.Sprintf("DNS %s • Connect %s • TLS %s • Send %s • Wait %s • Receive %s",
+			t.DNS.Round(time.Microsecond), t.Connect.Round(time.Microsecond), t.TLS.Round(time.Microsecond),
+			t.Send.Round(time.Microsecond), t.Wait.Round(time.Microsecond), t.Receive.Round(time.Microsecond))) + "\n"
+	}
+	content += "\n"
+
+	// Add assertion results
+	if len(resp.Assertions) > 0 {
+		content += "Assertions:\n"
+		for _, r := range resp.Assertions {
+			if r.Passed {
+				content += successStyle.Render("  ✓ "+r.Assertion.String()) + "\n"
+			} else {
+				content += errorStyle.Render(fmt.Sprintf("  ✗ %s: %s", r.Assertion.String(), r.Message)) + "\n"
+			}
 		}
+		content += "\n"
+	}
 
-		// Set default content-type if not specified and body exists
-		if req.Body != "" && httpReq.Header.Get("Content-Type") == "" {
-			httpReq.Header.Set("Content-Type", "application/json")
+	// Add contract check against the OpenAPI spec
+	if c := resp.Contract; c != nil {
+		content += "Contract:\n"
+		if c.Passed() {
+			content += successStyle.Render("  ✓ matches "+c.Operation) + "\n"
+		} else if c.Operation != "" {
+			content += fmt.Sprintf("  %s\n", c.Operation)
 		}
-
-		resp, err := client.Do(httpReq)
-		if err != nil {
-			return errMsg{err}
+		for _, v := range c.Violations {
+			content += errorStyle.Render("  ✗ "+v) + "\n"
 		}
-		defer resp.Body.Close()
+		content += "\n"
+	}
 
-		// Read response body
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return errMsg{err}
+	if len(resp.Headers) > 0 {
+		content += "Response Headers:\n"
+		for k, v := range resp.Headers {
+			content += fmt.Sprintf("%s: %s\n", k, v)
 		}
+		content += "\n"
+	}
 
-		// Convert response headers
-		headers := make(map[string]string)
-		for k, v := range resp.Header {
-			headers[k] = strings.Join(v, ", ")
-		}
+	content += "Response Body:\n" + highlightJSON(resp.Body)
 
-		return responseMsg{
-			StatusCode: resp.StatusCode,
-			Status:     resp.Status,
-			Headers:    headers,
-			Body:       string(body),
-		}
+	return content
+}
+
+// sendRequest sends the request of a tab in the background
+func sendRequest(tab int, req HTTPRequest) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := executeRequest(req)
+		return responseMsg{tab: tab, request: req, response: resp, err: err}
+	}
+}
+
+// httpClient sends every request, so connections are kept alive and reused
+var httpClient = newHTTPClient()
+
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Load tests keep many requests to the same host in flight
+	transport.MaxIdleConnsPerHost = 100
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}
+}
+
+// executeRequest performs the HTTP request and evaluates its assertions against the response
+func executeRequest(req HTTPRequest) (HTTPResponse, error) {
+	var reqBody io.Reader
+	if req.Body != "" {
+		reqBody = strings.NewReader(req.Body)
+	}
+
+	httpReq, err := http.NewRequest(req.Method, req.URL, reqBody)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+
+	// Add headers
+	for k, v := range req.Headers {
+		httpReq.Header.Add(k, v)
+	}
+	applyAuth(httpReq, req.Auth)
+	trace := &requestTrace{}
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), trace.clientTrace()))
+
+	// Set default content-type if not specified and body exists
+	if req.Body != "" && httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	trace.start = start
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+	duration := time.Since(start)
+
+	// Convert response headers
+	headers := make(map[string]string)
+	for k, v := range resp.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	response := HTTPResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    headers,
+		Body:       string(body),
+		Duration:   duration,
+		Timings:    trace.timings(start.Add(duration)),
+	}
+	response.Assertions = evaluateAssertions(req.Assertions, response)
+	if req.Spec != "" {
+		response.Contract = validateContract(req, response)
 	}
+
+	return response, nil
 }
 
 func saveRequest(req HTTPRequest) tea.Cmd {
 	return func() tea.Msg {
-		// Create requests directory if it doesn't exist
-		if err := os.MkdirAll("requests", 0755); err != nil {
+		if err := validateRequestName(req.Name); err != nil {
 			return errMsg{err}
 		}
+		if req.ID == "" {
+			req.ID = newRequestID()
+		}
 
 		// Save request to file
-		filename := filepath.Join("requests", fmt.Sprintf("%s.json", req.Name))
-		file, err := os.Create(filename)
-		if err != nil {
-			return errMsg{err}
+		if req.Path == "" {
+			req.Path = requestPath(req)
+			req = withSidecarName(req)
 		}
-		defer file.Close()
-
-		encoder := json.NewEncoder(file)
-		encoder.SetIndent("", "  ")
-		if err := encoder.Encode(req); err != nil {
+		if err := writeRequestFile(req); err != nil {
 			return errMsg{err}
 		}
 
-		return loadSavedRequests()
+		return requestSavedMsg(req)
 	}
 }
 
 func loadSavedRequests() tea.Msg {
-	// Check if requests directory exists
-	if _, err := os.Stat("requests"); os.IsNotExist(err) {
-		return savedRequestsMsg{}
-	}
-
-	// Read all files in requests directory
-	files, err := os.ReadDir("requests")
+	requests, folders, err := readSavedRequests()
 	if err != nil {
 		return errMsg{err}
 	}
+	return savedRequestsMsg{requests: requests, folders: folders}
+}
 
-	var requests []HTTPRequest
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
-			// Read and parse request file
-			data, err := os.ReadFile(filepath.Join("requests", file.Name()))
-			if err != nil {
-				continue
-			}
-
-			var req HTTPRequest
-			if err := json.Unmarshal(data, &req); err != nil {
-				continue
-			}
-
-			requests = append(requests, req)
-		}
+// readSavedRequests reads every request and folder stored in the requests
+// directory, followed by the personal requests when a project is open
+func readSavedRequests() ([]HTTPRequest, map[string]FolderConfig, error) {
+	requests, folders, err := readRequestTree(requestsDir)
+	if err != nil || personalRequestsDir == "" {
+		return requests, folders, err
 	}
-
-	return savedRequestsMsg(requests)
+	requests, err = readPersonalRequests(requests, folders)
+	return requests, folders, err
 }
 
 func parseHeaders(input string) map[string]string {
@@ -688,7 +1778,24 @@ func indexOf(val string, slice []string) int {
 }
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	override, args, err := splitWorkspaceFlag(os.Args[1:])
+	if err == nil {
+		err = setupWorkspace(override)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	if len(args) > 0 {
+		os.Exit(runCLI(args, os.Stdout, os.Stderr))
+	}
+
+	m, err := newClientModel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
 	}
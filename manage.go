@@ -0,0 +1,418 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// trashEntry records a deleted request so it can be restored
+type trashEntry struct {
+	request     HTTPRequest
+	trashedPath string
+}
+
+// requestMovedMsg reports that a saved request now lives at a new path
+type requestMovedMsg struct {
+	from    string
+	request HTTPRequest
+}
+
+type requestTrashedMsg trashEntry
+
+// requestSavedMsg reports that a request was written to disk
+type requestSavedMsg HTTPRequest
+
+// trashDir holds requests deleted from a folder's collection until they
+// are restored. It starts with a dot so it is skipped when the request tree
+// is read.
+func trashDir(folder string) string {
+	return filepath.Join(collectionDir(folder), ".trash")
+}
+
+// newRequestID returns a random identifier that stays with a request across renames and moves
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// slugify turns a display name into a filename-safe slug
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if runes := []rune(slug); len(runes) > 64 {
+		slug = strings.TrimSuffix(string(runes[:64]), "-")
+	}
+	if slug == "" {
+		return "request"
+	}
+	return slug
+}
+
+// isReservedFile reports whether a file name in a requests folder belongs to
+// folder settings, a sidecar body or a snapshot rather than a request
+func isReservedFile(name string) bool {
+	return filepath.Base(name) == folderConfigFile || isSidecarFile(name) || isSnapshotFile(name)
+}
+
+// validateRequestName rejects display names that could not be saved. Files
+// are named after the slug, so names may hold paths such as "GET /pets".
+func validateRequestName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("name must not be empty")
+	case strings.IndexFunc(name, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0:
+		return fmt.Errorf("name must contain a letter or digit")
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return fmt.Errorf("name must not contain control characters")
+	case isReservedFile(strings.ToLower(name)):
+		return fmt.Errorf("name %q is reserved for folder settings, bodies and snapshots", name)
+	}
+	return nil
+}
+
+// validateFolder rejects folders that would leave the requests directory
+func validateFolder(folder string) error {
+	for _, part := range strings.Split(folder, "/") {
+		if part == ".." {
+			return fmt.Errorf("folder %q is outside the requests directory", folder)
+		}
+	}
+	return nil
+}
+
+// sameRequest reports whether two requests refer to the same saved request
+func sameRequest(a, b HTTPRequest) bool {
+	if a.ID != "" && b.ID != "" {
+		return a.ID == b.ID
+	}
+	return a.Path != "" && a.Path == b.Path && a.Index == b.Index
+}
+
+// requestPath returns the file a request is saved to: its slugified name
+// in its folder, with a numeric suffix when another request already uses
+// that file or the name is reserved, as folder.json is. A request keeps its
+// current file while its slug is unchanged, and a request from a .http file
+// stays in it while its folder is unchanged.
+func requestPath(req HTTPRequest) string {
+	dir := folderDir(req.Folder)
+	if isHTTPFile(req.Path) && filepath.Dir(req.Path) == dir {
+		return req.Path
+	}
+	slug := slugify(req.Name)
+
+	// Requests keep their format; new ones use the directory's format
+	ext := filepath.Ext(req.Path)
+	if !isRequestFile(req.Path) {
+		ext = newRequestExt()
+	}
+
+	for i := 1; ; i++ {
+		name := slug
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", slug, i)
+		}
+		p := filepath.Join(dir, name+ext)
+		if isReservedFile(p) {
+			continue
+		}
+		if p == req.Path {
+			return p
+		}
+
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			return p
+		}
+		if owner, err := readRequestFile(p); err == nil && req.ID != "" && owner.ID == req.ID {
+			return p
+		}
+	}
+}
+
+// findByName returns the saved request in a folder with the given display name
+func findByName(requests []HTTPRequest, folder, name string) (HTTPRequest, bool) {
+	for _, req := range requests {
+		if req.Folder == folder && req.Name == name {
+			return req, true
+		}
+	}
+	return HTTPRequest{}, false
+}
+
+// nameTaken reports whether another request in the same folder has the request's display name
+func nameTaken(req HTTPRequest) bool {
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		return false
+	}
+	existing, ok := findByName(requests, req.Folder, req.Name)
+	return ok && !sameRequest(existing, req)
+}
+
+// writeRequestFile saves a request to its Path
+func writeRequestFile(req HTTPRequest) error {
+	if isHTTPFile(req.Path) {
+		return updateHTTPFile(req.Path, func(f *httpFile) { f.setRequest(req) })
+	}
+
+	// Create the request's folder if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(req.Path), 0755); err != nil {
+		return err
+	}
+
+	if req.BodyFile != "" {
+		sidecar, err := bodyFilePath(req.Path, req.BodyFile)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(sidecar, []byte(req.Body), 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := encodeRequestFile(req)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(req.Path, data, 0644)
+}
+
+// relocateRequest writes a request to its new path and removes the old
+// file, taking the snapshot along. old is the request as it was saved, with
+// an empty Path for new requests.
+func relocateRequest(old, req HTTPRequest) tea.Cmd {
+	from := old.Path
+	return func() tea.Msg {
+		if err := validateFolder(req.Folder); err != nil {
+			return errMsg{err}
+		}
+		if nameTaken(req) {
+			return errMsg{fmt.Errorf("a request named %q already exists in %s", req.Name, folderLabel(req.Folder))}
+		}
+		if req.ID == "" {
+			req.ID = newRequestID()
+		}
+		req.Path = requestPath(req)
+		if req.Path != from {
+			req = withSidecarName(req)
+		}
+		if err := writeRequestFile(req); err != nil {
+			return errMsg{err}
+		}
+		if from != "" && req.Path != from {
+			if err := removeRequestFile(from, req.Index); err != nil {
+				return errMsg{err}
+			}
+		}
+		if err := moveSnapshot(old, req); err != nil {
+			return errMsg{err}
+		}
+		return requestMovedMsg{from: from, request: req}
+	}
+}
+
+// removeRequestFile deletes a saved request and its sidecar body. For a
+// .http file it removes the request's block from the file.
+func removeRequestFile(p string, index int) error {
+	if isHTTPFile(p) {
+		return updateHTTPFile(p, func(f *httpFile) { f.removeRequest(index) })
+	}
+	if req, err := readRequestFile(p); err == nil && req.BodyFile != "" {
+		sidecar, err := bodyFilePath(p, req.BodyFile)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(sidecar); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Remove(p)
+}
+
+// renameRequest gives a saved request a new name in the same folder
+func renameRequest(req HTTPRequest, name string) tea.Cmd {
+	if err := validateRequestName(name); err != nil {
+		return func() tea.Msg { return errMsg{err} }
+	}
+	old := req
+	req.Name = name
+	return relocateRequest(old, req)
+}
+
+// moveRequest moves a saved request into another folder
+func moveRequest(req HTTPRequest, folder string) tea.Cmd {
+	old := req
+	req.Folder = cleanFolder(folder)
+	return relocateRequest(old, req)
+}
+
+// duplicateRequest saves a copy of a request next to it under an unused name
+func duplicateRequest(req HTTPRequest, existing []HTTPRequest) tea.Cmd {
+	taken := make(map[string]bool)
+	for _, r := range existing {
+		if r.Folder == req.Folder {
+			taken[r.Name] = true
+		}
+	}
+
+	name := req.Name + " copy"
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s copy %d", req.Name, i)
+	}
+
+	req.Name = name
+	req.ID = ""
+	req.Path = ""
+	return relocateRequest(HTTPRequest{}, req)
+}
+
+// trashRequest moves a saved request and its snapshot into the trash
+func trashRequest(req HTTPRequest) tea.Cmd {
+	return func() tea.Msg {
+		trash := trashDir(req.Folder)
+		if err := os.MkdirAll(trash, 0755); err != nil {
+			return errMsg{err}
+		}
+		trashed := filepath.Join(trash, fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(req.Path)))
+		if isHTTPFile(req.Path) {
+			// Keep a copy of the block's request; the rest of the file stays
+			trashed = filepath.Join(trash, fmt.Sprintf("%d-%s.json", time.Now().UnixNano(), slugify(req.Name)))
+			if err := writeIndentedJSON(trashed, req); err != nil {
+				return errMsg{err}
+			}
+			if err := removeRequestFile(req.Path, req.Index); err != nil {
+				return errMsg{err}
+			}
+			if err := moveSnapshot(req, HTTPRequest{Path: trashed}); err != nil {
+				return errMsg{err}
+			}
+			return requestTrashedMsg{request: req, trashedPath: trashed}
+		}
+		if err := os.Rename(req.Path, trashed); err != nil {
+			return errMsg{err}
+		}
+		if req.BodyFile != "" {
+			sidecar, err := bodyFilePath(req.Path, req.BodyFile)
+			if err != nil {
+				return errMsg{err}
+			}
+			if err := os.Rename(sidecar, sidecarPath(trashed, req.BodyFile)); err != nil {
+				return errMsg{err}
+			}
+		}
+		// A later request with the same name must not inherit the snapshot
+		if err := moveSnapshot(req, HTTPRequest{Path: trashed}); err != nil {
+			return errMsg{err}
+		}
+		return requestTrashedMsg{request: req, trashedPath: trashed}
+	}
+}
+
+// restoreRequest moves a trashed request and its snapshot back to where it
+// was deleted from
+func restoreRequest(entry trashEntry) tea.Cmd {
+	return func() tea.Msg {
+		if isHTTPFile(entry.request.Path) {
+			if err := updateHTTPFile(entry.request.Path, func(f *httpFile) { f.insertRequest(entry.request) }); err != nil {
+				return errMsg{err}
+			}
+			if err := moveSnapshot(HTTPRequest{Path: entry.trashedPath}, entry.request); err != nil {
+				return errMsg{err}
+			}
+			if err := os.Remove(entry.trashedPath); err != nil {
+				return errMsg{err}
+			}
+			return requestMovedMsg{request: entry.request}
+		}
+		if _, err := os.Stat(entry.request.Path); err == nil {
+			return errMsg{fmt.Errorf("cannot restore %q: a request with that name exists", entry.request.Name)}
+		}
+		if err := os.MkdirAll(filepath.Dir(entry.request.Path), 0755); err != nil {
+			return errMsg{err}
+		}
+		if err := os.Rename(entry.trashedPath, entry.request.Path); err != nil {
+			return errMsg{err}
+		}
+		if bodyFile := entry.request.BodyFile; bodyFile != "" {
+			sidecar, err := bodyFilePath(entry.request.Path, bodyFile)
+			if err != nil {
+				return errMsg{err}
+			}
+			if err := os.Rename(sidecarPath(entry.trashedPath, bodyFile), sidecar); err != nil {
+				return errMsg{err}
+			}
+		}
+		if err := moveSnapshot(HTTPRequest{Path: entry.trashedPath}, entry.request); err != nil {
+			return errMsg{err}
+		}
+		return requestMovedMsg{request: entry.request}
+	}
+}
+
+// cleanFolder normalises a folder typed by the user to the form used in HTTPRequest.Folder
+func cleanFolder(folder string) string {
+	folder = strings.Trim(filepath.ToSlash(strings.TrimSpace(folder)), "/")
+	if folder == "" || folder == "." {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(folder))
+}
+
+// folderLabel names a folder for display
+func folderLabel(folder string) string {
+	if folder == "" {
+		return "the top level"
+	}
+	return folder + "/"
+}
+
+// requestsEqual compares two requests, treating empty and missing fields alike
+func requestsEqual(a, b HTTPRequest) bool {
+	normalize := func(r HTTPRequest) string {
+		if len(r.Headers) == 0 {
+			r.Headers = nil
+		}
+		data, _ := json.Marshal(r)
+		return string(data)
+	}
+	return normalize(a) == normalize(b)
+}
+
+// formatHeaders renders headers in the Key: Value form accepted by parseHeaders
+func formatHeaders(headers map[string]string) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, headers[k]))
+	}
+	return strings.Join(lines, "\n")
+}
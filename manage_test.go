@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runCmd executes a command and fails the test if it reports an error
+func runCmd(t *testing.T, cmd tea.Cmd) tea.Msg {
+	t.Helper()
+	msg := cmd()
+	if err, ok := msg.(errMsg); ok {
+		t.Fatalf("Command failed: %v", err)
+	}
+	return msg
+}
+
+func savedNames(t *testing.T) map[string]string {
+	t.Helper()
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string)
+	for _, req := range requests {
+		names[req.Name] = req.Folder
+	}
+	return names
+}
+
+// TestManageSavedRequests tests renaming, duplicating, moving, deleting and restoring requests
+func TestManageSavedRequests(t *testing.T) {
+	chdirTemp(t)
+
+	runCmd(t, saveRequest(HTTPRequest{Name: "users", Method: "GET", URL: "http://example.com/users"}))
+	requests, _, _ := readSavedRequests()
+	req := requests[0]
+
+	moved := runCmd(t, renameRequest(req, "list users")).(requestMovedMsg)
+	if names := savedNames(t); len(names) != 1 || names["list users"] != "" {
+		t.Errorf("Expected only the renamed request, got %v", names)
+	}
+
+	runCmd(t, duplicateRequest(moved.request, []HTTPRequest{moved.request}))
+	if names := savedNames(t); len(names) != 2 || names["list users copy"] != "" {
+		t.Errorf("Expected a copy next to the original, got %v", names)
+	}
+
+	moved = runCmd(t, moveRequest(moved.request, "/admin/users/")).(requestMovedMsg)
+	if names := savedNames(t); names["list users"] != "admin/users" {
+		t.Errorf("Expected request to move into admin/users, got %v", names)
+	}
+
+	trashed := runCmd(t, trashRequest(moved.request)).(requestTrashedMsg)
+	if _, ok := savedNames(t)["list users"]; ok {
+		t.Errorf("Expected deleted request to disappear from the saved requests")
+	}
+	if _, err := os.Stat(trashed.trashedPath); err != nil {
+		t.Errorf("Expected deleted request in the trash: %v", err)
+	}
+
+	runCmd(t, restoreRequest(trashEntry(trashed)))
+	if names := savedNames(t); names["list users"] != "admin/users" {
+		t.Errorf("Expected request to be restored to admin/users, got %v", names)
+	}
+}
+
+// TestRenameRefusesToClobber tests that renaming onto an existing request fails
+func TestRenameRefusesToClobber(t *testing.T) {
+	chdirTemp(t)
+
+	runCmd(t, saveRequest(HTTPRequest{Name: "a", Method: "GET"}))
+	runCmd(t, saveRequest(HTTPRequest{Name: "b", Method: "GET"}))
+
+	a := HTTPRequest{Name: "a", Path: filepath.Join(requestsDir, "a.json")}
+	if _, ok := renameRequest(a, "b")().(errMsg); !ok {
+		t.Errorf("Expected renaming onto an existing request to fail")
+	}
+}
+
+// TestUnsavedChangesIndicator tests that editing a loaded request marks it as modified
+func TestUnsavedChangesIndicator(t *testing.T) {
+	m := initialModel()
+	m.setRequest(HTTPRequest{
+		Name:    "users",
+		Method:  "GET",
+		URL:     "http://example.com",
+		Headers: map[string]string{},
+		Path:    filepath.Join("requests", "users.json"),
+	})
+
+	if m.isModified() {
+		t.Errorf("Expected freshly loaded request not to be modified")
+	}
+	if m.urlInput.Value() != "http://example.com" {
+		t.Errorf("Expected URL input to be filled from the loaded request, got %q", m.urlInput.Value())
+	}
+
+	m.state = stateEditRequest
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = updatedModel.(model)
+
+	if !m.isModified() {
+		t.Errorf("Expected request to be modified after editing the URL")
+	}
+}
+
+// TestSlugify tests that display names become safe filenames
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Get User", "get-user"},
+		{"  List: all (v2)  ", "list-all-v2"},
+		{"../../etc/passwd", "etc-passwd"},
+		{"Größe prüfen", "größe-prüfen"},
+		{"???", "request"},
+	}
+
+	for _, tt := range tests {
+		if got := slugify(tt.name); got != tt.want {
+			t.Errorf("slugify(%q) = %q, expected %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestValidateRequestName tests that names which cannot be saved, or look like reserved files, are rejected
+func TestValidateRequestName(t *testing.T) {
+	for _, name := range []string{"", "  ", "..", "/", "tab\there", "folder.json", "Folder.JSON", "user.body.json", "user.snap.json"} {
+		if validateRequestName(name) == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+	for _, name := range []string{"Get user", "users: list", "v1.2", "Folder", "GET /pets", "Webhook POST /hook", `a\b`, "x..y"} {
+		if err := validateRequestName(name); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", name, err)
+		}
+	}
+	if err := validateFolder("a/../../b"); err == nil {
+		t.Errorf("Expected folder with .. to be rejected")
+	}
+}
+
+// TestRequestPathCollisions tests that requests whose names slugify alike get distinct files
+func TestRequestPathCollisions(t *testing.T) {
+	chdirTemp(t)
+
+	first := runCmd(t, saveRequest(HTTPRequest{Name: "Get User", Method: "GET"})).(requestSavedMsg)
+	second := runCmd(t, saveRequest(HTTPRequest{Name: "get-user", Method: "GET"})).(requestSavedMsg)
+
+	if filepath.Base(first.Path) != "get-user.json" || filepath.Base(second.Path) != "get-user-2.json" {
+		t.Errorf("Expected get-user.json and get-user-2.json, got %s and %s", first.Path, second.Path)
+	}
+	if first.ID == "" || first.ID == second.ID {
+		t.Errorf("Expected distinct non-empty IDs, got %q and %q", first.ID, second.ID)
+	}
+
+	// Saving again keeps the same file
+	again := runCmd(t, saveRequest(HTTPRequest(first))).(requestSavedMsg)
+	if again.Path != first.Path || again.ID != first.ID {
+		t.Errorf("Expected resave to keep %s (%s), got %s (%s)", first.Path, first.ID, again.Path, again.ID)
+	}
+
+	// A request named like the folder settings gets another file and stays listed
+	folder := runCmd(t, saveRequest(HTTPRequest{Name: "Folder", Method: "GET"})).(requestSavedMsg)
+	if filepath.Base(folder.Path) != "folder-2.json" {
+		t.Errorf("Expected folder-2.json, got %s", folder.Path)
+	}
+	loaded := runCmd(t, loadSavedRequests).(savedRequestsMsg)
+	if len(loaded.requests) != 3 {
+		t.Errorf("Expected 3 saved requests, got %d", len(loaded.requests))
+	}
+}
+
+// TestSaveAsksBeforeOverwriting tests that saving over a different request with the same name needs confirmation
+func TestSaveAsksBeforeOverwriting(t *testing.T) {
+	chdirTemp(t)
+
+	existing := runCmd(t, saveRequest(HTTPRequest{Name: "users", Method: "GET", URL: "http://old"})).(requestSavedMsg)
+
+	m := initialModel()
+	m.savedRequests = []HTTPRequest{HTTPRequest(existing)}
+	m.currentRequest.URL = "http://new"
+	m.state = stateSaveRequest
+	m.nameInput.SetValue("users")
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(model)
+	if m.state != stateConfirmOverwrite || cmd != nil {
+		t.Fatalf("Expected overwrite confirmation, got state %d", m.state)
+	}
+
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updatedModel.(model)
+	saved := runCmd(t, cmd).(requestSavedMsg)
+	if saved.ID != existing.ID || saved.Path != existing.Path || saved.URL != "http://new" {
+		t.Errorf("Expected existing request to be overwritten, got %+v", saved)
+	}
+
+	m.state = stateSaveRequest
+	m.nameInput.SetValue("folder.json")
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(model)
+	if cmd != nil || m.err == nil {
+		t.Errorf("Expected reserved name to be rejected")
+	}
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// whelm mock serves the examples of saved requests as a fake backend. A
+// request's example is its mock field, or else its snapshot. Paths are
+// matched segment by segment, where {{name}}, {name} and :name segments
+// capture any value; query parameters and match_headers in the request must
+// be present in the incoming request. Mock bodies are Go templates, e.g.
+//
+//	{"id": "{{.Params.id}}", "created": "{{now}}", "token": "{{uuid}}"}
+
+// MockResponse is the example a saved request answers with under whelm mock
+type MockResponse struct {
+	Status       int               `json:"status,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	MatchHeaders map[string]string `json:"match_headers,omitempty"`
+	DelayMS      int               `json:"delay_ms,omitempty"`
+	ErrorRate    float64           `json:"error_rate,omitempty"`
+	ErrorStatus  int               `json:"error_status,omitempty"`
+}
+
+// Sources of mock routes
+const (
+	mockSourceMock     = "mock"
+	mockSourceSnapshot = "snapshot"
+)
+
+// mockRoute answers requests matching a saved request
+type mockRoute struct {
+	Name     string
+	Method   string
+	Path     string
+	segments []string
+	query    map[string]string
+	Source   string
+	Response MockResponse
+	body     *template.Template // nil for bodies served as they are
+}
+
+// mockTemplateFuncs are available in mock response bodies
+var mockTemplateFuncs = template.FuncMap{
+	"now": func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"uuid": func() string {
+		b := make([]byte, 16)
+		rand.Read(b)
+		b[6], b[8] = b[6]&0x0f|0x40, b[8]&0x3f|0x80
+		h := hex.EncodeToString(b)
+		return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	},
+	"randInt": func(min, max int) int {
+		if max < min {
+			min, max = max, min
+		}
+		return min + mathrand.Intn(max-min+1)
+	},
+}
+
+// mockTemplateData is what a mock body template is executed with
+type mockTemplateData struct {
+	Method  string
+	Path    string
+	Params  map[string]string
+	Query   map[string]string
+	Headers map[string]string
+	Body    string
+	JSON    any
+}
+
+// buildMockRoutes turns saved requests with an example into routes. vars
+// resolve URL variables such as {{baseUrl}}; a leading variable that is not
+// set is dropped so only the path remains.
+func buildMockRoutes(requests []HTTPRequest, vars map[string]string) ([]mockRoute, error) {
+	var routes []mockRoute
+	for _, req := range requests {
+		route := mockRoute{Name: req.Name, Method: strings.ToUpper(req.Method)}
+		switch {
+		case req.Mock != nil:
+			route.Source, route.Response = mockSourceMock, *req.Mock
+		default:
+			s, err := readSnapshot(snapshotPath(req))
+			if err != nil {
+				continue
+			}
+			route.Source = mockSourceSnapshot
+			route.Response = MockResponse{Status: s.Status, Headers: s.Headers, Body: s.response().Body}
+		}
+
+		route.Path, route.query = mockPattern(substituteVariables(req.URL, mergeVariables(req.Variables, vars)))
+		route.segments = strings.Split(strings.Trim(route.Path, "/"), "/")
+
+		// Snapshot bodies are served as they are
+		if route.Source == mockSourceMock {
+			body, err := template.New(req.Name).Funcs(mockTemplateFuncs).Option("missingkey=zero").Parse(route.Response.Body)
+			if err != nil {
+				return nil, fmt.Errorf("mock body of %s: %w", req.Name, err)
+			}
+			route.body = body
+		}
+		routes = append(routes, route)
+	}
+
+	// Routes with more literal segments are more specific and tried first
+	sort.SliceStable(routes, func(i, j int) bool {
+		return mockLiterals(routes[i]) > mockLiterals(routes[j])
+	})
+	return routes, nil
+}
+
+// mockPattern splits a saved URL into its path and query parameters
+func mockPattern(rawURL string) (string, map[string]string) {
+	rest := rawURL
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			rest = rest[j:]
+		} else {
+			rest = "/"
+		}
+	} else if strings.HasPrefix(rest, "{{") {
+		if end := strings.Index(rest, "}}"); end >= 0 {
+			rest = rest[end+2:]
+		}
+	}
+
+	p, rawQuery, _ := strings.Cut(rest, "?")
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	query := make(map[string]string)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		query[k] = v
+	}
+	return p, query
+}
+
+// mockParam returns the parameter name of a path segment, if it is one
+func mockParam(segment string) (string, bool) {
+	switch {
+	case strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}"):
+		return strings.TrimSpace(segment[2 : len(segment)-2]), true
+	case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+		return segment[1 : len(segment)-1], true
+	case strings.HasPrefix(segment, ":") && len(segment) > 1:
+		return segment[1:], true
+	case segment == "*":
+		return "*", true
+	}
+	return "", false
+}
+
+func mockLiterals(r mockRoute) int {
+	n := 0
+	for _, s := range r.segments {
+		if _, ok := mockParam(s); !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// match reports whether the route answers r, returning the captured path parameters
+func (route mockRoute) match(r *http.Request) (map[string]string, bool) {
+	if route.Method != r.Method {
+		return nil, false
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) != len(route.segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, s := range route.segments {
+		if name, ok := mockParam(s); ok {
+			params[name] = segments[i]
+		} else if s != segments[i] {
+			return nil, false
+		}
+	}
+
+	query := r.URL.Query()
+	for k, v := range route.query {
+		if !query.Has(k) {
+			return nil, false
+		}
+		if !variablePattern.MatchString(v) && query.Get(k) != v {
+			return nil, false
+		}
+	}
+	for k, v := range route.Response.MatchHeaders {
+		if r.Header.Get(k) != v {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// mockHit is a request served by the mock server
+type mockHit struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	Route    string
+	Status   int
+	Duration time.Duration
+	Injected bool
+	route    int
+}
+
+// mockServer serves mock routes, applying latency and error injection
+type mockServer struct {
+	routes    []mockRoute
+	latency   time.Duration
+	errorRate float64
+	random    func() float64
+	hits      chan mockHit
+
+	mu     sync.Mutex
+	counts map[int]int
+}
+
+func newMockServer(routes []mockRoute, latency time.Duration, errorRate float64) *mockServer {
+	return &mockServer{
+		routes:    routes,
+		latency:   latency,
+		errorRate: errorRate,
+		random:    mathrand.Float64,
+		hits:      make(chan mockHit, 256),
+		counts:    make(map[int]int),
+	}
+}
+
+func (s *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	hit := mockHit{Time: start, Method: r.Method, Path: r.URL.RequestURI(), route: -1}
+	defer func() {
+		hit.Duration = time.Since(start)
+		s.mu.Lock()
+		s.counts[hit.route]++
+		s.mu.Unlock()
+		// Drop hits nobody is reading rather than blocking responses
+		select {
+		case s.hits <- hit:
+		default:
+		}
+	}()
+
+	for i, route := range s.routes {
+		params, ok := route.match(r)
+		if !ok {
+			continue
+		}
+		hit.Route, hit.route = route.Name, i
+		resp := route.Response
+
+		delay := s.latency
+		if resp.DelayMS > 0 {
+			delay = time.Duration(resp.DelayMS) * time.Millisecond
+		}
+		time.Sleep(delay)
+
+		errorRate := s.errorRate
+		if resp.ErrorRate > 0 {
+			errorRate = resp.ErrorRate
+		}
+		if errorRate > 0 && s.random() < errorRate {
+			hit.Status, hit.Injected = resp.ErrorStatus, true
+			if hit.Status == 0 {
+				hit.Status = http.StatusInternalServerError
+			}
+			writeMockError(w, hit.Status, "injected failure")
+			return
+		}
+
+		body, err := route.render(r, params)
+		if err != nil {
+			hit.Status = http.StatusInternalServerError
+			writeMockError(w, hit.Status, err.Error())
+			return
+		}
+		for k, v := range resp.Headers {
+			w.Header().Set(k, v)
+		}
+		hit.Status = resp.Status
+		if hit.Status == 0 {
+			hit.Status = http.StatusOK
+		}
+		w.WriteHeader(hit.Status)
+		w.Write(body)
+		return
+	}
+
+	hit.Status = http.StatusNotFound
+	writeMockError(w, hit.Status, fmt.Sprintf("no mock route matches %s %s", r.Method, r.URL.Path))
+}
+
+// render executes the route's body template for an incoming request
+func (route mockRoute) render(r *http.Request, params map[string]string) ([]byte, error) {
+	if route.body == nil {
+		return []byte(route.Response.Body), nil
+	}
+	data := mockTemplateData{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Params:  params,
+		Query:   make(map[string]string),
+		Headers: make(map[string]string),
+	}
+	for k := range r.URL.Query() {
+		data.Query[k] = r.URL.Query().Get(k)
+	}
+	for k, v := range r.Header {
+		data.Headers[k] = strings.Join(v, ", ")
+	}
+	if body, err := io.ReadAll(r.Body); err == nil {
+		data.Body = string(body)
+		json.Unmarshal(body, &data.JSON)
+	}
+
+	var buf bytes.Buffer
+	if err := route.body.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMockError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// hitCount returns how often the route at an index was hit; -1 counts unmatched requests
+func (s *mockServer) hitCount(route int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[route]
+}
+
+// mockHitMsg delivers a served request to the mock panel
+type mockHitMsg mockHit
+
+// waitForHit waits for the next request served by the mock server
+func (s *mockServer) waitForHit() tea.Msg {
+	return mockHitMsg(<-s.hits)
+}
+
+// maxMockHits bounds the hit log kept by the mock panel
+const maxMockHits = 500
+
+// mockModel is the panel showing the route table and hit log of a running mock server
+type mockModel struct {
+	server *mockServer
+	addr   string
+	routes table.Model
+	log    viewport.Model
+	hits   []mockHit
+	keys   keyMap
+}
+
+func newMockModel(server *mockServer, addr string) mockModel {
+	routes := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Method", Width: 7},
+			{Title: "Path", Width: 36},
+			{Title: "Name", Width: 24},
+			{Title: "Source", Width: 8},
+			{Title: "Hits", Width: 5},
+		}),
+		table.WithHeight(min(len(server.routes)+1, 12)),
+	)
+	m := mockModel{server: server, addr: addr, routes: routes, log: viewport.New(80, 10), keys: defaultKeyMap()}
+	m.refresh()
+	return m
+}
+
+func (m mockModel) Init() tea.Cmd {
+	return m.server.waitForHit
+}
+
+func (m *mockModel) refresh() {
+	var rows []table.Row
+	for i, r := range m.server.routes {
+		rows = append(rows, table.Row{r.Method, r.Path, r.Name, r.Source, strconv.Itoa(m.server.hitCount(i))})
+	}
+	m.routes.SetRows(rows)
+
+	var lines []string
+	for i := len(m.hits) - 1; i >= 0; i-- {
+		h := m.hits[i]
+		route := h.Route
+		if route == "" {
+			route = "no match"
+		}
+		if h.Injected {
+			route += " (injected)"
+		}
+		line := fmt.Sprintf("%s %3d %-7s %s → %s (%dms)", h.Time.Local().Format("15:04:05"), h.Status, h.Method, h.Path, route, h.Duration.Milliseconds())
+		if h.Status >= 400 {
+			line = errorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	m.log.SetContent(strings.Join(lines, "\n"))
+}
+
+func (m mockModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, m.keys.Mock.Stop) {
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.log.Width = msg.Width
+		m.log.Height = max(msg.Height-m.routes.Height()-9, 3)
+	case mockHitMsg:
+		m.hits = append(m.hits, mockHit(msg))
+		if len(m.hits) > maxMockHits {
+			m.hits = m.hits[len(m.hits)-maxMockHits:]
+		}
+		m.refresh()
+		return m, m.server.waitForHit
+	}
+
+	var cmd tea.Cmd
+	m.log, cmd = m.log.Update(msg)
+	return m, cmd
+}
+
+func (m mockModel) View() string {
+	s := titleStyle.Render("Mock Server") + helpStyle.Render("  listening on "+m.addr) + "\n\n"
+	s += m.routes.View() + "\n\n"
+	s += headerStyle.Render("  Hits:") + "\n"
+	s += m.log.View() + "\n\n"
+	s += helpLine(withHelp(scrollHelp, "Scroll hits"), m.keys.Mock.Stop)
+	return s
+}
+
+// mockCommand serves saved requests as a mock backend
+func mockCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mock", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	dir := fs.String("dir", requestsDir, "directory of saved requests to serve")
+	folder := fs.String("folder", "", "only serve requests in this folder")
+	envName := fs.String("env", "", "environment used to resolve URL variables")
+	latency := fs.Duration("latency", 0, "delay added to every response")
+	errorRate := fs.Float64("error-rate", 0, "fraction of requests answered with an injected error")
+	logOnly := fs.Bool("log", false, "print hits instead of showing the mock panel")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	all, folders, err := readRequestTree(*dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	var requests []HTTPRequest
+	for _, req := range all {
+		if inFolder(req.Folder, *folder) {
+			requests = append(requests, inheritFolderSettings(req, folders))
+		}
+	}
+
+	var vars map[string]string
+	if *envName != "" {
+		envs, err := readEnvironments()
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		env, ok := findEnvironment(envs, *envName)
+		if !ok {
+			fmt.Fprintf(stderr, "error: no environment named %q\n", *envName)
+			return 1
+		}
+		vars = env.Variables
+	}
+
+	routes, err := buildMockRoutes(requests, vars)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	if len(routes) == 0 {
+		fmt.Fprintf(stderr, "error: no saved request has a mock response or snapshot\n")
+		return 1
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	server := newMockServer(routes, *latency, *errorRate)
+	go http.Serve(listener, server)
+
+	if *logOnly {
+		fmt.Fprintf(stdout, "Serving %d routes on http://%s\n", len(routes), listener.Addr())
+		for hit := range server.hits {
+			fmt.Fprintf(stdout, "%s %d %s %s %s\n", hit.Time.Format(time.RFC3339), hit.Status, hit.Method, hit.Path, hit.Route)
+		}
+		return 0
+	}
+
+	// The mock panel follows the client's theme and keys
+	config, err := loadConfig()
+	if err == nil {
+		err = setupTheme(config)
+	}
+	m := newMockModel(server, "http://"+listener.Addr().String())
+	if err == nil {
+		if m.keys, err = config.keyMap(); err != nil {
+			err = fmt.Errorf("config: %w", err)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestMockServer tests that saved requests are served by path pattern, query, headers and templated bodies
+func TestMockServer(t *testing.T) {
+	chdirTemp(t)
+
+	for _, req := range []HTTPRequest{
+		{Name: "Get pet", Method: "GET", URL: "{{baseUrl}}/pets/{{id}}?fields={{fields}}",
+			Mock: &MockResponse{Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"id": "{{.Params.id}}", "fields": "{{.Query.fields}}"}`}},
+		{Name: "My pet", Method: "GET", URL: "https://api.example.com/pets/mine?fields=all",
+			Mock: &MockResponse{Body: "mine"}},
+		{Name: "Create pet", Method: "POST", URL: "{{baseUrl}}/pets",
+			Mock: &MockResponse{Status: 201, MatchHeaders: map[string]string{"X-Key": "1"}, Body: `created {{.JSON.name}}`}},
+		{Name: "Health", Method: "GET", URL: "http://x.test/health"},
+		{Name: "Unmocked", Method: "GET", URL: "http://x.test/other"},
+	} {
+		runCmd(t, saveRequest(req))
+	}
+	requests, _, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range requests {
+		if req.Name == "Health" {
+			if err := writeSnapshot(snapshotPath(req), Snapshot{Status: 200, Text: "ok"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	routes, err := buildMockRoutes(requests, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 4 {
+		t.Fatalf("Expected 4 routes, got %d", len(routes))
+	}
+	mock := newMockServer(routes, 0, 0)
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	send := func(method, path, body string, headers map[string]string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	tests := []struct {
+		method, path, body string
+		headers            map[string]string
+		wantStatus         int
+		wantBody           string
+	}{
+		{"GET", "/pets/7?fields=name", "", nil, 200, `{"id": "7", "fields": "name"}`},
+		{"GET", "/pets/mine?fields=all", "", nil, 200, "mine"},
+		{"GET", "/pets/7", "", nil, 404, "no mock route matches GET /pets/7"},
+		{"POST", "/pets", `{"name": "rex"}`, map[string]string{"X-Key": "1"}, 201, "created rex"},
+		{"POST", "/pets", `{"name": "rex"}`, nil, 404, "no mock route"},
+		{"GET", "/health", "", nil, 200, "ok"},
+	}
+	for _, tt := range tests {
+		status, body := send(tt.method, tt.path, tt.body, tt.headers)
+		if status != tt.wantStatus || !strings.Contains(body, tt.wantBody) {
+			t.Errorf("%s %s: Expected %d %q, got %d %q", tt.method, tt.path, tt.wantStatus, tt.wantBody, status, body)
+		}
+	}
+
+	// Error injection and latency
+	mock.errorRate, mock.latency = 0.5, 20*time.Millisecond
+	mock.random = func() float64 { return 0.1 }
+	start := time.Now()
+	if status, body := send("GET", "/health", "", nil); status != 500 || !strings.Contains(body, "injected failure") {
+		t.Errorf("Expected an injected failure, got %d %q", status, body)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Expected the response to be delayed")
+	}
+}
+
+// TestMockRandInt tests that randInt accepts its bounds in either order
+func TestMockRandInt(t *testing.T) {
+	randInt := mockTemplateFuncs["randInt"].(func(int, int) int)
+	for range 20 {
+		if n := randInt(5, 1); n < 1 || n > 5 {
+			t.Errorf("Expected a number from 1 to 5, got %d", n)
+		}
+	}
+}
+
+// TestMockModel tests that the mock panel counts hits per route and logs them newest first
+func TestMockModel(t *testing.T) {
+	routes, err := buildMockRoutes([]HTTPRequest{{Name: "List", Method: "GET", URL: "/items", Mock: &MockResponse{Body: "[]"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := newMockServer(routes, 0, 0)
+	m := newMockModel(mock, "http://localhost:0")
+
+	for _, path := range []string{"/items", "/missing"} {
+		rec := httptest.NewRecorder()
+		mock.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		updated, _ := m.Update(mock.waitForHit())
+		m = updated.(mockModel)
+	}
+
+	if rows := m.routes.Rows(); len(rows) != 1 || rows[0][4] != "1" {
+		t.Errorf("Expected one hit on the route, got %v", rows)
+	}
+	view := m.log.View()
+	if strings.Index(view, "/missing") > strings.Index(view, "/items") || !strings.Contains(view, "no match") {
+		t.Errorf("Expected the newest hit first with the unmatched request labelled, got:\n%s", view)
+	}
+	if !strings.Contains(m.View(), "↑/↓: Scroll hits • q: Stop") {
+		t.Errorf("Expected the help to show the stop key, got:\n%s", m.View())
+	}
+
+	m.keys, err = Config{Keys: map[string]keyList{"mock.stop": {"ctrl+q"}}}.keyMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, cmd := m.Update(keyMsg("q")); cmd != nil {
+		if _, ok := cmd().(tea.QuitMsg); ok {
+			t.Errorf("Expected the old stop key to do nothing")
+		}
+	}
+	if _, cmd := m.Update(keyMsg("ctrl+q")); cmd == nil {
+		t.Errorf("Expected the rebound key to stop the mock server")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Errorf("Expected the rebound key to stop the mock server")
+	}
+}
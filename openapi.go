@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMethods are the operation keys of a path item, in display order
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+// openAPISpec is a parsed OpenAPI 3.x or Swagger 2.0 document
+type openAPISpec struct {
+	doc     map[string]any
+	swagger bool
+}
+
+// openAPIOperation is a single method on a path
+type openAPIOperation struct {
+	Method string
+	Path   string
+	Op     map[string]any
+	Item   map[string]any
+}
+
+// Key identifies the operation independently of its summary or tags
+func (o openAPIOperation) Key() string {
+	return strings.ToUpper(o.Method) + " " + o.Path
+}
+
+// openAPIServer is a base URL the API is served from
+type openAPIServer struct {
+	URL         string
+	Description string
+}
+
+// loadOpenAPISpec reads a spec from a JSON or YAML file
+func loadOpenAPISpec(path string) (*openAPISpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := parseOpenAPISpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// parseOpenAPISpec parses a JSON or YAML document. It is normalised through
+// JSON so numbers decode as float64, as the schema validator expects.
+func parseOpenAPISpec(data []byte) (*openAPISpec, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(normalized, &doc); err != nil {
+		return nil, fmt.Errorf("not an OpenAPI document")
+	}
+
+	spec := &openAPISpec{doc: doc}
+	switch {
+	case strings.HasPrefix(stringField(doc, "openapi"), "3."):
+	case stringField(doc, "swagger") == "2.0":
+		spec.swagger = true
+	default:
+		return nil, fmt.Errorf("expected an OpenAPI 3.x or Swagger 2.0 document")
+	}
+	return spec, nil
+}
+
+// title returns the API title from the info object
+func (s *openAPISpec) title() string {
+	if title := stringField(mapField(s.doc, "info"), "title"); title != "" {
+		return title
+	}
+	return "openapi"
+}
+
+// resolve follows a local $ref, returning v unchanged when it is not a reference
+func (s *openAPISpec) resolve(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	for i := 0; i < 10 && m != nil; i++ {
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			break
+		}
+		target, err := resolveRef(s.doc, ref)
+		if err != nil {
+			return nil
+		}
+		m, _ = target.(map[string]any)
+	}
+	return m
+}
+
+// operations lists every operation sorted by path and method
+func (s *openAPISpec) operations() []openAPIOperation {
+	paths := mapField(s.doc, "paths")
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var ops []openAPIOperation
+	for _, p := range keys {
+		item := s.resolve(paths[p])
+		for _, method := range openAPIMethods {
+			if op, ok := item[method].(map[string]any); ok {
+				ops = append(ops, openAPIOperation{Method: method, Path: p, Op: op, Item: item})
+			}
+		}
+	}
+	return ops
+}
+
+// parameters returns the path level and operation level parameters, the latter taking precedence
+func (s *openAPISpec) parameters(o openAPIOperation) []map[string]any {
+	var params []map[string]any
+	index := make(map[string]int)
+	for _, list := range []any{o.Item["parameters"], o.Op["parameters"]} {
+		items, _ := list.([]any)
+		for _, p := range items {
+			param := s.resolve(p)
+			if param == nil {
+				continue
+			}
+			key := stringField(param, "in") + ":" + stringField(param, "name")
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// servers returns the base URLs declared by the spec with server variables set to their defaults
+func (s *openAPISpec) servers() []openAPIServer {
+	if s.swagger {
+		host := stringField(s.doc, "host")
+		if host == "" {
+			return nil
+		}
+		scheme := "https"
+		if schemes, ok := s.doc["schemes"].([]any); ok && len(schemes) > 0 {
+			scheme, _ = schemes[0].(string)
+		}
+		return []openAPIServer{{URL: scheme + "://" + host + stringField(s.doc, "basePath")}}
+	}
+
+	var servers []openAPIServer
+	list, _ := s.doc["servers"].([]any)
+	for _, entry := range list {
+		server, _ := entry.(map[string]any)
+		u := stringField(server, "url")
+		for name, v := range mapField(server, "variables") {
+			variable, _ := v.(map[string]any)
+			u = strings.ReplaceAll(u, "{"+name+"}", stringField(variable, "default"))
+		}
+		servers = append(servers, openAPIServer{URL: strings.TrimSuffix(u, "/"), Description: stringField(server, "description")})
+	}
+	return servers
+}
+
+// exampleFromSchema builds an example value from a schema, preferring
+// declared examples and defaults over generated placeholders
+func (s *openAPISpec) exampleFromSchema(schema any, depth int) any {
+	m := s.resolve(schema)
+	if m == nil || depth > 8 {
+		return nil
+	}
+	if ex, ok := m["example"]; ok {
+		return ex
+	}
+	if def, ok := m["default"]; ok {
+		return def
+	}
+	if enum, ok := m["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if all, ok := m["allOf"].([]any); ok {
+		merged := make(map[string]any)
+		for _, sub := range all {
+			if obj, ok := s.exampleFromSchema(sub, depth+1).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if choices, ok := m[key].([]any); ok && len(choices) > 0 {
+			return s.exampleFromSchema(choices[0], depth+1)
+		}
+	}
+
+	t, _ := m["type"].(string)
+	if t == "" {
+		if _, ok := m["properties"]; ok {
+			t = "object"
+		}
+	}
+	switch t {
+	case "object":
+		obj := make(map[string]any)
+		for name, prop := range mapField(m, "properties") {
+			if s.resolve(prop)["readOnly"] == true {
+				continue
+			}
+			obj[name] = s.exampleFromSchema(prop, depth+1)
+		}
+		return obj
+	case "array":
+		if item := s.exampleFromSchema(m["items"], depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		switch stringField(m, "format") {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// matchOperation finds the operation whose path template matches a request path
+func (s *openAPISpec) matchOperation(method, path string) (openAPIOperation, bool) {
+	var best openAPIOperation
+	bestParams := -1
+	for _, o := range s.operations() {
+		if !strings.EqualFold(o.Method, method) || !pathTemplateRegexp(o.Path).MatchString(path) {
+			continue
+		}
+		// Prefer the most specific template, e.g. /pets/mine over /pets/{id}
+		params := len(pathParamPattern.FindAllString(o.Path, -1))
+		if bestParams < 0 || params < bestParams {
+			best, bestParams = o, params
+		}
+	}
+	return best, bestParams >= 0
+}
+
+// pathTemplateRegexp compiles a path template such as /pets/{id} into a
+// regexp where each parameter matches one path segment
+func pathTemplateRegexp(template string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range pathParamPattern.FindAllStringIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		b.WriteString("[^/]+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("/?$")
+	return regexp.MustCompile(b.String())
+}
+
+func mapField(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+func stringField(m map[string]any, key string) string {
+	v, _ := m[key].(string)
+	return v
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"testing"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+info:
+  title: Pet Store
+servers:
+  - url: https://api.example.com/v1
+    description: Production
+  - url: https://{region}.staging.example.com
+    variables:
+      region:
+        default: eu
+security:
+  - bearerAuth: []
+paths:
+  /pets:
+    get:
+      summary: List pets
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            default: 20
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+          example: rex
+    get:
+      summary: Get pet
+      tags: [pets]
+      security: []
+      responses:
+        '200':
+          description: A pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /health:
+    get:
+      parameters:
+        - name: session
+          in: cookie
+components:
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+          example: Rex
+        tags:
+          type: array
+          items:
+            type: string
+`
+
+// TestParseOpenAPISpec tests that operations and servers are read from a YAML spec
+func TestParseOpenAPISpec(t *testing.T) {
+	spec, err := parseOpenAPISpec([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.title() != "Pet Store" {
+		t.Errorf("Expected title Pet Store, got %q", spec.title())
+	}
+	ops := spec.operations()
+	if len(ops) != 4 || ops[0].Key() != "GET /health" {
+		t.Errorf("Expected 4 operations starting with GET /health, got %d", len(ops))
+	}
+
+	servers := spec.servers()
+	if len(servers) != 2 || servers[1].URL != "https://eu.staging.example.com" {
+		t.Errorf("Expected server variables to be substituted, got %+v", servers)
+	}
+
+	if _, err := parseOpenAPISpec([]byte(`{"info": {}}`)); err == nil {
+		t.Errorf("Expected an error for a document without a version")
+	}
+}
+
+// TestSwaggerServers tests that Swagger 2.0 host, basePath and schemes form the server URL
+func TestSwaggerServers(t *testing.T) {
+	spec, err := parseOpenAPISpec([]byte(`{"swagger": "2.0", "host": "api.example.com", "basePath": "/v2", "schemes": ["http"], "paths": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := spec.servers()
+	if len(servers) != 1 || servers[0].URL != "http://api.example.com/v2" {
+		t.Errorf("Expected http://api.example.com/v2, got %+v", servers)
+	}
+}
+
+// TestExampleFromSchema tests that examples are generated from referenced schemas
+func TestExampleFromSchema(t *testing.T) {
+	spec, err := parseOpenAPISpec([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	example, ok := spec.exampleFromSchema(map[string]any{"$ref": "#/components/schemas/Pet"}, 0).(map[string]any)
+	if !ok {
+		t.Fatalf("Expected an object example")
+	}
+	if example["name"] != "Rex" {
+		t.Errorf("Expected the declared example for name, got %v", example["name"])
+	}
+	if _, ok := example["id"]; ok {
+		t.Errorf("Expected read-only properties to be left out, got %v", example)
+	}
+	if tags, ok := example["tags"].([]any); !ok || len(tags) != 1 {
+		t.Errorf("Expected a one item array for tags, got %v", example["tags"])
+	}
+}
+
+// TestMatchOperation tests that request paths are matched against path templates
+func TestMatchOperation(t *testing.T) {
+	spec, err := parseOpenAPISpec([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path, want string
+		found              bool
+	}{
+		{"GET", "/pets/42", "GET /pets/{id}", true},
+		{"get", "/pets", "GET /pets", true},
+		{"POST", "/pets/", "POST /pets", true},
+		{"DELETE", "/pets/42", "", false},
+		{"GET", "/pets/42/toys", "", false},
+	}
+	for _, tt := range tests {
+		o, ok := spec.matchOperation(tt.method, tt.path)
+		if ok != tt.found || (ok && o.Key() != tt.want) {
+			t.Errorf("Expected %s %s to match %q (%v), got %q (%v)", tt.method, tt.path, tt.want, tt.found, o.Key(), ok)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sahilm/fuzzy"
+)
+
+// maxPaletteRows bounds the matches listed in the command palette
+const maxPaletteRows = 15
+
+// paletteAction is a command offered in the palette, run by pressing its
+// key in the state it belongs to. Without a key it just opens the state.
+type paletteAction struct {
+	name  string
+	state int
+	key   func(k keyMap) key.Binding
+	// available reports whether the action makes sense right now
+	available func(m model) bool
+}
+
+// hasRequest reports whether there is a request to send
+func hasRequest(m model) bool { return m.currentRequest.URL != "" }
+
+// hasResponse reports whether there is a response to show
+func hasResponse(m model) bool { return m.response.StatusCode != 0 }
+
+// hasRequests reports whether there are saved requests to run
+func hasRequests(m model) bool { return len(m.savedRequests) > 0 }
+
+// paletteActions are the actions listed in the command palette
+var paletteActions = []paletteAction{
+	{name: "Send request", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.Send }, available: hasRequest},
+	{name: "Edit request", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.Edit }},
+	{name: "Save request", state: stateEditRequest, key: func(k keyMap) key.Binding { return k.Editor.Save }},
+	{name: "Edit body in $EDITOR", state: stateEditRequest, key: func(k keyMap) key.Binding { return k.Editor.External }},
+	{name: "View response", state: stateViewResponse, available: hasResponse},
+	{name: "Watch response", state: stateViewResponse, key: func(k keyMap) key.Binding { return k.Response.Watch }, available: hasResponse},
+	{name: "Compare response with history", state: stateViewResponse, key: func(k keyMap) key.Binding { return k.Response.Compare }, available: hasResponse},
+	{name: "Export response as HAR", state: stateViewResponse, key: func(k keyMap) key.Binding { return k.Response.Export }, available: hasResponse},
+	{name: "Open response in $EDITOR", state: stateViewResponse, key: func(k keyMap) key.Binding { return k.Response.External }, available: hasResponse},
+	{name: "Load saved request", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.Load }},
+	{name: "Show history", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.History }},
+	{name: "Run all requests", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.Run }, available: hasRequests},
+	{name: "Run all requests with snapshots", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.RunSnapshots }, available: hasRequests},
+	{name: "Load test request", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.LoadTest }, available: hasRequest},
+	{name: "Webhook catcher", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.Webhooks }},
+	{name: "Next environment", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.Environment }},
+	{name: "New tab", state: stateMain, key: func(k keyMap) key.Binding { return k.Tabs.New }},
+	{name: "Close tab", state: stateMain, key: func(k keyMap) key.Binding { return k.Tabs.Close }},
+	{name: "Next tab", state: stateMain, key: func(k keyMap) key.Binding { return k.Tabs.Next }},
+	{name: "Previous tab", state: stateMain, key: func(k keyMap) key.Binding { return k.Tabs.Previous }},
+	{name: "Toggle split view", state: stateEditRequest, key: func(k keyMap) key.Binding { return k.Layout.Toggle }},
+	{name: "Quit", state: stateMain, key: func(k keyMap) key.Binding { return k.Main.Quit }},
+}
+
+// paletteItem is one entry of the command palette
+type paletteItem struct {
+	kind  string
+	title string
+	// hint is the key of an action, shown next to it
+	hint string
+	run  func(m model) (tea.Model, tea.Cmd)
+}
+
+// paletteItems is the searchable list of palette entries
+type paletteItems []paletteItem
+
+func (p paletteItems) String(i int) string { return p[i].title }
+func (p paletteItems) Len() int            { return len(p) }
+
+// buildPalette collects the actions, saved requests, history entries and
+// environments offered by the palette
+func (m model) buildPalette() paletteItems {
+	var items paletteItems
+	for _, a := range paletteActions {
+		if a.available != nil && !a.available(m) {
+			continue
+		}
+		var hint string
+		if a.key != nil {
+			hint = a.key(m.keys).Help().Key
+		}
+		items = append(items, paletteItem{kind: "Action", title: a.name, hint: hint, run: func(m model) (tea.Model, tea.Cmd) {
+			m.state = a.state
+			if a.state == stateEditRequest {
+				m.urlInput.Focus()
+			}
+			if a.key == nil || len(a.key(m.keys).Keys()) == 0 {
+				return m, nil
+			}
+			return m.Update(keyMsg(a.key(m.keys).Keys()[0]))
+		}})
+	}
+
+	for _, req := range m.savedRequests {
+		title := req.Name
+		if req.Folder != "" {
+			title = req.Folder + "/" + req.Name
+		}
+		items = append(items, paletteItem{kind: "Request", title: title, run: func(m model) (tea.Model, tea.Cmd) {
+			m.setRequest(req)
+			m.state = stateMain
+			return m, nil
+		}})
+	}
+
+	for i := range m.environments {
+		items = append(items, paletteItem{kind: "Environment", title: m.environments[i].Label(), run: func(m model) (tea.Model, tea.Cmd) {
+			m.envIndex = i
+			m.state = stateMain
+			return m, nil
+		}})
+	}
+	if len(m.environments) > 0 {
+		items = append(items, paletteItem{kind: "Environment", title: "No environment", run: func(m model) (tea.Model, tea.Cmd) {
+			m.envIndex = -1
+			m.state = stateMain
+			return m, nil
+		}})
+	}
+
+	for _, e := range m.history {
+		h := historyItem{entry: e}
+		items = append(items, paletteItem{kind: "History", title: h.Title() + " • " + h.Description(), run: func(m model) (tea.Model, tea.Cmd) {
+			m.setRequest(e.Request)
+			m.response = e.Response
+			m.responseView.SetContent(formatResponse(e.Request, e.Response))
+			m.responseView.GotoTop()
+			m.state = stateViewResponse
+			return m, nil
+		}})
+	}
+	return items
+}
+
+// canOpenPalette reports whether the palette can be opened from a state;
+// prompts keep their keys for typing
+func canOpenPalette(state int) bool {
+	switch state {
+	case stateMain, stateEditRequest, stateViewResponse, stateLoadRequest, stateRunResults, stateHistory, stateDiff, stateListen:
+		return true
+	}
+	return false
+}
+
+// openPalette shows the command palette over the current state
+func (m *model) openPalette() tea.Cmd {
+	m.paletteReturn = m.state
+	m.palette = m.buildPalette()
+	m.promptInput.SetValue("")
+	m.promptInput.Placeholder = "Type to search actions, requests, environments and history"
+	m.promptInput.Focus()
+	m.filterPalette()
+	m.state = stateCommandPalette
+	// History is read when needed, so fetch it for searching
+	return tea.Batch(loadHistory, textinput.Blink)
+}
+
+// closePalette returns to the state the palette was opened from
+func (m *model) closePalette() {
+	m.promptInput.Blur()
+	m.promptInput.Placeholder = ""
+	m.state = m.paletteReturn
+}
+
+// filterPalette fuzzy matches the palette entries against the query, best first
+func (m *model) filterPalette() {
+	query := strings.TrimSpace(m.promptInput.Value())
+	if query == "" {
+		m.paletteMatches = make(fuzzy.Matches, len(m.palette))
+		for i := range m.palette {
+			m.paletteMatches[i] = fuzzy.Match{Str: m.palette[i].title, Index: i}
+		}
+	} else {
+		m.paletteMatches = fuzzy.FindFrom(query, m.palette)
+	}
+	m.paletteCursor = 0
+}
+
+// handlePaletteKey moves through and runs the palette's matches, passing
+// other keys to the search input
+func (m model) handlePaletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel), key.Matches(msg, m.keys.Palette.Open) && msg.Type != tea.KeyRunes:
+		m.closePalette()
+		return m, nil
+	case key.Matches(msg, m.keys.Palette.Up):
+		if m.paletteCursor > 0 {
+			m.paletteCursor--
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.Palette.Down):
+		if m.paletteCursor < len(m.paletteMatches)-1 {
+			m.paletteCursor++
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.Confirm):
+		if len(m.paletteMatches) == 0 {
+			return m, nil
+		}
+		item := m.palette[m.paletteMatches[m.paletteCursor].Index]
+		m.closePalette()
+		m.status = ""
+		return item.run(m)
+	}
+
+	var cmd tea.Cmd
+	query := m.promptInput.Value()
+	m.promptInput, cmd = m.promptInput.Update(msg)
+	if m.promptInput.Value() != query {
+		m.filterPalette()
+	}
+	return m, cmd
+}
+
+// highlightMatch emphasises the characters of a title matched by the query
+func highlightMatch(match fuzzy.Match) string {
+	matched := make(map[int]bool, len(match.MatchedIndexes))
+	for _, i := range match.MatchedIndexes {
+		matched[i] = true
+	}
+	var b strings.Builder
+	for i, r := range match.Str {
+		if matched[i] {
+			b.WriteString(paletteMatchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// paletteView renders the search input and the best matches
+func (m model) paletteView() string {
+	s := titleStyle.Render("Command Palette")
+	s += "\n\n"
+	s += focusedInputStyle.Render(m.promptInput.View()) + "\n\n"
+
+	if len(m.paletteMatches) == 0 {
+		s += "  No matches\n"
+	}
+	// Scroll so the selected match stays in view
+	start := max(0, m.paletteCursor-maxPaletteRows+1)
+	end := min(len(m.paletteMatches), start+maxPaletteRows)
+	for i := start; i < end; i++ {
+		match := m.paletteMatches[i]
+		item := m.palette[match.Index]
+		row := paletteKindStyle.Render(item.kind) + highlightMatch(match)
+		if item.hint != "" {
+			row += helpStyle.Render("  " + item.hint)
+		}
+		if i == m.paletteCursor {
+			s += selectedItemStyle.Render("> "+row) + "\n"
+		} else {
+			s += itemStyle.Render(row) + "\n"
+		}
+	}
+	if len(m.paletteMatches) > end {
+		s += helpStyle.Render(fmt.Sprintf("    … %d more", len(m.paletteMatches)-end)) + "\n"
+	}
+
+	s += "\n"
+	s += helpLine(withHelp(m.keys.Palette.Up, "Previous"), withHelp(m.keys.Palette.Down, "Next"),
+		withHelp(m.keys.Confirm, "Run"), withHelp(m.keys.Cancel, "Close"))
+	return s
+}
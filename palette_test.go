@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// typeQuery types a search into the command palette
+func typeQuery(m model, query string) model {
+	for _, r := range query {
+		m, _ = pressKey(m, string(r))
+	}
+	return m
+}
+
+// TestCommandPalette tests that the palette fuzzy finds and runs actions, requests, environments and history
+func TestCommandPalette(t *testing.T) {
+	m := initialModel()
+	m.savedRequests = []HTTPRequest{
+		{Name: "List users", Method: "GET", URL: "http://example.test/users", Folder: "users"},
+		{Name: "Create order", Method: "POST", URL: "http://example.test/orders"},
+	}
+	m.environments = []Environment{{Name: "staging"}, {Name: "production"}}
+
+	m, cmd := pressKey(m, "ctrl+p")
+	if m.state != stateCommandPalette || cmd == nil {
+		t.Fatalf("Expected ctrl+p to open the palette, got state %d", m.state)
+	}
+	if strings.Contains(m.View(), "Send request") {
+		t.Errorf("Expected no send action without a request to send")
+	}
+
+	// Saved requests open in the client
+	m = typeQuery(m, "lstusr")
+	if len(m.paletteMatches) == 0 || m.palette[m.paletteMatches[0].Index].title != "users/List users" {
+		t.Fatalf("Expected the saved request to match best, got %v", m.paletteMatches)
+	}
+	m, _ = pressKey(m, "enter")
+	if m.state != stateMain || m.currentRequest.URL != "http://example.test/users" {
+		t.Errorf("Expected the saved request to be opened, got state %d with %q", m.state, m.currentRequest.URL)
+	}
+
+	// Environments are selected
+	m, _ = pressKey(m, "ctrl+p")
+	m = typeQuery(m, "prod")
+	m, _ = pressKey(m, "enter")
+	if m.environment().Name != "production" {
+		t.Errorf("Expected the production environment, got %q", m.environment().Name)
+	}
+
+	// Actions run as if their key was pressed
+	m, _ = pressKey(m, "ctrl+p")
+	m = typeQuery(m, "send")
+	m, cmd = pressKey(m, "enter")
+	if !m.loading || cmd == nil {
+		t.Errorf("Expected the send action to send the request")
+	}
+	m.loading = false
+
+	// History entries arrive after the palette opens and show their response
+	m, _ = pressKey(m, "ctrl+p")
+	entry := HistoryEntry{Time: time.Now(), Request: HTTPRequest{Method: "DELETE", URL: "http://example.test/orders/7"},
+		Response: HTTPResponse{StatusCode: 204, Status: "204 No Content"}}
+	updated, _ := m.Update(historyMsg{entry})
+	m = updated.(model)
+	m = typeQuery(m, "delete orders")
+	m, _ = pressKey(m, "enter")
+	if m.state != stateViewResponse || m.response.StatusCode != 204 {
+		t.Errorf("Expected the history entry's response, got state %d with %d", m.state, m.response.StatusCode)
+	}
+
+	// Escape returns to where the palette was opened
+	m, _ = pressKey(m, "ctrl+p")
+	m = typeQuery(m, "zzzz")
+	if !strings.Contains(m.View(), "No matches") {
+		t.Errorf("Expected no matches for an unknown query")
+	}
+	m, _ = pressKey(m, "esc")
+	if m.state != stateViewResponse {
+		t.Errorf("Expected esc to return to the response, got state %d", m.state)
+	}
+}
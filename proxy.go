@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultProxyAddr is where the recording proxy listens unless told otherwise
+const defaultProxyAddr = "localhost:8888"
+
+// CA files written to the proxy's CA directory
+const (
+	proxyCACert = "whelm-ca.pem"
+	proxyCAKey  = "whelm-ca-key.pem"
+)
+
+// hopHeaders only apply to a single connection and are not forwarded
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// proxyFilter selects the hosts whose exchanges are recorded. Hosts also
+// match their subdomains; everything is recorded when include is empty.
+type proxyFilter struct {
+	include listFlag
+	exclude listFlag
+}
+
+func (f proxyFilter) match(host string) bool {
+	if inDomains(host, f.exclude) {
+		return false
+	}
+	return len(f.include) == 0 || inDomains(host, f.include)
+}
+
+// recordingProxy is a forward HTTP proxy that logs exchanges into history.
+// With a CA it intercepts CONNECT tunnels to recorded hosts and decrypts
+// them; other tunnels are passed through untouched.
+type recordingProxy struct {
+	transport *http.Transport
+	filter    proxyFilter
+	ca        *tls.Certificate
+	recorded  chan HistoryEntry
+
+	mu    sync.Mutex // guards certs and writes to the history file
+	certs map[string]*tls.Certificate
+}
+
+func newRecordingProxy(filter proxyFilter, ca *tls.Certificate) *recordingProxy {
+	return &recordingProxy{
+		// Compression is left to the client so its Accept-Encoding passes through
+		transport: &http.Transport{DisableCompression: true, TLSClientConfig: &tls.Config{}},
+		filter:    filter,
+		ca:        ca,
+		recorded:  make(chan HistoryEntry, 256),
+		certs:     make(map[string]*tls.Certificate),
+	}
+}
+
+func (p *recordingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.connect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "whelm proxy: expected an absolute URL, configure this address as an HTTP proxy", http.StatusBadRequest)
+		return
+	}
+	p.forward(w, r)
+}
+
+// forward sends a request upstream, copies the response back and records the exchange
+func (p *recordingProxy) forward(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out.Header = r.Header.Clone()
+	removeHopHeaders(out.Header)
+
+	entry := HistoryEntry{Time: time.Now(), Request: proxiedRequest(out, body)}
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		entry.Response = HTTPResponse{Error: err.Error(), Duration: time.Since(entry.Time)}
+		p.record(r.URL.Hostname(), entry)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	entry.Response = proxiedResponse(resp, respBody, time.Since(entry.Time))
+	if err != nil {
+		entry.Response.Error = err.Error()
+	}
+
+	removeHopHeaders(resp.Header)
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+	p.record(r.URL.Hostname(), entry)
+}
+
+// record appends an exchange to history when its host passes the filter
+func (p *recordingProxy) record(host string, entry HistoryEntry) {
+	if !p.filter.match(host) {
+		return
+	}
+	p.mu.Lock()
+	err := appendHistory(entry)
+	p.mu.Unlock()
+	if err != nil {
+		entry.Response.Error = err.Error()
+	}
+	select {
+	case p.recorded <- entry:
+	default:
+	}
+}
+
+// connect handles a CONNECT tunnel, intercepting it when a CA is configured
+func (p *recordingProxy) connect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "whelm proxy: tunnelling is not supported", http.StatusInternalServerError)
+		return
+	}
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host, port = r.Host, "443"
+	}
+
+	var upstream net.Conn
+	intercept := p.ca != nil && p.filter.match(host)
+	if !intercept {
+		if upstream, err = net.DialTimeout("tcp", net.JoinHostPort(host, port), 30*time.Second); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		if upstream != nil {
+			upstream.Close()
+		}
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		conn.Close()
+		return
+	}
+
+	if !intercept {
+		tunnel(conn, upstream)
+		return
+	}
+
+	// Serve the decrypted connection, sending its requests on to the real host
+	authority := host
+	if port != "443" {
+		authority = net.JoinHostPort(host, port)
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return p.certificate(host) },
+		NextProtos:     []string{"http/1.1"},
+	})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Scheme, r.URL.Host = "https", authority
+		p.forward(w, r)
+	})}
+	server.Serve(&singleConnListener{conn: tlsConn})
+}
+
+// tunnel copies bytes both ways until either side closes
+func tunnel(client, upstream net.Conn) {
+	done := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go pipe(upstream, client)
+	go pipe(client, upstream)
+	<-done
+	client.Close()
+	upstream.Close()
+}
+
+// singleConnListener hands a single connection to an http.Server
+type singleConnListener struct {
+	conn net.Conn
+	once sync.Once
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	if conn == nil {
+		return nil, io.EOF
+	}
+	return conn, nil
+}
+
+func (l *singleConnListener) Close() error   { return nil }
+func (l *singleConnListener) Addr() net.Addr { return l.conn.LocalAddr() }
+
+func removeHopHeaders(h http.Header) {
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// proxiedRequest converts a forwarded request for history, leaving out the
+// headers the client sets again when it is re-sent
+func proxiedRequest(r *http.Request, body []byte) HTTPRequest {
+	headers := make(map[string]string)
+	for k, v := range r.Header {
+		switch strings.ToLower(k) {
+		case "content-length", "accept-encoding":
+			continue
+		}
+		headers[k] = strings.Join(v, ", ")
+	}
+	return HTTPRequest{Method: r.Method, URL: r.URL.String(), Headers: headers, Body: string(body)}
+}
+
+// proxiedResponse converts an upstream response for history, decompressing
+// gzip bodies so they can be read
+func proxiedResponse(resp *http.Response, body []byte, duration time.Duration) HTTPResponse {
+	headers := make(map[string]string)
+	for k, v := range resp.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		if zr, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+			if decoded, err := io.ReadAll(zr); err == nil {
+				body = decoded
+				delete(headers, "Content-Encoding")
+				delete(headers, "Content-Length")
+			}
+		}
+	}
+	return HTTPResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    headers,
+		Body:       string(body),
+		Duration:   duration,
+	}
+}
+
+// certificate returns a certificate for host signed by the proxy's CA
+func (p *recordingProxy) certificate(host string) (*tls.Certificate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cert, ok := p.certs[host]; ok {
+		return cert, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(0, 0, 365),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca.Leaf, &key.PublicKey, p.ca.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	cert := &tls.Certificate{Certificate: [][]byte{der, p.ca.Certificate[0]}, PrivateKey: key}
+	p.certs[host] = cert
+	return cert, nil
+}
+
+// loadOrCreateCA reads the proxy's CA from dir, generating it on first use
+func loadOrCreateCA(dir string) (*tls.Certificate, error) {
+	certPath, keyPath := filepath.Join(dir, proxyCACert), filepath.Join(dir, proxyCAKey)
+	if _, err := os.Stat(certPath); os.IsNotExist(err) {
+		if err := createCA(certPath, keyPath); err != nil {
+			return nil, err
+		}
+	}
+	ca, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if ca.Leaf, err = x509.ParseCertificate(ca.Certificate[0]); err != nil {
+		return nil, err
+	}
+	return &ca, nil
+}
+
+func createCA(certPath, keyPath string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "whelm proxy CA", Organization: []string{"whelm"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certPath), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+func randomSerial() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}
+
+// proxyCommand runs the recording proxy, printing each recorded exchange
+func proxyCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("proxy", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", defaultProxyAddr, "address to listen on")
+	mitm := fs.Bool("mitm", false, "decrypt HTTPS to recorded hosts using the whelm CA")
+	caDir := fs.String("ca", "", "directory of the CA certificate and key (default ca/ in the personal workspace)")
+	var filter proxyFilter
+	fs.Var(&filter.include, "domain", "only record requests to this host or its subdomains")
+	fs.Var(&filter.exclude, "exclude", "never record requests to this host or its subdomains")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var ca *tls.Certificate
+	if *mitm {
+		dir := *caDir
+		if dir == "" {
+			root, err := personalWorkspaceRoot()
+			if err != nil {
+				fmt.Fprintf(stderr, "error: %v\n", err)
+				return 1
+			}
+			dir = filepath.Join(root, "ca")
+		}
+		var err error
+		if ca, err = loadOrCreateCA(dir); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Decrypting HTTPS with the CA in %s; trust %s in the client\n", dir, proxyCACert)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	proxy := newRecordingProxy(filter, ca)
+	go http.Serve(listener, proxy)
+
+	fmt.Fprintf(stdout, "Proxying on http://%s, recording into %s\n", listener.Addr(), displayPath(historyFile))
+	for e := range proxy.recorded {
+		if e.Response.Error != "" {
+			fmt.Fprintf(stdout, "%s ERR %s %s: %s\n", e.Time.Format(time.RFC3339), e.Request.Method, e.Request.URL, e.Response.Error)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s %d %s %s (%dms)\n", e.Time.Format(time.RFC3339), e.Response.StatusCode,
+			e.Request.Method, e.Request.URL, e.Response.Duration.Milliseconds())
+	}
+	return 0
+}
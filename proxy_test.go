@@ -0,0 +1,193 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// proxyClient returns a client sending its requests through the proxy at proxyURL
+func proxyClient(t *testing.T, proxyURL string, roots *x509.CertPool) *http.Client {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(u), TLSClientConfig: &tls.Config{RootCAs: roots}}}
+}
+
+// nextRecorded waits for the proxy to record an exchange
+func nextRecorded(t *testing.T, proxy *recordingProxy) HistoryEntry {
+	t.Helper()
+	select {
+	case e := <-proxy.recorded:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a recorded exchange")
+		return HistoryEntry{}
+	}
+}
+
+// TestRecordingProxy tests that proxied exchanges are forwarded and recorded into history unless filtered out
+func TestRecordingProxy(t *testing.T) {
+	chdirTemp(t)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusCreated)
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(`{"echo": "` + string(body) + `", "token": "` + r.Header.Get("X-Token") + `"}`))
+		zw.Close()
+	}))
+	defer target.Close()
+
+	proxy := newRecordingProxy(proxyFilter{}, nil)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+	client := proxyClient(t, server.URL, nil)
+
+	req, _ := http.NewRequest("POST", target.URL+"/orders?page=2", strings.NewReader("hi"))
+	req.Header.Set("X-Token", "abc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 201 || string(body) != `{"echo": "hi", "token": "abc"}` {
+		t.Errorf("Expected the upstream response through the proxy, got %d %q", resp.StatusCode, body)
+	}
+
+	e := nextRecorded(t, proxy)
+	if e.Request.Method != "POST" || e.Request.URL != target.URL+"/orders?page=2" || e.Request.Body != "hi" {
+		t.Errorf("Expected the recorded request, got %s %s %q", e.Request.Method, e.Request.URL, e.Request.Body)
+	}
+	if e.Request.Headers["X-Token"] != "abc" {
+		t.Errorf("Expected recorded request headers, got %v", e.Request.Headers)
+	}
+	if e.Response.StatusCode != 201 || e.Response.Body != `{"echo": "hi", "token": "abc"}` {
+		t.Errorf("Expected the decompressed response to be recorded, got %d %q", e.Response.StatusCode, e.Response.Body)
+	}
+	if _, ok := e.Response.Headers["Content-Encoding"]; ok {
+		t.Errorf("Expected Content-Encoding to be dropped from the recorded response")
+	}
+
+	history, err := readHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Request.URL != e.Request.URL {
+		t.Fatalf("Expected 1 history entry, got %d", len(history))
+	}
+
+	// Excluded hosts are still proxied but not recorded
+	proxy.filter = proxyFilter{exclude: listFlag{"127.0.0.1"}}
+	resp, err = client.Get(target.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 201 {
+		t.Errorf("Expected excluded host to be proxied, got %d", resp.StatusCode)
+	}
+	if history, _ := readHistory(); len(history) != 1 {
+		t.Errorf("Expected excluded host not to be recorded, got %d entries", len(history))
+	}
+}
+
+// TestProxyFilter tests that hosts are recorded by include and exclude domains
+func TestProxyFilter(t *testing.T) {
+	f := proxyFilter{include: listFlag{"example.com"}, exclude: listFlag{"cdn.example.com"}}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"api.example.com", true},
+		{"cdn.example.com", false},
+		{"img.cdn.example.com", false},
+		{"example.org", false},
+	}
+	for _, tt := range tests {
+		if got := f.match(tt.host); got != tt.want {
+			t.Errorf("Expected match(%q) = %v, got %v", tt.host, tt.want, got)
+		}
+	}
+	if !(proxyFilter{}).match("anything.test") {
+		t.Errorf("Expected an empty filter to record every host")
+	}
+}
+
+// TestRecordingProxyMITM tests that HTTPS is decrypted with the generated CA and recorded
+func TestRecordingProxyMITM(t *testing.T) {
+	chdirTemp(t)
+
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret " + r.URL.Path))
+	}))
+	defer target.Close()
+
+	caDir := filepath.Join(t.TempDir(), "ca")
+	ca, err := loadOrCreateCA(caDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(caDir, proxyCAKey)); err != nil {
+		t.Errorf("Expected the CA key to be written: %v", err)
+	}
+	again, err := loadOrCreateCA(caDir)
+	if err != nil || !again.Leaf.Equal(ca.Leaf) {
+		t.Errorf("Expected the CA to be reused, got %v", err)
+	}
+
+	proxy := newRecordingProxy(proxyFilter{}, ca)
+	upstreamRoots := x509.NewCertPool()
+	upstreamRoots.AddCert(target.Certificate())
+	proxy.transport.TLSClientConfig.RootCAs = upstreamRoots
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	resp, err := proxyClient(t, server.URL, roots).Get(target.URL + "/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "secret /users/1" {
+		t.Errorf("Expected the upstream body through the intercepted tunnel, got %q", body)
+	}
+
+	e := nextRecorded(t, proxy)
+	if e.Request.URL != target.URL+"/users/1" || e.Response.Body != "secret /users/1" {
+		t.Errorf("Expected the decrypted exchange to be recorded, got %s %q", e.Request.URL, e.Response.Body)
+	}
+
+	// Without interception the tunnel is passed through and nothing is recorded
+	proxy.filter = proxyFilter{exclude: listFlag{"127.0.0.1"}}
+	upstreamClient := proxyClient(t, server.URL, upstreamRoots)
+	resp, err = upstreamClient.Get(target.URL + "/users/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "secret /users/2" {
+		t.Errorf("Expected the tunnelled body, got %q", body)
+	}
+	if history, _ := readHistory(); len(history) != 1 {
+		t.Errorf("Expected only the intercepted exchange in history, got %d", len(history))
+	}
+}
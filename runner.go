@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RunOptions configures a collection run
+type RunOptions struct {
+	Environment   Environment
+	Concurrency   int
+	StopOnFailure bool
+	Snapshots     string
+}
+
+// RunResult is the outcome of one request in a collection run
+type RunResult struct {
+	Request  HTTPRequest     `json:"request"`
+	Response HTTPResponse    `json:"response"`
+	Error    string          `json:"error,omitempty"`
+	Skipped  bool            `json:"skipped,omitempty"`
+	Snapshot *SnapshotResult `json:"snapshot,omitempty"`
+}
+
+// Passed reports whether the request completed, all of its assertions passed
+// and the response matched its OpenAPI spec and snapshot
+func (r RunResult) Passed() bool {
+	return !r.Skipped && r.Error == "" && assertionsPassed(r.Response.Assertions) && r.Response.Contract.Passed() && r.Snapshot.Passed()
+}
+
+// CollectionResult is the outcome of running a collection of requests
+type CollectionResult struct {
+	Name        string        `json:"name"`
+	Environment string        `json:"environment,omitempty"`
+	Started     time.Time     `json:"started"`
+	Duration    time.Duration `json:"duration"`
+	Results     []RunResult   `json:"results"`
+}
+
+// Counts returns the number of passed, failed and skipped requests
+func (c CollectionResult) Counts() (passed, failed, skipped int) {
+	for _, r := range c.Results {
+		switch {
+		case r.Skipped:
+			skipped++
+		case r.Passed():
+			passed++
+		default:
+			failed++
+		}
+	}
+	return passed, failed, skipped
+}
+
+type collectionResultMsg CollectionResult
+
+// runCollection sends the requests in order, at most opts.Concurrency at a
+// time. Request variables are overridden by the environment. Variables
+// extracted from a response are visible to every request started after it
+// completes. With StopOnFailure no further requests are started once one
+// has failed; those are reported as skipped. With Snapshots set, responses
+// are compared with their snapshots.
+func runCollection(name string, requests []HTTPRequest, opts RunOptions) CollectionResult {
+	result := CollectionResult{
+		Name:        name,
+		Environment: opts.Environment.Name,
+		Started:     time.Now(),
+		Results:     make([]RunResult, len(requests)),
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed bool
+		vars   = mergeVariables(opts.Environment.Variables)
+		slots  = make(chan struct{}, concurrency)
+	)
+
+	for i, req := range requests {
+		slots <- struct{}{}
+
+		mu.Lock()
+		stop := failed && opts.StopOnFailure
+		resolved := applyVariables(req, mergeVariables(req.Variables, vars))
+		mu.Unlock()
+
+		if stop {
+			<-slots
+			result.Results[i] = RunResult{Request: req, Skipped: true}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, req HTTPRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			run := RunResult{Request: req}
+			resp, err := executeRequest(req)
+			if err != nil {
+				run.Error = err.Error()
+			} else {
+				run.Response = resp
+				extracted, err := extractVariables(req.Extract, resp)
+				if err != nil {
+					run.Error = err.Error()
+				}
+				if opts.Snapshots != "" {
+					if run.Snapshot, err = checkSnapshot(req, resp, opts.Snapshots); err != nil {
+						run.Error = err.Error()
+					}
+				}
+				mu.Lock()
+				for k, v := range extracted {
+					vars[k] = v
+				}
+				mu.Unlock()
+			}
+
+			mu.Lock()
+			if !run.Passed() {
+				failed = true
+			}
+			result.Results[i] = run
+			mu.Unlock()
+		}(i, resolved)
+	}
+
+	wg.Wait()
+	result.Duration = time.Since(result.Started)
+	return result
+}
+
+func runCollectionCmd(name string, requests []HTTPRequest, opts RunOptions) tea.Cmd {
+	return func() tea.Msg {
+		return collectionResultMsg(runCollection(name, requests, opts))
+	}
+}
+
+type reportsWrittenMsg string
+
+// reportsDir is where the interactive client writes collection reports
+var reportsDir = "reports"
+
+// collectionRows builds the rows of the run results table
+func collectionRows(c CollectionResult) []table.Row {
+	var rows []table.Row
+	for i, r := range c.Results {
+		status, elapsed, outcome := "", "", "PASS"
+		if r.Response.StatusCode != 0 {
+			status = strconv.Itoa(r.Response.StatusCode)
+			elapsed = fmt.Sprintf("%dms", r.Response.Duration.Milliseconds())
+		}
+		switch {
+		case r.Skipped:
+			outcome = "SKIP"
+		case r.Error != "":
+			outcome = "FAIL " + r.Error
+		case !r.Passed():
+			failed := 0
+			for _, a := range r.Response.Assertions {
+				if !a.Passed {
+					failed++
+				}
+			}
+			outcome = fmt.Sprintf("FAIL %d/%d assertions", failed, len(r.Response.Assertions))
+			switch {
+			case failed > 0:
+			case !r.Response.Contract.Passed():
+				outcome = fmt.Sprintf("FAIL %d contract violations", len(r.Response.Contract.Violations))
+			default:
+				outcome = "FAIL snapshot " + r.Snapshot.Status
+			}
+		case r.Snapshot != nil && r.Snapshot.Status != snapshotMatched:
+			outcome = "PASS snapshot " + r.Snapshot.Status
+		}
+		rows = append(rows, table.Row{strconv.Itoa(i + 1), r.Request.Name, r.Request.Method, status, elapsed, outcome})
+	}
+	return rows
+}
+
+// writeReports writes JUnit and JSON reports of a collection run into reportsDir
+func writeReports(c CollectionResult) tea.Cmd {
+	return func() tea.Msg {
+		if err := os.MkdirAll(reportsDir, 0755); err != nil {
+			return errMsg{err}
+		}
+		if err := writeReportFile(filepath.Join(reportsDir, "junit.xml"), c, writeJUnitReport); err != nil {
+			return errMsg{err}
+		}
+		if err := writeReportFile(filepath.Join(reportsDir, "report.json"), c, writeJSONReport); err != nil {
+			return errMsg{err}
+		}
+		return reportsWrittenMsg(reportsDir)
+	}
+}
+
+// JUnit XML report structure, as understood by common CI systems
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the collection result as JUnit XML
+func writeJUnitReport(w io.Writer, c CollectionResult) error {
+	suite := junitTestSuite{
+		Name:      c.Name,
+		Tests:     len(c.Results),
+		Time:      fmt.Sprintf("%.3f", c.Duration.Seconds()),
+		Timestamp: c.Started.Format(time.RFC3339),
+	}
+
+	for _, r := range c.Results {
+		tc := junitTestCase{
+			Name:      r.Request.Name,
+			ClassName: c.Name,
+			Time:      fmt.Sprintf("%.3f", r.Response.Duration.Seconds()),
+		}
+		switch {
+		case r.Skipped:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: "skipped after earlier failure"}
+		case r.Error != "":
+			suite.Errors++
+			tc.Error = &junitMessage{Message: r.Error}
+		case !r.Passed():
+			suite.Failures++
+			tc.Failure = &junitMessage{Message: "assertions failed"}
+			if assertionsPassed(r.Response.Assertions) {
+				tc.Failure.Message = "contract violated"
+				if r.Response.Contract.Passed() {
+					tc.Failure.Message = "snapshot changed"
+				}
+			}
+			for _, a := range r.Response.Assertions {
+				if !a.Passed {
+					tc.Failure.Text += fmt.Sprintf("%s: %s\n", a.Assertion, a.Message)
+				}
+			}
+			if c := r.Response.Contract; !c.Passed() {
+				for _, v := range c.Violations {
+					tc.Failure.Text += fmt.Sprintf("contract %s: %s\n", c.Operation, v)
+				}
+			}
+			if s := r.Snapshot; !s.Passed() {
+				if s.Diff != nil {
+					tc.Failure.Text += fmt.Sprintf("snapshot %s:\n%s", s.Path, diffSummary(*s.Diff))
+				} else {
+					tc.Failure.Text += fmt.Sprintf("snapshot %s: %s\n", s.Path, s.Status)
+				}
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeJSONReport writes the collection result as indented JSON
+func writeJSONReport(w io.Writer, c CollectionResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// writeReportFile creates path and writes a report into it
+func writeReportFile(path string, c CollectionResult, write func(io.Writer, CollectionResult) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, c); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRunCollectionChainsVariables tests that variables extracted by one request feed the next
+func TestRunCollectionChainsVariables(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Write([]byte(`{"token": "t0k3n"}`))
+		case "/me":
+			if r.Header.Get("Authorization") != "Bearer t0k3n" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"name": "ada"}`))
+		}
+	}))
+	defer server.Close()
+
+	requests := []HTTPRequest{
+		{Name: "login", Method: "POST", URL: "{{base}}/login", Extract: map[string]string{"token": "$.token"}},
+		{Name: "me", Method: "GET", URL: "{{base}}/me", Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+			Assertions: []Assertion{{Type: assertStatus, Value: "200"}}},
+	}
+
+	result := runCollection("auth", requests, RunOptions{
+		Environment: Environment{Name: "local", Variables: map[string]string{"base": server.URL}},
+	})
+
+	passed, failed, _ := result.Counts()
+	if passed != 2 || failed != 0 {
+		t.Errorf("Expected 2 passed and 0 failed, got %d passed and %d failed", passed, failed)
+		for _, r := range result.Results {
+			t.Logf("%s: %d %s %v", r.Request.Name, r.Response.StatusCode, r.Error, r.Response.Assertions)
+		}
+	}
+	if result.Environment != "local" {
+		t.Errorf("Expected environment to be recorded, got %q", result.Environment)
+	}
+}
+
+// TestRunCollectionStopOnFailure tests that requests after a failure are skipped when configured
+func TestRunCollectionStopOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	requests := []HTTPRequest{
+		{Name: "first", Method: "GET", URL: server.URL, Assertions: []Assertion{{Type: assertStatus, Value: "200"}}},
+		{Name: "second", Method: "GET", URL: server.URL},
+		{Name: "third", Method: "GET", URL: server.URL},
+	}
+
+	result := runCollection("broken", requests, RunOptions{StopOnFailure: true})
+	passed, failed, skipped := result.Counts()
+	if passed != 0 || failed != 1 || skipped != 2 {
+		t.Errorf("Expected 0/1/2 passed/failed/skipped, got %d/%d/%d", passed, failed, skipped)
+	}
+
+	result = runCollection("broken", requests, RunOptions{Concurrency: 3})
+	if _, _, skipped := result.Counts(); skipped != 0 {
+		t.Errorf("Expected nothing to be skipped without StopOnFailure, got %d", skipped)
+	}
+}
+
+// TestWriteJUnitReport tests that failures, errors and skips are reported in JUnit XML
+func TestWriteJUnitReport(t *testing.T) {
+	result := CollectionResult{
+		Name: "suite",
+		Results: []RunResult{
+			{Request: HTTPRequest{Name: "ok"}, Response: HTTPResponse{StatusCode: 200}},
+			{Request: HTTPRequest{Name: "bad"}, Response: HTTPResponse{StatusCode: 500, Assertions: []AssertionResult{
+				{Assertion: Assertion{Type: assertStatus, Value: "200"}, Message: "got status 500"},
+			}}},
+			{Request: HTTPRequest{Name: "down"}, Error: "connection refused"},
+			{Request: HTTPRequest{Name: "later"}, Skipped: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeJUnitReport(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`<testsuite name="suite" tests="4" failures="1" errors="1" skipped="1"`,
+		`status == 200: got status 500`,
+		`<error message="connection refused">`,
+		`<skipped message=`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validateSchema checks a decoded JSON value against a JSON Schema and
+// returns one message per violation. It supports the commonly used
+// keywords: type, enum, const, properties, required, additionalProperties,
+// items, length and range limits, pattern, allOf/anyOf/oneOf/not and
+// local $ref pointers.
+func validateSchema(schema, value any) []string {
+	return validateAgainst(schema, value, schema, "$")
+}
+
+func validateAgainst(schema, value, root any, at string) []string {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		// true/false schemas
+		if b, ok := schema.(bool); ok && !b {
+			return []string{fmt.Sprintf("%s: no value allowed", at)}
+		}
+		return nil
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := resolveRef(root, ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", at, err)}
+		}
+		return validateAgainst(target, value, root, at)
+	}
+
+	var errs []string
+
+	if t, ok := s["type"]; ok && !matchesType(t, value, s) {
+		return []string{fmt.Sprintf("%s: expected %v, got %s", at, t, jsonTypeOf(value))}
+	}
+
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: %s is not one of %s", at, formatJSONValue(value), formatJSONValue(enum)))
+		}
+	}
+
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		errs = append(errs, fmt.Sprintf("%s: expected %s", at, formatJSONValue(c)))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		errs = append(errs, validateObject(s, v, root, at)...)
+	case []any:
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				errs = append(errs, validateAgainst(items, item, root, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+		if min, ok := s["minItems"].(float64); ok && float64(len(v)) < min {
+			errs = append(errs, fmt.Sprintf("%s: expected at least %v items", at, min))
+		}
+		if max, ok := s["maxItems"].(float64); ok && float64(len(v)) > max {
+			errs = append(errs, fmt.Sprintf("%s: expected at most %v items", at, max))
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := s["minLength"].(float64); ok && length < min {
+			errs = append(errs, fmt.Sprintf("%s: shorter than %v", at, min))
+		}
+		if max, ok := s["maxLength"].(float64); ok && length > max {
+			errs = append(errs, fmt.Sprintf("%s: longer than %v", at, max))
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				errs = append(errs, fmt.Sprintf("%s: does not match %s", at, pattern))
+			}
+		}
+	case float64:
+		if min, ok := s["minimum"].(float64); ok && v < min {
+			errs = append(errs, fmt.Sprintf("%s: %v is less than %v", at, v, min))
+		}
+		if max, ok := s["maximum"].(float64); ok && v > max {
+			errs = append(errs, fmt.Sprintf("%s: %v is greater than %v", at, v, max))
+		}
+		if min, ok := s["exclusiveMinimum"].(float64); ok && v <= min {
+			errs = append(errs, fmt.Sprintf("%s: %v is not greater than %v", at, v, min))
+		}
+		if max, ok := s["exclusiveMaximum"].(float64); ok && v >= max {
+			errs = append(errs, fmt.Sprintf("%s: %v is not less than %v", at, v, max))
+		}
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			errs = append(errs, validateAgainst(sub, value, root, at)...)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if len(validateAgainst(sub, value, root, at)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			errs = append(errs, fmt.Sprintf("%s: does not match any of anyOf", at))
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if len(validateAgainst(sub, value, root, at)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			errs = append(errs, fmt.Sprintf("%s: matches %d of oneOf, expected exactly 1", at, matches))
+		}
+	}
+	if not, ok := s["not"]; ok && len(validateAgainst(not, value, root, at)) == 0 {
+		errs = append(errs, fmt.Sprintf("%s: must not match schema", at))
+	}
+
+	return errs
+}
+
+func validateObject(s map[string]any, v map[string]any, root any, at string) []string {
+	var errs []string
+
+	if required, ok := s["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := v[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", at, name))
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+
+	// Iterate in a stable order so violations are reported deterministically
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := at + "." + k
+		if prop, ok := properties[k]; ok {
+			errs = append(errs, validateAgainst(prop, v[k], root, path)...)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				errs = append(errs, fmt.Sprintf("%s: unexpected property", path))
+			}
+		case map[string]any:
+			errs = append(errs, validateAgainst(additional, v[k], root, path)...)
+		}
+	}
+
+	return errs
+}
+
+func matchesType(t any, value any, s map[string]any) bool {
+	if value == nil {
+		// OpenAPI 3.0 marks optional nulls with nullable instead of a type list
+		if nullable, ok := s["nullable"].(bool); ok && nullable {
+			return true
+		}
+	}
+	switch t := t.(type) {
+	case string:
+		return typeMatches(t, value)
+	case []any:
+		for _, name := range t {
+			if n, ok := name.(string); ok && typeMatches(n, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func typeMatches(name string, value any) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonTypeOf(value) == name
+	}
+}
+
+func jsonTypeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// resolveRef follows a local JSON pointer such as #/definitions/User
+func resolveRef(root any, ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	current := root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if current, ok = obj[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestValidateSchema tests that schema keywords report violations
+func TestValidateSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+			"kind": {"enum": ["a", "b"]},
+			"tags": {"type": "array", "items": {"$ref": "#/definitions/tag"}, "maxItems": 2}
+		},
+		"definitions": {
+			"tag": {"type": "string"}
+		}
+	}`
+
+	tests := []struct {
+		doc        string
+		violations int
+	}{
+		{`{"id": 1, "name": "ada"}`, 0},
+		{`{"id": 1, "name": "ada", "kind": "a", "tags": ["x", "y"]}`, 0},
+		{`{"id": 1.5, "name": "ada"}`, 1},
+		{`{"id": 0, "name": "a"}`, 2},
+		{`{"name": "ADA"}`, 2},
+		{`{"id": 1, "name": "ada", "kind": "c"}`, 1},
+		{`{"id": 1, "name": "ada", "tags": [1, "y", "z"]}`, 2},
+		{`{"id": 1, "name": "ada", "extra": true}`, 1},
+		{`[]`, 1},
+	}
+
+	var s any
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		var doc any
+		if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if got := validateSchema(s, doc); len(got) != tt.violations {
+			t.Errorf("%s: expected %d violations, got %d: %v", tt.doc, tt.violations, len(got), got)
+		}
+	}
+}
+
+// TestValidateSchemaCombinators tests anyOf, oneOf and not
+func TestValidateSchemaCombinators(t *testing.T) {
+	var s any
+	if err := json.Unmarshal([]byte(`{
+		"anyOf": [{"type": "string"}, {"type": "number"}],
+		"oneOf": [{"type": "integer"}, {"minimum": 10}],
+		"not": {"const": 3}
+	}`), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := validateSchema(s, float64(5)); len(got) != 0 {
+		t.Errorf("Expected 5 to be valid, got %v", got)
+	}
+	if got := validateSchema(s, float64(12)); len(got) != 1 {
+		t.Errorf("Expected 12 to match both oneOf branches, got %v", got)
+	}
+	if got := validateSchema(s, float64(3)); len(got) != 1 {
+		t.Errorf("Expected 3 to be rejected by not, got %v", got)
+	}
+	if got := validateSchema(s, true); len(got) == 0 {
+		t.Errorf("Expected true to fail anyOf")
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A snapshot is the normalised response of a saved request, stored next to
+// it as <name>.snap.json. Values at the request's ignore paths are replaced
+// by redactedValue, so timestamps and generated IDs do not break the
+// comparison. Requests in .http files get <file>.<request>.snap.json.
+
+// Snapshot modes of a collection run
+const (
+	snapshotCheck  = "check"
+	snapshotUpdate = "update"
+	// snapshotCI fails requests without a snapshot instead of writing one
+	snapshotCI = "ci"
+)
+
+// Snapshot outcomes
+const (
+	snapshotMatched  = "matched"
+	snapshotChanged  = "changed"
+	snapshotNew      = "new"
+	snapshotMissing  = "missing"
+	snapshotUpdated  = "updated"
+	snapshotRejected = "rejected"
+)
+
+// redactedValue replaces ignored values in snapshots
+const redactedValue = "<redacted>"
+
+// snapshotHeaders are the response headers kept in snapshots; the rest vary between runs
+var snapshotHeaders = []string{"Content-Type"}
+
+// Snapshot is the stored form of a response
+type Snapshot struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	JSON    json.RawMessage   `json:"json,omitempty"`
+	Text    string            `json:"text,omitempty"`
+}
+
+// SnapshotResult is the outcome of comparing a response with its snapshot
+type SnapshotResult struct {
+	Path   string        `json:"path"`
+	Status string        `json:"status"`
+	Diff   *ResponseDiff `json:"diff,omitempty"`
+	Actual Snapshot      `json:"-"`
+}
+
+// Passed reports whether the response matched its snapshot, or the snapshot
+// was updated. A nil result means no snapshot was checked.
+func (s *SnapshotResult) Passed() bool {
+	return s == nil || (s.Status != snapshotChanged && s.Status != snapshotRejected && s.Status != snapshotMissing)
+}
+
+func isSnapshotFile(name string) bool {
+	return strings.HasSuffix(filepath.Base(name), ".snap.json")
+}
+
+// snapshotPath returns the snapshot file of a saved request, or "" for unsaved requests
+func snapshotPath(req HTTPRequest) string {
+	if req.Path == "" {
+		return ""
+	}
+	base := strings.TrimSuffix(req.Path, filepath.Ext(req.Path))
+	if isHTTPFile(req.Path) {
+		base += "." + slugify(req.Name)
+	}
+	return base + ".snap.json"
+}
+
+// newSnapshot normalises a response, redacting the JSON paths in ignore
+func newSnapshot(resp HTTPResponse, ignore []string) Snapshot {
+	s := Snapshot{Status: resp.StatusCode}
+	for _, name := range snapshotHeaders {
+		if v, ok := lookupHeader(resp.Headers, name); ok && !containsFold(ignore, name) {
+			if s.Headers == nil {
+				s.Headers = make(map[string]string)
+			}
+			s.Headers[name] = v
+		}
+	}
+
+	var doc any
+	if err := json.Unmarshal([]byte(resp.Body), &doc); err != nil {
+		s.Text = resp.Body
+		return s
+	}
+	for _, p := range ignore {
+		if strings.HasPrefix(strings.TrimSpace(p), "$") {
+			doc = redactJSONPath(doc, splitJSONPath(p))
+		}
+	}
+	// Re-encoding sorts object keys, so key order does not matter
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(doc)
+	s.JSON = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return s
+}
+
+// redactJSONPath replaces the values matching path, where * matches any key or index
+func redactJSONPath(doc any, path []string) any {
+	if len(path) == 0 {
+		return redactedValue
+	}
+	switch v := doc.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if path[0] == "*" || path[0] == k {
+				v[k] = redactJSONPath(child, path[1:])
+			}
+		}
+	case []any:
+		for i, child := range v {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				v[i] = redactJSONPath(child, path[1:])
+			}
+		}
+	}
+	return doc
+}
+
+// response converts a snapshot back to a response so snapshots can be diffed
+func (s Snapshot) response() HTTPResponse {
+	resp := HTTPResponse{
+		StatusCode: s.Status,
+		Status:     fmt.Sprintf("%d %s", s.Status, http.StatusText(s.Status)),
+		Headers:    s.Headers,
+		Body:       s.Text,
+	}
+	if len(s.JSON) > 0 {
+		resp.Body = string(s.JSON)
+	}
+	return resp
+}
+
+func readSnapshot(p string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("%s: %w", p, err)
+	}
+	return s, nil
+}
+
+func writeSnapshot(p string, s Snapshot) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	return os.WriteFile(p, buf.Bytes(), 0644)
+}
+
+// checkSnapshot compares a response with the request's snapshot. Missing
+// snapshots are written, except in CI mode where they fail; in update mode
+// changed ones are overwritten too.
+func checkSnapshot(req HTTPRequest, resp HTTPResponse, mode string) (*SnapshotResult, error) {
+	p := snapshotPath(req)
+	if p == "" {
+		return nil, nil
+	}
+	result := &SnapshotResult{Path: p, Actual: newSnapshot(resp, req.Ignore)}
+
+	stored, err := readSnapshot(p)
+	if os.IsNotExist(err) && mode == snapshotCI {
+		result.Status = snapshotMissing
+		return result, nil
+	}
+	if os.IsNotExist(err) {
+		result.Status = snapshotNew
+		return result, writeSnapshot(p, result.Actual)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := diffResponses(stored.response(), result.Actual.response(), nil)
+	switch {
+	case d.Empty():
+		result.Status = snapshotMatched
+	case mode == snapshotUpdate:
+		result.Status = snapshotUpdated
+		return result, writeSnapshot(p, result.Actual)
+	default:
+		result.Status = snapshotChanged
+		result.Diff = &d
+	}
+	return result, nil
+}
+
+// moveSnapshot renames the snapshot of a request that was renamed or moved
+func moveSnapshot(old, req HTTPRequest) error {
+	from, to := snapshotPath(old), snapshotPath(req)
+	if from == "" || from == to {
+		return nil
+	}
+	if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// snapshotReviewedMsg reports that a changed snapshot was accepted or rejected
+type snapshotReviewedMsg struct {
+	index  int
+	status string
+}
+
+// acceptSnapshot overwrites the snapshot of a run result with its actual response
+func acceptSnapshot(index int, s SnapshotResult) tea.Cmd {
+	return func() tea.Msg {
+		if err := writeSnapshot(s.Path, s.Actual); err != nil {
+			return errMsg{err}
+		}
+		return snapshotReviewedMsg{index: index, status: snapshotUpdated}
+	}
+}
+
+// nextChangedSnapshot returns the first result from start on whose snapshot changed, or -1
+func nextChangedSnapshot(results []RunResult, start int) int {
+	for i := start; i < len(results); i++ {
+		if s := results[i].Snapshot; s != nil && s.Status == snapshotChanged {
+			return i
+		}
+	}
+	return -1
+}
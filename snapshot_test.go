@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestRunCommandSnapshot tests that snapshots are written, compared with ignored paths redacted, and updated
+func TestRunCommandSnapshot(t *testing.T) {
+	chdirTemp(t)
+
+	name := "rex"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Date", time.Now().String())
+		w.Write([]byte(`{"name": "` + name + `", "at": "` + time.Now().Format(time.RFC3339Nano) + `"}`))
+	}))
+	defer server.Close()
+
+	runCmd(t, saveRequest(HTTPRequest{Name: "pet", Method: "GET", URL: server.URL, Ignore: []string{"$.at"}}))
+	snap := filepath.Join(requestsDir, "pet.snap.json")
+
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := runCLI(append([]string{"run"}, args...), &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+
+	if code, out := run("-snapshot"); code != 0 || !strings.Contains(out, "snapshot new") {
+		t.Fatalf("Expected a new snapshot, got %d:\n%s", code, out)
+	}
+	data, err := os.ReadFile(snap)
+	if err != nil || !strings.Contains(string(data), `"at": "<redacted>"`) {
+		t.Fatalf("Expected the ignored value to be redacted, got %s (%v)", data, err)
+	}
+
+	if requests, _, _ := readSavedRequests(); len(requests) != 1 {
+		t.Errorf("Expected snapshots not to be read as requests, got %d requests", len(requests))
+	}
+
+	if code, out := run("-snapshot"); code != 0 || !strings.Contains(out, "✓ snapshot\n") {
+		t.Errorf("Expected the snapshot to match, got %d:\n%s", code, out)
+	}
+
+	name = "fido"
+	if code, out := run("-snapshot"); code != 1 || !strings.Contains(out, `~ $.name: "rex" → "fido"`) {
+		t.Errorf("Expected a changed snapshot, got %d:\n%s", code, out)
+	}
+	if code, out := run("-update"); code != 0 || !strings.Contains(out, "snapshot updated") {
+		t.Errorf("Expected the snapshot to be updated, got %d:\n%s", code, out)
+	}
+	if code, out := run("-snapshot"); code != 0 {
+		t.Errorf("Expected the updated snapshot to match, got %d:\n%s", code, out)
+	}
+
+	// Snapshots follow renamed requests
+	requests, _, _ := readSavedRequests()
+	runCmd(t, renameRequest(requests[0], "dog"))
+	if _, err := os.Stat(filepath.Join(requestsDir, "dog.snap.json")); err != nil {
+		t.Errorf("Expected the snapshot to follow the rename: %v", err)
+	}
+
+	// Deleting a request takes its snapshot to the trash, and undo brings it back
+	dog := filepath.Join(requestsDir, "dog.snap.json")
+	requests, _, _ = readSavedRequests()
+	trashed := runCmd(t, trashRequest(requests[0])).(requestTrashedMsg)
+	if _, err := os.Stat(dog); !os.IsNotExist(err) {
+		t.Errorf("Expected the snapshot to be trashed with its request")
+	}
+	runCmd(t, restoreRequest(trashEntry(trashed)))
+	if _, err := os.Stat(dog); err != nil {
+		t.Errorf("Expected the snapshot to be restored: %v", err)
+	}
+
+	// In CI a missing snapshot fails instead of being written
+	if code, out := run("-ci"); code != 0 {
+		t.Errorf("Expected the snapshot to match in CI, got %d:\n%s", code, out)
+	}
+	if err := os.Remove(dog); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := run("-ci"); code != 1 || !strings.Contains(out, "✗ snapshot missing") {
+		t.Errorf("Expected a missing snapshot to fail in CI, got %d:\n%s", code, out)
+	}
+	if _, err := os.Stat(dog); !os.IsNotExist(err) {
+		t.Errorf("Expected no snapshot to be written in CI")
+	}
+}
+
+// TestSnapshotReview tests that changed snapshots can be accepted and rejected from the run results
+func TestSnapshotReview(t *testing.T) {
+	dir := t.TempDir()
+	changed := func(name string) RunResult {
+		p := filepath.Join(dir, name+".snap.json")
+		if err := writeSnapshot(p, Snapshot{Status: 200, Text: "old"}); err != nil {
+			t.Fatal(err)
+		}
+		d := diffResponses(HTTPResponse{Body: "old"}, HTTPResponse{Body: "new"}, nil)
+		return RunResult{
+			Request:  HTTPRequest{Name: name},
+			Response: HTTPResponse{StatusCode: 200, Body: "new"},
+			Snapshot: &SnapshotResult{Path: p, Status: snapshotChanged, Diff: &d, Actual: Snapshot{Status: 200, Text: "new"}},
+		}
+	}
+
+	m := initialModel()
+	updated, _ := m.Update(collectionResultMsg(CollectionResult{Results: []RunResult{changed("a"), {Request: HTTPRequest{Name: "b"}}, changed("c")}}))
+	m = updated.(model)
+
+	key := func(k string) {
+		t.Helper()
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = updated.(model)
+		if cmd != nil {
+			updated, _ = m.Update(cmd())
+			m = updated.(model)
+		}
+	}
+
+	key("s")
+	if m.state != stateReviewSnapshot || m.reviewIndex != 0 {
+		t.Fatalf("Expected to review the first changed snapshot, got state %d index %d", m.state, m.reviewIndex)
+	}
+	key("a")
+	if m.state != stateReviewSnapshot || m.reviewIndex != 2 {
+		t.Fatalf("Expected to move on to the next changed snapshot, got state %d index %d", m.state, m.reviewIndex)
+	}
+	key("r")
+	if m.state != stateRunResults {
+		t.Errorf("Expected to return to the results, got state %d", m.state)
+	}
+
+	if s, _ := readSnapshot(filepath.Join(dir, "a.snap.json")); s.Text != "new" {
+		t.Errorf("Expected the accepted snapshot to be written, got %q", s.Text)
+	}
+	if s, _ := readSnapshot(filepath.Join(dir, "c.snap.json")); s.Text != "old" {
+		t.Errorf("Expected the rejected snapshot to be kept, got %q", s.Text)
+	}
+	if _, failed, _ := m.collection.Counts(); failed != 1 {
+		t.Errorf("Expected only the rejected snapshot to fail, got %d failures", failed)
+	}
+}
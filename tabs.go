@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxTabTitle bounds the width of a title in the tab bar
+const maxTabTitle = 24
+
+// tab is a request being worked on. The active tab lives in the model's
+// own fields; the others are kept here until they are switched to.
+type tab struct {
+	id             int
+	state          int
+	currentRequest HTTPRequest
+	loadedRequest  HTTPRequest
+	response       HTTPResponse
+	loading        bool
+	responseView   viewport.Model
+	watch          *watcher
+	err            error
+}
+
+// isTabState reports whether a state belongs to a tab rather than the whole client
+func isTabState(state int) bool {
+	return state == stateMain || state == stateEditRequest || state == stateViewResponse
+}
+
+// stashTab saves the active tab's fields from the model
+func (m *model) stashTab() {
+	t := &m.tabs[m.activeTab]
+	if isTabState(m.state) {
+		t.state = m.state
+	}
+	t.currentRequest, t.loadedRequest = m.currentRequest, m.loadedRequest
+	t.response, t.loading = m.response, m.loading
+	t.responseView, t.watch, t.err = m.responseView, m.watch, m.err
+}
+
+// restoreTab loads the active tab's fields into the model, leaving the
+// editor inputs alone
+func (m *model) restoreTab() {
+	t := m.tabs[m.activeTab]
+	if isTabState(m.state) {
+		m.state = t.state
+	}
+	m.currentRequest, m.loadedRequest = t.currentRequest, t.loadedRequest
+	m.response, m.loading = t.response, t.loading
+	m.watch, m.err = t.watch, t.err
+
+	// Each tab keeps its scroll position, while the size follows the window
+	width, height := m.responseView.Width, m.responseView.Height
+	m.responseView = t.responseView
+	m.responseView.Width, m.responseView.Height = width, height
+}
+
+// switchTab makes tab i the active one
+func (m *model) switchTab(i int) tea.Cmd {
+	if i < 0 || i >= len(m.tabs) || i == m.activeTab {
+		return nil
+	}
+	m.stashTab()
+	m.activeTab = i
+	m.restoreTab()
+
+	// Fill the editor without losing track of the saved request it came from
+	loaded := m.loadedRequest
+	m.setRequest(m.currentRequest)
+	m.loadedRequest = loaded
+	m.status = ""
+	if m.loading {
+		return m.spinner.Tick
+	}
+	return nil
+}
+
+// newTab opens an empty tab after the active one and switches to it
+func (m *model) newTab() tea.Cmd {
+	m.nextTabID++
+	t := tab{
+		id:             m.nextTabID,
+		state:          stateMain,
+		currentRequest: HTTPRequest{Method: "GET", Headers: make(map[string]string)},
+		responseView:   viewport.New(m.responseView.Width, m.responseView.Height),
+	}
+	m.stashTab()
+	m.tabs = append(m.tabs[:m.activeTab+1], append([]tab{t}, m.tabs[m.activeTab+1:]...)...)
+	m.activeTab++
+	m.restoreTab()
+	m.state = stateMain
+	m.setRequest(m.currentRequest)
+	m.status = ""
+	return nil
+}
+
+// closeTab closes the active tab, keeping at least one open. Responses to
+// requests still in flight in it are dropped.
+func (m *model) closeTab() tea.Cmd {
+	if len(m.tabs) == 1 {
+		m.status = "The last tab cannot be closed"
+		return nil
+	}
+	if m.watch != nil {
+		m.watch.running = false
+	}
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	m.activeTab = min(m.activeTab, len(m.tabs)-1)
+	m.restoreTab()
+	loaded := m.loadedRequest
+	m.setRequest(m.currentRequest)
+	m.loadedRequest = loaded
+	m.status = ""
+	if m.loading {
+		return m.spinner.Tick
+	}
+	return nil
+}
+
+// moveTab moves the active tab by delta places
+func (m *model) moveTab(delta int) {
+	to := m.activeTab + delta
+	if to < 0 || to >= len(m.tabs) {
+		return
+	}
+	m.tabs[m.activeTab], m.tabs[to] = m.tabs[to], m.tabs[m.activeTab]
+	m.activeTab = to
+}
+
+// tabIndex returns the position of the tab with the given id, or -1 once it was closed
+func (m model) tabIndex(id int) int {
+	for i, t := range m.tabs {
+		if t.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// watchTab returns the position of the tab running a watch, or -1
+func (m model) watchTab(w *watcher) int {
+	if m.watch == w {
+		return m.activeTab
+	}
+	for i, t := range m.tabs {
+		if i != m.activeTab && t.watch == w {
+			return i
+		}
+	}
+	return -1
+}
+
+// inTab runs f with tab i loaded into the model, so messages for tabs in
+// the background are handled like those for the active one
+func (m *model) inTab(i int, f func() tea.Cmd) tea.Cmd {
+	if i == m.activeTab {
+		return f()
+	}
+	m.stashTab()
+	active, state, status := m.activeTab, m.state, m.status
+	m.activeTab, m.state = i, m.tabs[i].state
+	m.restoreTab()
+	cmd := f()
+	m.stashTab()
+	m.activeTab, m.state, m.status = active, state, status
+	m.restoreTab()
+	return cmd
+}
+
+// handleTabKey handles the keys that open, close, switch and reorder tabs
+func (m *model) handleTabKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Tabs.New):
+		return m.newTab(), true
+	case key.Matches(msg, m.keys.Tabs.Close):
+		return m.closeTab(), true
+	case key.Matches(msg, m.keys.Tabs.Next):
+		return m.switchTab((m.activeTab + 1) % len(m.tabs)), true
+	case key.Matches(msg, m.keys.Tabs.Previous):
+		return m.switchTab((m.activeTab + len(m.tabs) - 1) % len(m.tabs)), true
+	case key.Matches(msg, m.keys.Tabs.MoveRight):
+		m.moveTab(1)
+		return nil, true
+	case key.Matches(msg, m.keys.Tabs.MoveLeft):
+		m.moveTab(-1)
+		return nil, true
+	case key.Matches(msg, m.keys.Tabs.GoTo):
+		// The go to keys are the tabs in order
+		return m.switchTab(indexOf(msg.String(), m.keys.Tabs.GoTo.Keys())), true
+	}
+	return nil, false
+}
+
+// tabTitle describes a tab's request in the tab bar
+func tabTitle(req HTTPRequest) string {
+	title := req.Name
+	if title == "" && req.URL != "" {
+		title = req.URL
+		if u, err := url.Parse(req.URL); err == nil && u.Host != "" {
+			title = u.Host + u.Path
+		}
+		title = req.Method + " " + title
+	}
+	if title == "" {
+		title = "New request"
+	}
+	if r := []rune(title); len(r) > maxTabTitle {
+		title = string(r[:maxTabTitle-1]) + "…"
+	}
+	return title
+}
+
+// tabBar renders the open tabs, numbered for switching
+func (m model) tabBar() string {
+	tabs := append([]tab(nil), m.tabs...)
+	active := &tabs[m.activeTab]
+	active.currentRequest, active.loadedRequest = m.currentRequest, m.loadedRequest
+	active.loading, active.watch = m.loading, m.watch
+
+	var parts []string
+	for i, t := range tabs {
+		label := fmt.Sprintf("%d %s", i+1, tabTitle(t.currentRequest))
+		switch {
+		case t.loading:
+			label += " " + m.spinner.View()
+		case t.watch != nil && t.watch.running:
+			label += " ↻"
+		case t.loadedRequest.Path != "" && !requestsEqual(t.currentRequest, t.loadedRequest):
+			label += " ●"
+		}
+		if i == m.activeTab {
+			parts = append(parts, activeTabStyle.Render(label))
+		} else {
+			parts = append(parts, inactiveTabStyle.Render(label))
+		}
+	}
+	return strings.Join(parts, " ") + "\n"
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pressKey sends a key to the model
+func pressKey(m model, key string) (model, tea.Cmd) {
+	updated, cmd := m.Update(keyMsg(key))
+	return updated.(model), cmd
+}
+
+// TestTabsRunConcurrently tests that each tab keeps its own request and receives its own response
+func TestTabsRunConcurrently(t *testing.T) {
+	m := initialModel()
+	m.setRequest(HTTPRequest{Method: "GET", URL: "http://one.test/users"})
+	m, cmd := pressKey(m, "enter")
+	if !m.loading || cmd == nil {
+		t.Fatalf("Expected the first tab to be sending")
+	}
+	first := m.tabs[0].id
+
+	m, _ = pressKey(m, "t")
+	if len(m.tabs) != 2 || m.activeTab != 1 || m.currentRequest.URL != "" || m.loading {
+		t.Fatalf("Expected a new empty tab, got %d tabs with %q", len(m.tabs), m.currentRequest.URL)
+	}
+	m.setRequest(HTTPRequest{Method: "POST", URL: "http://two.test/orders"})
+	m, _ = pressKey(m, "enter")
+	second := m.tabs[1].id
+	if !m.loading || !m.tabs[0].loading {
+		t.Fatalf("Expected both tabs to be sending")
+	}
+
+	// The first tab's response arrives while the second is active
+	updated, _ := m.Update(responseMsg{tab: first, request: HTTPRequest{Method: "GET", URL: "http://one.test/users"},
+		response: HTTPResponse{StatusCode: 200, Status: "200 OK", Body: "users"}})
+	m = updated.(model)
+	if !m.loading || m.state != stateMain || m.currentRequest.URL != "http://two.test/orders" {
+		t.Errorf("Expected the active tab to be unaffected, got loading=%v state %d %s", m.loading, m.state, m.currentRequest.URL)
+	}
+	if m.tabs[0].loading || m.tabs[0].response.Body != "users" || m.tabs[0].state != stateViewResponse {
+		t.Errorf("Expected the first tab to receive its response, got %+v", m.tabs[0].response)
+	}
+
+	updated, _ = m.Update(responseMsg{tab: second, request: HTTPRequest{Method: "POST", URL: "http://two.test/orders"},
+		response: HTTPResponse{StatusCode: 201, Status: "201 Created", Body: "order"}})
+	m = updated.(model)
+	if m.loading || m.state != stateViewResponse || m.response.Body != "order" {
+		t.Errorf("Expected the second tab to show its response, got %q", m.response.Body)
+	}
+
+	m, _ = pressKey(m, "1")
+	if m.activeTab != 0 || m.response.Body != "users" || m.urlInput.Value() != "http://one.test/users" || m.state != stateViewResponse {
+		t.Errorf("Expected the first tab's request and response, got %q %q", m.urlInput.Value(), m.response.Body)
+	}
+	m, _ = pressKey(m, "tab")
+	if m.activeTab != 1 || m.response.Body != "order" {
+		t.Errorf("Expected tab to switch to the second tab, got %d", m.activeTab)
+	}
+}
+
+// TestTabsOpenCloseReorder tests that tabs are reordered and closed, and that closed tabs drop their responses
+func TestTabsOpenCloseReorder(t *testing.T) {
+	m := initialModel()
+	m.setRequest(HTTPRequest{Name: "List users", Method: "GET", URL: "http://x.test/users"})
+	m, _ = pressKey(m, "t")
+	m.setRequest(HTTPRequest{Method: "DELETE", URL: "http://x.test/users/1"})
+	m, _ = pressKey(m, "t")
+	if len(m.tabs) != 3 || m.activeTab != 2 {
+		t.Fatalf("Expected 3 tabs, got %d", len(m.tabs))
+	}
+
+	bar := m.tabBar()
+	if !strings.Contains(bar, "1 List users") || !strings.Contains(bar, "2 DELETE x.test/users/1") || !strings.Contains(bar, "3 New request") {
+		t.Errorf("Expected every tab in the bar, got %q", bar)
+	}
+	if !strings.HasPrefix(m.View(), bar) {
+		t.Errorf("Expected the tab bar at the top of the view")
+	}
+
+	m, _ = pressKey(m, "<")
+	m, _ = pressKey(m, "<")
+	if m.activeTab != 0 || m.tabs[1].currentRequest.Name != "List users" {
+		t.Errorf("Expected the new tab to move to the front, got active %d", m.activeTab)
+	}
+	m, _ = pressKey(m, "<")
+	if m.activeTab != 0 {
+		t.Errorf("Expected the first tab to stay first")
+	}
+
+	m, _ = pressKey(m, "2")
+	closed := m.tabs[1].id
+	m.loading = true
+	m, _ = pressKey(m, "ctrl+w")
+	if len(m.tabs) != 2 || m.currentRequest.Method != "DELETE" || m.loading {
+		t.Errorf("Expected the next tab to become active after closing, got %s with %d tabs", m.currentRequest.Method, len(m.tabs))
+	}
+	updated, _ := m.Update(responseMsg{tab: closed, response: HTTPResponse{StatusCode: 200, Body: "late"}})
+	if updated.(model).response.Body == "late" {
+		t.Errorf("Expected a response for a closed tab to be dropped")
+	}
+
+	m, _ = pressKey(m, "ctrl+w")
+	m, _ = pressKey(m, "ctrl+w")
+	if len(m.tabs) != 1 || m.status != "The last tab cannot be closed" {
+		t.Errorf("Expected the last tab to stay open, got %d tabs", len(m.tabs))
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Built-in theme names. themeAuto picks dark or light to suit the
+// terminal's background.
+const (
+	themeAuto         = "auto"
+	themeDark         = "dark"
+	themeLight        = "light"
+	themeHighContrast = "high-contrast"
+)
+
+// Theme holds the colours of the interactive client. Custom themes in the
+// config file set any of them over a built-in theme named by base.
+type Theme struct {
+	Base string `json:"base,omitempty"`
+
+	Heading       lipgloss.Color `json:"heading"`
+	Accent        lipgloss.Color `json:"accent"`
+	Muted         lipgloss.Color `json:"muted"`
+	Border        lipgloss.Color `json:"border"`
+	Focus         lipgloss.Color `json:"focus"`
+	TabText       lipgloss.Color `json:"tab_text"`
+	TabBackground lipgloss.Color `json:"tab_background"`
+
+	// Outcomes, also used for status codes: 2xx, 3xx, 4xx and 5xx
+	Success lipgloss.Color `json:"success"`
+	Info    lipgloss.Color `json:"info"`
+	Warning lipgloss.Color `json:"warning"`
+	Error   lipgloss.Color `json:"error"`
+
+	// JSON syntax highlighting
+	JSONKey         lipgloss.Color `json:"json_key"`
+	JSONString      lipgloss.Color `json:"json_string"`
+	JSONNumber      lipgloss.Color `json:"json_number"`
+	JSONLiteral     lipgloss.Color `json:"json_literal"`
+	JSONPunctuation lipgloss.Color `json:"json_punctuation"`
+}
+
+var darkTheme = Theme{
+	Heading:         "252",
+	Accent:          "170",
+	Muted:           "241",
+	Border:          "63",
+	Focus:           "205",
+	TabText:         "230",
+	TabBackground:   "62",
+	Success:         "42",
+	Info:            "39",
+	Warning:         "214",
+	Error:           "9",
+	JSONKey:         "75",
+	JSONString:      "114",
+	JSONNumber:      "215",
+	JSONLiteral:     "177",
+	JSONPunctuation: "245",
+}
+
+var lightTheme = Theme{
+	Heading:         "0",
+	Accent:          "127",
+	Muted:           "244",
+	Border:          "62",
+	Focus:           "161",
+	TabText:         "255",
+	TabBackground:   "62",
+	Success:         "28",
+	Info:            "25",
+	Warning:         "166",
+	Error:           "160",
+	JSONKey:         "25",
+	JSONString:      "28",
+	JSONNumber:      "130",
+	JSONLiteral:     "127",
+	JSONPunctuation: "242",
+}
+
+// highContrastTheme sticks to the bright basic colours, which terminals
+// render at full strength
+var highContrastTheme = Theme{
+	Heading:         "15",
+	Accent:          "13",
+	Muted:           "7",
+	Border:          "15",
+	Focus:           "11",
+	TabText:         "0",
+	TabBackground:   "11",
+	Success:         "10",
+	Info:            "14",
+	Warning:         "11",
+	Error:           "9",
+	JSONKey:         "14",
+	JSONString:      "10",
+	JSONNumber:      "11",
+	JSONLiteral:     "13",
+	JSONPunctuation: "15",
+}
+
+// builtinThemes are the themes that can be picked by name
+var builtinThemes = map[string]Theme{
+	themeDark:         darkTheme,
+	themeLight:        lightTheme,
+	themeHighContrast: highContrastTheme,
+}
+
+// theme is the active theme, set with applyTheme
+var theme Theme
+
+// Styles, set from the theme by applyTheme
+var (
+	titleStyle        lipgloss.Style
+	itemStyle         lipgloss.Style
+	selectedItemStyle lipgloss.Style
+	helpStyle         lipgloss.Style
+	urlInputStyle     lipgloss.Style
+	headerStyle       lipgloss.Style
+	focusedInputStyle lipgloss.Style
+	accentStyle       lipgloss.Style
+	successStyle      lipgloss.Style
+	warningStyle      lipgloss.Style
+	errorStyle        lipgloss.Style
+	focusedPaneStyle  lipgloss.Style
+	paneStyle         lipgloss.Style
+	activeTabStyle    lipgloss.Style
+	inactiveTabStyle  lipgloss.Style
+	paletteKindStyle  lipgloss.Style
+	paletteMatchStyle lipgloss.Style
+)
+
+func init() {
+	applyTheme(darkTheme)
+}
+
+// applyTheme makes t the active theme and restyles everything with it
+func applyTheme(t Theme) {
+	theme = t
+	titleStyle = lipgloss.NewStyle().MarginLeft(2).Bold(true).Foreground(t.Heading)
+	itemStyle = lipgloss.NewStyle().PaddingLeft(4)
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(t.Accent)
+	helpStyle = lipgloss.NewStyle().Foreground(t.Muted)
+	urlInputStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderForeground(t.Border)
+	headerStyle = lipgloss.NewStyle().Bold(true).Foreground(t.Heading)
+	focusedInputStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderForeground(t.Focus)
+	accentStyle = lipgloss.NewStyle().Foreground(t.Accent)
+	successStyle = lipgloss.NewStyle().Foreground(t.Success)
+	warningStyle = lipgloss.NewStyle().Foreground(t.Warning)
+	errorStyle = lipgloss.NewStyle().Foreground(t.Error)
+	focusedPaneStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Focus)
+	paneStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(t.Muted)
+	activeTabStyle = lipgloss.NewStyle().Padding(0, 1).Bold(true).Foreground(t.TabText).Background(t.TabBackground)
+	inactiveTabStyle = lipgloss.NewStyle().Padding(0, 1).Foreground(t.Muted)
+	paletteKindStyle = lipgloss.NewStyle().Width(12).Foreground(t.Muted)
+	paletteMatchStyle = lipgloss.NewStyle().Bold(true).Underline(true).Foreground(t.Accent)
+}
+
+// resolveTheme returns the configured theme. Custom themes are read over
+// their base, and auto asks hasDarkBackground which of dark and light to use.
+func (c Config) resolveTheme(hasDarkBackground func() bool) (Theme, error) {
+	name := c.Theme
+	if name == "" {
+		name = themeAuto
+	}
+	if raw, ok := c.Themes[name]; ok {
+		var custom Theme
+		if err := json.Unmarshal(raw, &custom); err != nil {
+			return Theme{}, fmt.Errorf("theme %s: %w", name, err)
+		}
+		base, err := builtinTheme(custom.Base, hasDarkBackground)
+		if err != nil {
+			return Theme{}, fmt.Errorf("theme %s: %w", name, err)
+		}
+		// Colours the custom theme leaves out keep those of the base
+		if err := json.Unmarshal(raw, &base); err != nil {
+			return Theme{}, fmt.Errorf("theme %s: %w", name, err)
+		}
+		return base, nil
+	}
+	return builtinTheme(name, hasDarkBackground)
+}
+
+// builtinTheme returns a built-in theme by name, detecting the background for auto
+func builtinTheme(name string, hasDarkBackground func() bool) (Theme, error) {
+	if name == "" || name == themeAuto {
+		if hasDarkBackground() {
+			return darkTheme, nil
+		}
+		return lightTheme, nil
+	}
+	t, ok := builtinThemes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, use %s, %s, %s, %s or a theme defined in themes",
+			name, themeAuto, themeDark, themeLight, themeHighContrast)
+	}
+	return t, nil
+}
+
+// themedDelegate returns a list delegate drawing the selected item in the theme's accent
+func themedDelegate() list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(theme.Accent).BorderForeground(theme.Accent)
+	d.Styles.SelectedDesc = d.Styles.SelectedDesc.Foreground(theme.Accent).BorderForeground(theme.Accent)
+	return d
+}
+
+// themedList creates a list of items styled with the theme
+func themedList(items []list.Item) list.Model {
+	l := list.New(items, themedDelegate(), 0, 0)
+	l.Styles.Title = l.Styles.Title.Foreground(theme.TabText).Background(theme.TabBackground)
+	return l
+}
+
+// themedTableStyles returns table styles highlighting the selected row with the theme
+func themedTableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.BorderForeground(theme.Muted).Bold(true)
+	s.Selected = s.Selected.Foreground(theme.TabText).Background(theme.TabBackground)
+	return s
+}
+
+// statusStyle colours a status code by its class; 0 means the request failed
+func statusStyle(status int) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(statusColor(status))
+}
+
+// statusColor is the colour of a status code: success, info for redirects,
+// warning for client errors and error for server and network errors
+func statusColor(status int) lipgloss.Color {
+	switch {
+	case status == 0 || status >= http.StatusInternalServerError:
+		return theme.Error
+	case status >= http.StatusBadRequest:
+		return theme.Warning
+	case status >= http.StatusMultipleChoices:
+		return theme.Info
+	default:
+		return theme.Success
+	}
+}
+
+// maxHighlightSize is the largest body highlighted; styling every token of
+// bigger ones would stall the UI on each response and watch tick
+const maxHighlightSize = 64 << 10
+
+// highlightJSON colours the keys, strings, numbers, literals and punctuation
+// of a JSON document, keeping its layout. Other text, and documents over
+// maxHighlightSize, are returned unchanged.
+func highlightJSON(s string) string {
+	if len(s) > maxHighlightSize || !json.Valid([]byte(s)) {
+		return s
+	}
+	keyStyle := lipgloss.NewStyle().Foreground(theme.JSONKey)
+	stringStyle := lipgloss.NewStyle().Foreground(theme.JSONString)
+	numberStyle := lipgloss.NewStyle().Foreground(theme.JSONNumber)
+	literalStyle := lipgloss.NewStyle().Foreground(theme.JSONLiteral)
+	punctuationStyle := lipgloss.NewStyle().Foreground(theme.JSONPunctuation)
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			// Find the closing quote, skipping escaped characters
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+			// A string followed by a colon is a key
+			rest := strings.TrimLeft(s[j:], " \t\r\n")
+			if strings.HasPrefix(rest, ":") {
+				b.WriteString(keyStyle.Render(s[i:j]))
+			} else {
+				b.WriteString(stringStyle.Render(s[i:j]))
+			}
+			i = j
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789.eE+-", s[j]) >= 0 {
+				j++
+			}
+			b.WriteString(numberStyle.Render(s[i:j]))
+			i = j
+		case c == 't' || c == 'f' || c == 'n':
+			j := i + 1
+			for j < len(s) && s[j] >= 'a' && s[j] <= 'z' {
+				j++
+			}
+			b.WriteString(literalStyle.Render(s[i:j]))
+			i = j
+		case strings.IndexByte("{}[],:", c) >= 0:
+			b.WriteString(punctuationStyle.Render(string(c)))
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
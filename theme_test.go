@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// TestResolveTheme tests picking built-in and custom themes and detecting the background
+func TestResolveTheme(t *testing.T) {
+	dark := func() bool { return true }
+	light := func() bool { return false }
+
+	tests := []struct {
+		config   Config
+		detect   func() bool
+		expected Theme
+	}{
+		{Config{}, dark, darkTheme},
+		{Config{}, light, lightTheme},
+		{Config{Theme: "auto"}, light, lightTheme},
+		{Config{Theme: "dark"}, light, darkTheme},
+		{Config{Theme: "high-contrast"}, light, highContrastTheme},
+	}
+	for _, tt := range tests {
+		got, err := tt.config.resolveTheme(tt.detect)
+		if err != nil || got != tt.expected {
+			t.Errorf("Expected %+v for theme %q, got %+v, %v", tt.expected, tt.config.Theme, got, err)
+		}
+	}
+
+	// Custom themes keep the colours of their base they leave out
+	config := Config{Theme: "mine", Themes: map[string]json.RawMessage{
+		"mine": json.RawMessage(`{"base": "light", "accent": "#d33682", "json_key": "33"}`),
+	}}
+	got, err := config.resolveTheme(dark)
+	if err != nil {
+		t.Fatalf("Expected the custom theme, got %v", err)
+	}
+	if got.Accent != "#d33682" || got.JSONKey != "33" || got.Error != lightTheme.Error || got.Heading != lightTheme.Heading {
+		t.Errorf("Expected the custom colours over the light theme, got %+v", got)
+	}
+
+	if _, err := (Config{Theme: "solarized"}).resolveTheme(dark); err == nil {
+		t.Errorf("Expected an error for an unknown theme")
+	}
+	config.Themes["mine"] = json.RawMessage(`{"base": "sepia"}`)
+	if _, err := config.resolveTheme(dark); err == nil {
+		t.Errorf("Expected an error for an unknown base theme")
+	}
+}
+
+// TestThemeColours tests that status codes and JSON bodies are coloured from the theme
+func TestThemeColours(t *testing.T) {
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer applyTheme(theme)
+	applyTheme(darkTheme)
+
+	statuses := map[int]lipgloss.Color{200: darkTheme.Success, 301: darkTheme.Info, 404: darkTheme.Warning, 503: darkTheme.Error, 0: darkTheme.Error}
+	for status, expected := range statuses {
+		if got := statusColor(status); got != expected {
+			t.Errorf("Expected colour %s for status %d, got %s", expected, status, got)
+		}
+	}
+
+	body := "{\n  \"name\": \"a \\\"quoted\\\" word\",\n  \"count\": -1.5e3,\n  \"ok\": true,\n  \"tags\": [null]\n}"
+	highlighted := highlightJSON(body)
+	if stripped := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(highlighted, ""); stripped != body {
+		t.Errorf("Expected the layout to be kept, got:\n%s", stripped)
+	}
+	for _, expected := range []string{
+		"\x1b[38;5;75m\"name\"",
+		"\x1b[38;5;114m\"a \\\"quoted\\\" word\"",
+		"\x1b[38;5;215m-1.5e3",
+		"\x1b[38;5;177mtrue",
+		"\x1b[38;5;177mnull",
+	} {
+		if !strings.Contains(highlighted, expected) {
+			t.Errorf("Expected %q in the highlighted body, got %q", expected, highlighted)
+		}
+	}
+
+	if got := highlightJSON("not json"); got != "not json" {
+		t.Errorf("Expected other text to be unchanged, got %q", got)
+	}
+	large := `["` + strings.Repeat("x", maxHighlightSize) + `"]`
+	if got := highlightJSON(large); got != large {
+		t.Errorf("Expected a body over the size limit to be unchanged")
+	}
+}
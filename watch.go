@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultWatchSpec is offered when watch mode is started
+const defaultWatchSpec = "5s"
+
+// maxWatchSamples bounds the samples drawn in the sparkline and timeline
+const maxWatchSamples = 60
+
+// sparkBlocks are the bars of the latency sparkline, shortest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// watchSample is one response received in watch mode
+type watchSample struct {
+	Time     time.Time
+	Status   int
+	Duration time.Duration
+	Error    string
+	Change   string
+}
+
+// watcher re-sends the current request every interval until stopped or
+// until its condition holds
+type watcher struct {
+	interval time.Duration
+	until    *Assertion
+	ignore   []string
+	running  bool
+	reason   string
+	samples  []watchSample
+	last     *HTTPResponse
+	changed  time.Time
+}
+
+// watchResponseMsg carries a response received in watch mode
+type watchResponseMsg struct {
+	watch *watcher
+	resp  HTTPResponse
+	err   error
+}
+
+// watchTickMsg asks for the next send of a watch
+type watchTickMsg struct{ watch *watcher }
+
+// parseWatchSpec reads an interval, optionally followed by "until" and a
+// stop condition, e.g. "10s until status 200". A bare number is seconds.
+func parseWatchSpec(s string) (*watcher, error) {
+	spec, condition, hasCondition := strings.Cut(strings.TrimSpace(s), " until ")
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = defaultWatchSpec
+	}
+	interval, err := time.ParseDuration(spec)
+	if secs, convErr := strconv.ParseFloat(spec, 64); convErr == nil {
+		interval, err = time.Duration(secs*float64(time.Second)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid interval %q", spec)
+	}
+	if interval < 100*time.Millisecond {
+		return nil, fmt.Errorf("interval %s is too short", interval)
+	}
+
+	w := &watcher{interval: interval, running: true}
+	if hasCondition {
+		a, err := parseWatchCondition(condition)
+		if err != nil {
+			return nil, err
+		}
+		w.until = &a
+	}
+	return w, nil
+}
+
+// parseWatchCondition reads a stop condition as an assertion:
+//
+//	status 200, status == 200
+//	body /pattern/, body matches pattern
+//	header Name, header Name == value
+//	$.path == value
+func parseWatchCondition(s string) (Assertion, error) {
+	s = strings.TrimSpace(s)
+	field, rest, _ := strings.Cut(s, " ")
+	rest = strings.TrimSpace(rest)
+	value := strings.TrimSpace(strings.TrimPrefix(rest, "=="))
+
+	switch {
+	case field == "status":
+		if _, err := strconv.Atoi(value); err != nil {
+			return Assertion{}, fmt.Errorf("invalid status %q", value)
+		}
+		return Assertion{Type: assertStatus, Value: value}, nil
+
+	case field == "body":
+		pattern := strings.TrimSpace(strings.TrimPrefix(rest, "matches"))
+		if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			pattern = pattern[1 : len(pattern)-1]
+		}
+		if _, err := regexp.Compile(pattern); err != nil || pattern == "" {
+			return Assertion{}, fmt.Errorf("invalid body pattern %q", pattern)
+		}
+		return Assertion{Type: assertBodyRegex, Value: pattern}, nil
+
+	case field == "header":
+		name, expected, _ := strings.Cut(rest, "==")
+		if strings.TrimSpace(name) == "" {
+			return Assertion{}, fmt.Errorf("expected a header name")
+		}
+		return Assertion{Type: assertHeader, Name: strings.TrimSpace(name), Value: strings.TrimSpace(expected)}, nil
+
+	case strings.HasPrefix(field, "$"):
+		path, expected, ok := strings.Cut(s, "==")
+		if !ok {
+			return Assertion{}, fmt.Errorf("expected %s == value", field)
+		}
+		return Assertion{Type: assertJSONPath, Name: strings.TrimSpace(path), Value: strings.TrimSpace(expected)}, nil
+	}
+	return Assertion{}, fmt.Errorf("unknown condition %q, use status, body, header or a JSON path", s)
+}
+
+// watchSend sends the request for a watch
+func watchSend(w *watcher, req HTTPRequest) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := executeRequest(req)
+		return watchResponseMsg{watch: w, resp: resp, err: err}
+	}
+}
+
+func watchTick(w *watcher) tea.Cmd {
+	return tea.Tick(w.interval, func(time.Time) tea.Msg { return watchTickMsg{watch: w} })
+}
+
+// record adds a response to the watch, noting how it differs from the
+// previous one, and stops the watch when its condition holds
+func (w *watcher) record(resp HTTPResponse, err error) watchSample {
+	sample := watchSample{Time: time.Now(), Status: resp.StatusCode, Duration: resp.Duration}
+	if err != nil {
+		sample.Error = err.Error()
+	} else {
+		if w.last != nil {
+			sample.Change = describeChange(*w.last, resp, w.ignore)
+		}
+		w.last = &resp
+	}
+	if sample.Change != "" {
+		w.changed = sample.Time
+	}
+
+	w.samples = append(w.samples, sample)
+	if len(w.samples) > maxWatchSamples {
+		w.samples = w.samples[len(w.samples)-maxWatchSamples:]
+	}
+
+	if w.until != nil && err == nil && evaluateAssertion(*w.until, resp) == nil {
+		w.running = false
+		w.reason = "Stopped: " + w.until.String()
+	}
+	return sample
+}
+
+// describeChange summarises how the status or body changed, ignoring
+// headers, or returns "" when they did not
+func describeChange(old, new HTTPResponse, ignore []string) string {
+	old.Headers, new.Headers = nil, nil
+	d := diffResponses(old, new, ignore)
+	switch {
+	case d.Status != nil:
+		return fmt.Sprintf("status %d → %d", old.StatusCode, new.StatusCode)
+	case len(d.Body) == 0:
+		return ""
+	case !d.JSON:
+		return "body changed"
+	case len(d.Body) == 1:
+		return "body changed (1 difference)"
+	}
+	return fmt.Sprintf("body changed (%d differences)", len(d.Body))
+}
+
+// sparkline draws durations as bars scaled between the shortest and longest
+func sparkline(durations []time.Duration) string {
+	if len(durations) == 0 {
+		return ""
+	}
+	lo, hi := durations[0], durations[0]
+	for _, d := range durations {
+		lo, hi = min(lo, d), max(hi, d)
+	}
+	var b strings.Builder
+	for _, d := range durations {
+		i := 0
+		if hi > lo {
+			i = int((d - lo) * time.Duration(len(sparkBlocks)-1) / (hi - lo))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// view renders the watch panel shown above the response
+func (w *watcher) view() string {
+	state := "Watching every " + w.interval.String()
+	if w.until != nil {
+		state += " until " + w.until.String()
+	}
+	if !w.running {
+		state = "Stopped watching"
+		if w.reason != "" {
+			state = w.reason
+		}
+	}
+	s := fmt.Sprintf("  %s • %d sent", state, len(w.samples))
+	if !w.changed.IsZero() {
+		s += " • last change " + w.changed.Local().Format("15:04:05")
+	}
+	s += "\n"
+
+	var durations []time.Duration
+	var timeline strings.Builder
+	for _, sample := range w.samples {
+		if sample.Error == "" {
+			durations = append(durations, sample.Duration)
+		}
+		block := "█"
+		if sample.Change != "" {
+			block = "▲"
+		}
+		timeline.WriteString(statusStyle(sample.Status).Render(block))
+	}
+	if len(durations) > 0 {
+		lo, hi := durations[0], durations[0]
+		for _, d := range durations {
+			lo, hi = min(lo, d), max(hi, d)
+		}
+		s += fmt.Sprintf("  Latency  %s  %dms–%dms\n", sparkline(durations), lo.Milliseconds(), hi.Milliseconds())
+	}
+	s += "  Status   " + timeline.String() + "\n"
+
+	if n := len(w.samples); n > 0 {
+		last := w.samples[n-1]
+		switch {
+		case last.Error != "":
+			s += errorStyle.Render("  Error: "+last.Error) + "\n"
+		case last.Change != "":
+			s += warningStyle.Bold(true).Render("  Changed: "+last.Change) + "\n"
+		}
+	}
+	return s
+}
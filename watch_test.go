@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestParseWatchSpec tests that intervals and stop conditions are parsed
+func TestParseWatchSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		interval time.Duration
+		until    *Assertion
+		wantErr  bool
+	}{
+		{"5s", 5 * time.Second, nil, false},
+		{"2", 2 * time.Second, nil, false},
+		{"", 5 * time.Second, nil, false},
+		{"1m until status 200", time.Minute, &Assertion{Type: assertStatus, Value: "200"}, false},
+		{"3s until status == 503", 3 * time.Second, &Assertion{Type: assertStatus, Value: "503"}, false},
+		{"1s until body /ready|ok/", time.Second, &Assertion{Type: assertBodyRegex, Value: "ready|ok"}, false},
+		{"1s until body matches deployed v2", time.Second, &Assertion{Type: assertBodyRegex, Value: "deployed v2"}, false},
+		{"1s until header X-Version == 2", time.Second, &Assertion{Type: assertHeader, Name: "X-Version", Value: "2"}, false},
+		{`1s until $.status == "up"`, time.Second, &Assertion{Type: assertJSONPath, Name: "$.status", Value: `"up"`}, false},
+		{"soon", 0, nil, true},
+		{"10ms", 0, nil, true},
+		{"1s until status ok", 0, nil, true},
+		{"1s until latency 5", 0, nil, true},
+	}
+	for _, tt := range tests {
+		w, err := parseWatchSpec(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected an error for %q", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", tt.spec, err)
+			continue
+		}
+		if w.interval != tt.interval {
+			t.Errorf("Expected interval %s for %q, got %s", tt.interval, tt.spec, w.interval)
+		}
+		if (w.until == nil) != (tt.until == nil) || (w.until != nil && w.until.String() != tt.until.String()) {
+			t.Errorf("Expected condition %v for %q, got %v", tt.until, tt.spec, w.until)
+		}
+	}
+}
+
+// TestWatcherRecord tests that changes are described and the watch stops once its condition holds
+func TestWatcherRecord(t *testing.T) {
+	w, err := parseWatchSpec(`1s until $.state == "up"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.ignore = []string{"$.time"}
+
+	responses := []HTTPResponse{
+		{StatusCode: 503, Body: `{"state": "starting", "time": 1}`, Duration: 10 * time.Millisecond},
+		{StatusCode: 503, Body: `{"state": "starting", "time": 2}`, Duration: 30 * time.Millisecond},
+		{StatusCode: 200, Body: `{"state": "starting", "time": 3}`, Duration: 20 * time.Millisecond},
+		{StatusCode: 200, Body: `{"state": "up", "time": 4}`, Duration: 20 * time.Millisecond},
+	}
+	want := []string{"", "", "status 503 → 200", "body changed (1 difference)"}
+	for i, resp := range responses {
+		if !w.running {
+			t.Fatalf("Expected the watch to run until the condition holds, stopped after %d", i)
+		}
+		if got := w.record(resp, nil).Change; got != want[i] {
+			t.Errorf("Expected change %q for response %d, got %q", want[i], i, got)
+		}
+	}
+	if w.running || !strings.Contains(w.reason, `$.state == "up"`) {
+		t.Errorf("Expected the watch to stop on its condition, got running=%v reason %q", w.running, w.reason)
+	}
+
+	view := w.view()
+	if !strings.Contains(w.view(), "4 sent") || !strings.Contains(view, "10ms–30ms") || !strings.Contains(view, "Changed: body changed") {
+		t.Errorf("Expected counts, latency range and the last change in the panel, got:\n%s", view)
+	}
+}
+
+// TestSparkline tests that durations are scaled between the shortest and longest
+func TestSparkline(t *testing.T) {
+	got := sparkline([]time.Duration{10 * time.Millisecond, 80 * time.Millisecond, 45 * time.Millisecond, 10 * time.Millisecond})
+	if got != "▁█▄▁" {
+		t.Errorf("Expected ▁█▄▁, got %s", got)
+	}
+	if got := sparkline([]time.Duration{time.Second, time.Second}); got != "▁▁" {
+		t.Errorf("Expected flat bars for equal durations, got %s", got)
+	}
+	if sparkline(nil) != "" {
+		t.Errorf("Expected no bars without durations")
+	}
+}
+
+// TestWatchModel tests that watch mode re-sends on each tick and ignores stale messages
+func TestWatchModel(t *testing.T) {
+	m := initialModel()
+	m.setRequest(HTTPRequest{Method: "GET", URL: "http://x.test/health"})
+	m.response = HTTPResponse{StatusCode: 503, Status: "503 Service Unavailable", Body: "down"}
+	m.state = stateViewResponse
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("W")})
+	m = updated.(model)
+	if m.state != stateWatchConfig {
+		t.Fatalf("Expected the watch prompt, got state %d", m.state)
+	}
+	m.promptInput.SetValue("2s until status 200")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != stateViewResponse || m.watch == nil || cmd == nil {
+		t.Fatalf("Expected watching to start with a send")
+	}
+	w := m.watch
+
+	updated, cmd = m.Update(watchResponseMsg{watch: w, resp: HTTPResponse{StatusCode: 503, Status: "503 Service Unavailable", Body: "still down"}})
+	m = updated.(model)
+	if cmd == nil || !w.running {
+		t.Errorf("Expected the next send to be scheduled")
+	}
+	if m.response.Body != "still down" || w.samples[0].Change != "body changed" {
+		t.Errorf("Expected the response to be shown and compared with the previous one, got %+v", w.samples)
+	}
+	if _, cmd := m.Update(watchTickMsg{watch: w}); cmd == nil {
+		t.Errorf("Expected a tick to re-send the request")
+	}
+
+	updated, cmd = m.Update(watchResponseMsg{watch: w, resp: HTTPResponse{StatusCode: 200, Status: "200 OK", Body: "up"}})
+	m = updated.(model)
+	if w.running || cmd != nil {
+		t.Errorf("Expected the watch to stop on status 200")
+	}
+	if !strings.Contains(m.View(), "Stopped: status == 200") {
+		t.Errorf("Expected the stop reason in the view")
+	}
+	if _, cmd := m.Update(watchTickMsg{watch: w}); cmd != nil {
+		t.Errorf("Expected ticks of a stopped watch to be ignored")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(model).watch != nil {
+		t.Errorf("Expected leaving the response to end the watch")
+	}
+}
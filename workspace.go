@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// A workspace is a directory holding requests/, environments/, history/,
+// reports/ and exports/. whelm uses the first of:
+//
+//   - the directory given with --workspace (or its .whelm/ subdirectory)
+//   - a .whelm/ directory in the current directory or any parent
+//   - the current directory, when it already holds requests/ or environments/
+//   - the personal workspace in $XDG_DATA_HOME/whelm
+//
+// When a project workspace is used, the personal requests and environments
+// are shown next to the project's, under personalFolder.
+
+// Workspace kinds
+const (
+	workspaceProject  = "project"
+	workspacePersonal = "personal"
+)
+
+// workspaceDirName marks the root of a project
+const workspaceDirName = ".whelm"
+
+// personalFolder is the top-level folder the personal requests are shown
+// under while a project workspace is active
+const personalFolder = "~personal"
+
+// Workspace is a directory whelm keeps its files in
+type Workspace struct {
+	Kind string
+	Root string
+}
+
+// workspace is the active workspace; its Root is empty until useWorkspace is called
+var workspace Workspace
+
+// Personal requests and environments shown alongside a project, empty when
+// the active workspace is the personal one
+var (
+	personalRequestsDir     string
+	personalEnvironmentsDir string
+)
+
+// personalWorkspaceRoot returns the personal workspace directory, following
+// the XDG base directory spec
+func personalWorkspaceRoot() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "whelm"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "whelm"), nil
+}
+
+// discoverWorkspace finds the workspace for a run started in dir. override
+// is the value of --workspace, empty when not given.
+func discoverWorkspace(override, dir string) (Workspace, error) {
+	if override != "" {
+		root, err := filepath.Abs(override)
+		if err != nil {
+			return Workspace{}, err
+		}
+		if isDir(filepath.Join(root, workspaceDirName)) {
+			root = filepath.Join(root, workspaceDirName)
+		} else if !isDir(root) {
+			return Workspace{}, fmt.Errorf("workspace %s is not a directory", override)
+		}
+		return Workspace{Kind: workspaceProject, Root: root}, nil
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return Workspace{}, err
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if isDir(filepath.Join(d, workspaceDirName)) {
+			return Workspace{Kind: workspaceProject, Root: filepath.Join(d, workspaceDirName)}, nil
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	// Directories laid out before workspaces existed keep working
+	if isDir(filepath.Join(dir, "requests")) || isDir(filepath.Join(dir, "environments")) {
+		return Workspace{Kind: workspaceProject, Root: dir}, nil
+	}
+
+	root, err := personalWorkspaceRoot()
+	if err != nil {
+		return Workspace{}, err
+	}
+	return Workspace{Kind: workspacePersonal, Root: root}, nil
+}
+
+// useWorkspace points every file location at ws. personalRoot is the
+// personal workspace shown alongside a project, or empty for none.
+func useWorkspace(ws Workspace, personalRoot string) {
+	workspace = ws
+	requestsDir = filepath.Join(ws.Root, "requests")
+	environmentsDir = filepath.Join(ws.Root, "environments")
+	historyFile = filepath.Join(ws.Root, "history", "history.jsonl")
+	reportsDir = filepath.Join(ws.Root, "reports")
+	exportsDir = filepath.Join(ws.Root, "exports")
+
+	personalRequestsDir, personalEnvironmentsDir = "", ""
+	if ws.Kind != workspacePersonal && personalRoot != "" && filepath.Clean(personalRoot) != filepath.Clean(ws.Root) {
+		personalRequestsDir = filepath.Join(personalRoot, "requests")
+		personalEnvironmentsDir = filepath.Join(personalRoot, "environments")
+	}
+}
+
+// setupWorkspace discovers the workspace for the current directory and uses it
+func setupWorkspace(override string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	ws, err := discoverWorkspace(override, wd)
+	if err != nil {
+		return err
+	}
+	personal, err := personalWorkspaceRoot()
+	if err != nil {
+		personal = ""
+	}
+	useWorkspace(ws, personal)
+	return nil
+}
+
+// splitWorkspaceFlag removes a leading --workspace DIR (or --workspace=DIR)
+// from the command line
+func splitWorkspaceFlag(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+	name, value, hasValue := strings.Cut(args[0], "=")
+	if name != "--workspace" && name != "-workspace" {
+		return "", args, nil
+	}
+	if hasValue {
+		return value, args[1:], nil
+	}
+	if len(args) < 2 {
+		return "", nil, fmt.Errorf("flag needs an argument: %s", name)
+	}
+	return args[1], args[2:], nil
+}
+
+// folderDir returns the directory a request folder is stored in
+func folderDir(folder string) string {
+	if personalRequestsDir != "" && inFolder(folder, personalFolder) {
+		rel := strings.TrimPrefix(strings.TrimPrefix(folder, personalFolder), "/")
+		return filepath.Join(personalRequestsDir, filepath.FromSlash(rel))
+	}
+	return filepath.Join(requestsDir, filepath.FromSlash(folder))
+}
+
+// collectionDir returns the requests directory a folder belongs to
+func collectionDir(folder string) string {
+	if personalRequestsDir != "" && inFolder(folder, personalFolder) {
+		return personalRequestsDir
+	}
+	return requestsDir
+}
+
+// readPersonalRequests reads the personal requests with their folders
+// placed under personalFolder
+func readPersonalRequests(requests []HTTPRequest, folders map[string]FolderConfig) ([]HTTPRequest, error) {
+	personal, personalFolders, err := readRequestTree(personalRequestsDir)
+	if err != nil {
+		return requests, err
+	}
+	for name, config := range personalFolders {
+		folders[path.Join(personalFolder, name)] = config
+	}
+	for _, req := range personal {
+		req.Folder = path.Join(personalFolder, req.Folder)
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// workspaceLabel describes the active workspace for display
+func workspaceLabel() string {
+	if workspace.Root == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s)", workspace.Kind, displayPath(workspace.Root))
+}
+
+// displayPath shortens paths in the home directory to ~/...
+func displayPath(p string) string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		if rel, err := filepath.Rel(home, p); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return p
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// workspaceIgnore keeps generated files of a project workspace out of version control
+const workspaceIgnore = "history/\nreports/\nexports/\n"
+
+// initCommand creates a project workspace in the current directory
+func initCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintf(stderr, "error: init takes no arguments\n")
+		return 2
+	}
+	root := workspaceDirName
+	if isDir(root) {
+		fmt.Fprintf(stderr, "error: %s already exists\n", root)
+		return 1
+	}
+	for _, dir := range []string{"requests", "environments"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(workspaceIgnore), 0644); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Created workspace %s\n", root)
+	return 0
+}
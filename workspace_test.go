@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useTestWorkspace switches to a workspace for the duration of a test
+func useTestWorkspace(t *testing.T, ws Workspace, personalRoot string) {
+	t.Helper()
+	saved := []string{requestsDir, environmentsDir, historyFile, reportsDir, exportsDir, personalRequestsDir, personalEnvironmentsDir}
+	savedWorkspace := workspace
+	t.Cleanup(func() {
+		requestsDir, environmentsDir, historyFile, reportsDir, exportsDir = saved[0], saved[1], saved[2], saved[3], saved[4]
+		personalRequestsDir, personalEnvironmentsDir = saved[5], saved[6]
+		workspace = savedWorkspace
+	})
+	useWorkspace(ws, personalRoot)
+}
+
+// TestDiscoverWorkspace tests that the workspace is found by flag, by walking up to .whelm, by legacy layout and in XDG_DATA_HOME
+func TestDiscoverWorkspace(t *testing.T) {
+	root := t.TempDir()
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+
+	project := filepath.Join(root, "project")
+	nested := filepath.Join(project, "src", "api")
+	legacy := filepath.Join(root, "legacy")
+	for _, dir := range []string{filepath.Join(project, ".whelm"), nested, filepath.Join(legacy, "requests"), filepath.Join(root, "empty")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, override, dir string
+		want                Workspace
+	}{
+		{"nested", "", nested, Workspace{workspaceProject, filepath.Join(project, ".whelm")}},
+		{"override", project, filepath.Join(root, "empty"), Workspace{workspaceProject, filepath.Join(project, ".whelm")}},
+		{"legacy", "", legacy, Workspace{workspaceProject, legacy}},
+		{"personal", "", filepath.Join(root, "empty"), Workspace{workspacePersonal, filepath.Join(data, "whelm")}},
+	}
+	for _, tt := range tests {
+		got, err := discoverWorkspace(tt.override, tt.dir)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := discoverWorkspace(filepath.Join(root, "missing"), root); err == nil {
+		t.Errorf("Expected an error for a missing --workspace directory")
+	}
+}
+
+// TestSplitWorkspaceFlag tests that a leading --workspace flag is removed from the command line
+func TestSplitWorkspaceFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     string
+		wantRest []string
+	}{
+		{[]string{"--workspace", "dir", "run", "-env", "dev"}, "dir", []string{"run", "-env", "dev"}},
+		{[]string{"--workspace=dir"}, "dir", []string{}},
+		{[]string{"run", "--workspace", "dir"}, "", []string{"run", "--workspace", "dir"}},
+	}
+	for _, tt := range tests {
+		got, rest, err := splitWorkspaceFlag(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || !reflect.DeepEqual(rest, tt.wantRest) {
+			t.Errorf("Expected %q %v for %v, got %q %v", tt.want, tt.wantRest, tt.args, got, rest)
+		}
+	}
+	if _, _, err := splitWorkspaceFlag([]string{"--workspace"}); err == nil {
+		t.Errorf("Expected an error when --workspace has no value")
+	}
+}
+
+// TestPersonalRequests tests that personal requests are listed under their own folder and saved, moved and trashed in the personal workspace
+func TestPersonalRequests(t *testing.T) {
+	project, personal := t.TempDir(), t.TempDir()
+	useTestWorkspace(t, Workspace{workspaceProject, project}, personal)
+
+	runCmd(t, saveRequest(HTTPRequest{Name: "Shared", Method: "GET", URL: "http://x.test"}))
+	runCmd(t, saveRequest(HTTPRequest{Name: "Mine", Method: "GET", URL: "http://x.test", Folder: personalFolder + "/scratch"}))
+	if err := os.MkdirAll(filepath.Join(personal, "environments"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeIndentedJSON(filepath.Join(personal, "environments", "local.json"), Environment{Variables: map[string]string{"token": "t"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(personal, "requests", "scratch", "mine.json")); err != nil {
+		t.Errorf("Expected the personal request in the personal workspace: %v", err)
+	}
+
+	requests, folders, err := readSavedRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || requests[1].Folder != personalFolder+"/scratch" {
+		t.Fatalf("Expected the project request and the personal one, got %+v", requests)
+	}
+	items := buildRequestTree(requests, folders, map[string]bool{})
+	if items[0].label != "personal" || !strings.Contains(items[0].Description(), "1 requests") {
+		t.Errorf("Expected a personal folder row first, got %q %q", items[0].Title(), items[0].Description())
+	}
+
+	envs, err := readEnvironments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 1 || envs[0].Label() != "local (personal)" {
+		t.Errorf("Expected the personal environment, got %+v", envs)
+	}
+
+	// Moving a personal request into the project
+	moved := runCmd(t, moveRequest(requests[1], "team")).(requestMovedMsg)
+	if want := filepath.Join(project, "requests", "team", "mine.json"); moved.request.Path != want {
+		t.Errorf("Expected the request at %s, got %s", want, moved.request.Path)
+	}
+
+	// Trashing a personal request keeps it in the personal workspace
+	mine := runCmd(t, saveRequest(HTTPRequest{Name: "Other", Method: "GET", URL: "http://x.test", Folder: personalFolder})).(requestSavedMsg)
+	trashed := runCmd(t, trashRequest(HTTPRequest(mine))).(requestTrashedMsg)
+	if !strings.HasPrefix(trashed.trashedPath, filepath.Join(personal, "requests", ".trash")) {
+		t.Errorf("Expected the personal trash, got %s", trashed.trashedPath)
+	}
+}
+
+// TestInitCommand tests that init creates a .whelm workspace that is then discovered
+func TestInitCommand(t *testing.T) {
+	dir := chdirTemp(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"init"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected init to succeed, got %d: %s", code, stderr.String())
+	}
+	if code := runCLI([]string{"init"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected init to fail when the workspace exists, got %d", code)
+	}
+
+	ws, err := discoverWorkspace("", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Kind != workspaceProject || filepath.Base(ws.Root) != ".whelm" {
+		t.Errorf("Expected the new project workspace, got %+v", ws)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ".whelm", ".gitignore"))
+	if !strings.Contains(string(data), "history/") {
+		t.Errorf("Expected history to be ignored, got %q", data)
+	}
+}